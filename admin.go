@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/database"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
+)
+
+const adminUsage = `usage: FoodHaven-Backend admin [-config file] <email>
+
+Grants the admin role to the registered user with the given email. Use it to
+create the first admin; admins manage other roles through /private/admin.`
+
+// runAdmin implements the `admin` subcommand. Like migrate it only needs the
+// database settings.
+func runAdmin(args []string) {
+	flags := flag.NewFlagSet("admin", flag.ExitOnError)
+	configPath := flags.String("config", "", "path to a YAML or JSON config file (defaults to $CONFIG_FILE)")
+	flags.Usage = func() { fmt.Fprintln(os.Stderr, adminUsage) }
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, adminUsage)
+		os.Exit(2)
+	}
+	email := flags.Arg(0)
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := cfg.Database.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	ctx := context.Background()
+	dbClient, err := database.Open(ctx, cfg.Database)
+	if err != nil {
+		log.Fatalf("Database connection error: %v", err)
+	}
+	defer dbClient.Close()
+
+	users := store.NewPostgres(dbClient).Users
+	user, err := users.GetByEmail(ctx, email)
+	if err != nil {
+		log.Fatalf("Failed to find user %q: %v", email, err)
+	}
+	if user.Role == models.RoleAdmin {
+		log.Printf("User %d (%s) is already an admin", user.Id, email)
+		return
+	}
+	if err := users.SetRole(ctx, user.Id, models.RoleAdmin); err != nil {
+		log.Fatalf("Failed to grant admin role: %v", err)
+	}
+	log.Printf("User %d (%s) is now an admin; was %s", user.Id, email, user.Role)
+}
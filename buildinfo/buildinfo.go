@@ -0,0 +1,67 @@
+// Package buildinfo describes the running binary. Commit and BuildTime are
+// stamped at link time, e.g.
+//
+//	go build -ldflags "-X github.com/vishal-sharma-001/FoodHaven-Backend/buildinfo.Commit=$(git rev-parse HEAD)"
+//
+// and fall back to the VCS metadata the Go toolchain embeds when they are not.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+var (
+	Commit    = ""
+	BuildTime = ""
+)
+
+var startTime = time.Now()
+
+type Info struct {
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+	StartedAt string `json:"started_at"`
+}
+
+func Get() Info {
+	info := Info{
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		StartedAt: startTime.UTC().Format(time.RFC3339),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = s.Value
+				}
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
+	}
+	return info
+}
+
+// Uptime reports how long the process has been running.
+func Uptime() time.Duration {
+	return time.Since(startTime)
+}
@@ -0,0 +1,552 @@
+// Package config loads the server configuration.
+//
+// Values are resolved in increasing order of precedence: built-in defaults,
+// an optional YAML or JSON file, a .env file in the working directory, and
+// finally the process environment. Validate reports every missing or invalid
+// setting at once so a misconfigured deployment fails at boot rather than on
+// the first request that needs the value.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/database"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/ratelimit"
+)
+
+type Config struct {
+	Server    ServerConfig    `yaml:"server"`
+	Database  database.Config `yaml:"database"`
+	Session   SessionConfig   `yaml:"session"`
+	CORS      CORSConfig      `yaml:"cors"`
+	Payment   PaymentConfig   `yaml:"payment"`
+	Stripe    StripeConfig    `yaml:"stripe"`
+	App       AppConfig       `yaml:"app"`
+	Mail      MailConfig      `yaml:"mail"`
+	SMS       SMSConfig       `yaml:"sms"`
+	Auth      AuthConfig      `yaml:"auth"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+}
+
+// Listen modes for ServerConfig.Mode.
+const (
+	// ModeTLS terminates TLS in this process.
+	ModeTLS = "tls"
+	// ModeHTTP serves plain HTTP, for local development or when a load
+	// balancer in front of the service terminates TLS.
+	ModeHTTP = "http"
+)
+
+type ServerConfig struct {
+	Addr        string `yaml:"addr"`
+	Mode        string `yaml:"mode"`
+	TLSCertFile string `yaml:"tls_cert_file"`
+	TLSKeyFile  string `yaml:"tls_key_file"`
+	// RedirectAddr, when set in TLS mode, starts a second plain-HTTP listener
+	// that redirects every request to HTTPS.
+	RedirectAddr string `yaml:"redirect_addr"`
+	UIDir        string `yaml:"ui_dir"`
+	// TrustProxy takes the client address from the X-Forwarded-For header
+	// set by a proxy in front of the service. Leave it off when clients
+	// connect directly, since they can set the header themselves.
+	TrustProxy bool `yaml:"trust_proxy"`
+
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout bounds how long in-flight requests may run after a
+	// termination signal before the server stops waiting for them.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type SessionConfig struct {
+	Key string `yaml:"key"`
+}
+
+type CORSConfig struct {
+	AllowedOrigin string `yaml:"allowed_origin"`
+}
+
+// Payment providers for PaymentConfig.Provider.
+const (
+	PaymentStripe = "stripe"
+	// PaymentFake is an offline provider for local development and tests.
+	PaymentFake = "fake"
+)
+
+type PaymentConfig struct {
+	// Provider selects the payment gateway: "stripe", or "fake" for local
+	// development and integration tests.
+	Provider string `yaml:"provider"`
+	// FakeWebhookSecret signs webhook deliveries to the fake provider.
+	FakeWebhookSecret string `yaml:"fake_webhook_secret"`
+}
+
+type StripeConfig struct {
+	SecretKey string `yaml:"secret_key"`
+	// WebhookSecret is the signing secret of the /webhooks/stripe endpoint.
+	// Without it webhook deliveries are rejected.
+	WebhookSecret string `yaml:"webhook_secret"`
+}
+
+type AppConfig struct {
+	// PublicURL is where the UI is served; payment return URLs point here.
+	PublicURL string `yaml:"public_url"`
+	// ImageBaseURL is prefixed to cloud image IDs when images are sent to
+	// third parties such as the checkout page.
+	ImageBaseURL string `yaml:"image_base_url"`
+}
+
+// Mailers for MailConfig.Mailer.
+const (
+	MailerSMTP = "smtp"
+	// MailerLog writes messages to a file or the log instead of sending
+	// them, for local development.
+	MailerLog = "log"
+)
+
+type MailConfig struct {
+	// Mailer selects how email is sent: "smtp", or "log" for local
+	// development.
+	Mailer string `yaml:"mailer"`
+	// From is the sender address of every message.
+	From         string `yaml:"from"`
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     string `yaml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+	// File, with the log mailer, is appended to instead of the log.
+	File string `yaml:"file"`
+}
+
+// SMS senders for SMSConfig.Sender.
+const (
+	// SMSLog writes messages to the log instead of sending them, for local
+	// development.
+	SMSLog = "log"
+)
+
+// RateLimitConfig sets the request limits of each route group. Public and
+// Auth are counted per client IP, Private and Checkout per signed in user.
+// Auth and Checkout apply on top of Public and Private respectively. A
+// limit with zero requests turns that group's limiting off.
+type RateLimitConfig struct {
+	// Public covers everything under /public.
+	Public ratelimit.Limit `yaml:"public"`
+	// Auth covers signup, login and the other public /user routes.
+	Auth ratelimit.Limit `yaml:"auth"`
+	// Private covers everything under /private.
+	Private ratelimit.Limit `yaml:"private"`
+	// Checkout covers the /private/payment routes.
+	Checkout ratelimit.Limit `yaml:"checkout"`
+}
+
+type SMSConfig struct {
+	// Sender selects how text messages are sent. Only "log" is available.
+	Sender string `yaml:"sender"`
+}
+
+// MaxPasswordBytes is the longest password bcrypt can hash.
+const MaxPasswordBytes = 72
+
+type AuthConfig struct {
+	// PasswordMinLength is the shortest password users may choose.
+	PasswordMinLength int `yaml:"password_min_length"`
+	// PasswordResetTTL is how long a password reset link stays valid.
+	PasswordResetTTL time.Duration `yaml:"password_reset_ttl"`
+	// PasswordResetResendInterval is how long a user must wait before
+	// another password reset email is sent.
+	PasswordResetResendInterval time.Duration `yaml:"password_reset_resend_interval"`
+	// EmailVerificationTTL is how long an email verification link stays
+	// valid.
+	EmailVerificationTTL time.Duration `yaml:"email_verification_ttl"`
+	// VerificationResendInterval is how long a user must wait before
+	// another verification email is sent.
+	VerificationResendInterval time.Duration `yaml:"verification_resend_interval"`
+	// OTPTTL is how long a one-time code sent by SMS stays valid.
+	OTPTTL time.Duration `yaml:"otp_ttl"`
+	// OTPMaxAttempts is how many wrong guesses a code survives.
+	OTPMaxAttempts int `yaml:"otp_max_attempts"`
+	// OTPResendInterval is how long a user must wait before another code
+	// is sent.
+	OTPResendInterval time.Duration `yaml:"otp_resend_interval"`
+	// RequireVerifiedEmail stops users whose email is unverified from
+	// checking out.
+	RequireVerifiedEmail bool `yaml:"require_verified_email"`
+
+	// Failed logins are counted per account and per client IP over
+	// LoginFailureWindow; an account's count starts again after it logs in.
+	// Past LoginBackoffAfter failures each attempt must wait for a delay
+	// that starts at LoginBackoffBase and doubles with every failure up to
+	// LoginBackoffMax. Past LoginLockoutAfter failures attempts are refused
+	// for LoginLockoutDuration after the last one. The IP variants allow
+	// more, since many users can share an address.
+	LoginFailureWindow   time.Duration `yaml:"login_failure_window"`
+	LoginBackoffAfter    int           `yaml:"login_backoff_after"`
+	LoginLockoutAfter    int           `yaml:"login_lockout_after"`
+	LoginIPBackoffAfter  int           `yaml:"login_ip_backoff_after"`
+	LoginIPLockoutAfter  int           `yaml:"login_ip_lockout_after"`
+	LoginBackoffBase     time.Duration `yaml:"login_backoff_base"`
+	LoginBackoffMax      time.Duration `yaml:"login_backoff_max"`
+	LoginLockoutDuration time.Duration `yaml:"login_lockout_duration"`
+}
+
+// Default returns the configuration used when nothing is overridden.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:              ":8080",
+			Mode:              ModeTLS,
+			TLSCertFile:       "/etc/tls/tls.crt",
+			TLSKeyFile:        "/etc/tls/tls.key",
+			UIDir:             "./FoodHavenUI",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Database: database.Config{
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			ConnectAttempts: 5,
+			RetryDelay:      2 * time.Second,
+		},
+		Payment: PaymentConfig{
+			Provider: PaymentStripe,
+		},
+		CORS: CORSConfig{
+			AllowedOrigin: "http://localhost:3000",
+		},
+		App: AppConfig{
+			PublicURL:    "https://foodhaven.run.place",
+			ImageBaseURL: "https://storage.cloud.google.com/foodhaven_bucket/Images/",
+		},
+		Mail: MailConfig{
+			Mailer:   MailerLog,
+			From:     "FoodHaven <no-reply@foodhaven.run.place>",
+			SMTPPort: "587",
+		},
+		SMS: SMSConfig{
+			Sender: SMSLog,
+		},
+		Auth: AuthConfig{
+			PasswordMinLength:           8,
+			PasswordResetTTL:            time.Hour,
+			PasswordResetResendInterval: time.Minute,
+
+			EmailVerificationTTL:       24 * time.Hour,
+			VerificationResendInterval: time.Minute,
+			RequireVerifiedEmail:       true,
+
+			OTPTTL:            5 * time.Minute,
+			OTPMaxAttempts:    5,
+			OTPResendInterval: 30 * time.Second,
+
+			LoginFailureWindow:   time.Hour,
+			LoginBackoffAfter:    3,
+			LoginLockoutAfter:    10,
+			LoginIPBackoffAfter:  10,
+			LoginIPLockoutAfter:  50,
+			LoginBackoffBase:     time.Second,
+			LoginBackoffMax:      time.Minute,
+			LoginLockoutDuration: 15 * time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			Public:   ratelimit.Limit{Requests: 120, Period: time.Minute},
+			Auth:     ratelimit.Limit{Requests: 20, Period: time.Minute},
+			Private:  ratelimit.Limit{Requests: 120, Period: time.Minute},
+			Checkout: ratelimit.Limit{Requests: 10, Period: time.Minute},
+		},
+	}
+}
+
+// Load resolves the configuration. path names an optional YAML or JSON file;
+// when empty, CONFIG_FILE is consulted instead.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read .env: %w", err)
+	}
+
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile decodes a YAML file. JSON is a subset of YAML, so .json files are
+// accepted by the same decoder.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	var e envReader
+
+	e.str("SERVER_ADDR", &c.Server.Addr)
+	e.str("SERVER_MODE", &c.Server.Mode)
+	e.str("TLS_CERT_FILE", &c.Server.TLSCertFile)
+	e.str("TLS_KEY_FILE", &c.Server.TLSKeyFile)
+	e.str("REDIRECT_ADDR", &c.Server.RedirectAddr)
+	e.str("UI_DIR", &c.Server.UIDir)
+	e.bool("TRUST_PROXY", &c.Server.TrustProxy)
+	e.duration("SERVER_READ_TIMEOUT", &c.Server.ReadTimeout)
+	e.duration("SERVER_READ_HEADER_TIMEOUT", &c.Server.ReadHeaderTimeout)
+	e.duration("SERVER_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	e.duration("SERVER_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	e.duration("SERVER_SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+
+	e.str("DB_HOST", &c.Database.Host)
+	e.str("DB_PORT", &c.Database.Port)
+	e.str("DB_USER", &c.Database.User)
+	e.str("DB_PASSWORD", &c.Database.Password)
+	e.str("DB_NAME", &c.Database.Name)
+	e.str("DB_SSLMODE", &c.Database.SSLMode)
+	e.int("DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	e.int("DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	e.duration("DB_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime)
+	e.duration("DB_CONN_MAX_IDLE_TIME", &c.Database.ConnMaxIdleTime)
+	e.int("DB_CONNECT_ATTEMPTS", &c.Database.ConnectAttempts)
+	e.duration("DB_CONNECT_RETRY_DELAY", &c.Database.RetryDelay)
+
+	e.str("SESSION_KEY", &c.Session.Key)
+	e.str("CORS_ALLOWED_ORIGIN", &c.CORS.AllowedOrigin)
+	e.str("PAYMENT_PROVIDER", &c.Payment.Provider)
+	e.str("FAKE_PAYMENT_WEBHOOK_SECRET", &c.Payment.FakeWebhookSecret)
+	e.str("STRIPE_SECRET_KEY", &c.Stripe.SecretKey)
+	e.str("STRIPE_WEBHOOK_SECRET", &c.Stripe.WebhookSecret)
+	e.str("APP_PUBLIC_URL", &c.App.PublicURL)
+	e.str("IMAGE_BASE_URL", &c.App.ImageBaseURL)
+
+	e.str("MAILER", &c.Mail.Mailer)
+	e.str("MAIL_FROM", &c.Mail.From)
+	e.str("SMTP_HOST", &c.Mail.SMTPHost)
+	e.str("SMTP_PORT", &c.Mail.SMTPPort)
+	e.str("SMTP_USERNAME", &c.Mail.SMTPUsername)
+	e.str("SMTP_PASSWORD", &c.Mail.SMTPPassword)
+	e.str("MAIL_FILE", &c.Mail.File)
+
+	e.str("SMS_SENDER", &c.SMS.Sender)
+
+	e.int("PASSWORD_MIN_LENGTH", &c.Auth.PasswordMinLength)
+	e.duration("PASSWORD_RESET_TTL", &c.Auth.PasswordResetTTL)
+	e.duration("PASSWORD_RESET_RESEND_INTERVAL", &c.Auth.PasswordResetResendInterval)
+	e.duration("EMAIL_VERIFICATION_TTL", &c.Auth.EmailVerificationTTL)
+	e.duration("VERIFICATION_RESEND_INTERVAL", &c.Auth.VerificationResendInterval)
+	e.bool("REQUIRE_VERIFIED_EMAIL", &c.Auth.RequireVerifiedEmail)
+	e.duration("OTP_TTL", &c.Auth.OTPTTL)
+	e.int("OTP_MAX_ATTEMPTS", &c.Auth.OTPMaxAttempts)
+	e.duration("OTP_RESEND_INTERVAL", &c.Auth.OTPResendInterval)
+	e.duration("LOGIN_FAILURE_WINDOW", &c.Auth.LoginFailureWindow)
+	e.int("LOGIN_BACKOFF_AFTER", &c.Auth.LoginBackoffAfter)
+	e.int("LOGIN_LOCKOUT_AFTER", &c.Auth.LoginLockoutAfter)
+	e.int("LOGIN_IP_BACKOFF_AFTER", &c.Auth.LoginIPBackoffAfter)
+	e.int("LOGIN_IP_LOCKOUT_AFTER", &c.Auth.LoginIPLockoutAfter)
+	e.duration("LOGIN_BACKOFF_BASE", &c.Auth.LoginBackoffBase)
+	e.duration("LOGIN_BACKOFF_MAX", &c.Auth.LoginBackoffMax)
+	e.duration("LOGIN_LOCKOUT_DURATION", &c.Auth.LoginLockoutDuration)
+
+	e.int("RATE_LIMIT_PUBLIC_REQUESTS", &c.RateLimit.Public.Requests)
+	e.duration("RATE_LIMIT_PUBLIC_PERIOD", &c.RateLimit.Public.Period)
+	e.int("RATE_LIMIT_AUTH_REQUESTS", &c.RateLimit.Auth.Requests)
+	e.duration("RATE_LIMIT_AUTH_PERIOD", &c.RateLimit.Auth.Period)
+	e.int("RATE_LIMIT_PRIVATE_REQUESTS", &c.RateLimit.Private.Requests)
+	e.duration("RATE_LIMIT_PRIVATE_PERIOD", &c.RateLimit.Private.Period)
+	e.int("RATE_LIMIT_CHECKOUT_REQUESTS", &c.RateLimit.Checkout.Requests)
+	e.duration("RATE_LIMIT_CHECKOUT_PERIOD", &c.RateLimit.Checkout.Period)
+
+	return errors.Join(e.errs...)
+}
+
+// Validate checks everything the server needs before it starts listening.
+func (c *Config) Validate() error {
+	var errs []error
+	if err := c.Database.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Session.Key == "" {
+		errs = append(errs, errors.New("SESSION_KEY is missing. Please set it in your environment variables or .env file"))
+	}
+	switch c.Payment.Provider {
+	case PaymentStripe:
+		if c.Stripe.SecretKey == "" {
+			errs = append(errs, errors.New("STRIPE_SECRET_KEY is required when PAYMENT_PROVIDER is stripe"))
+		}
+	case PaymentFake:
+		if c.Payment.FakeWebhookSecret == "" {
+			errs = append(errs, errors.New("FAKE_PAYMENT_WEBHOOK_SECRET is required when PAYMENT_PROVIDER is fake"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("PAYMENT_PROVIDER %q must be %q or %q", c.Payment.Provider, PaymentStripe, PaymentFake))
+	}
+	switch c.Mail.Mailer {
+	case MailerSMTP:
+		if c.Mail.SMTPHost == "" || c.Mail.SMTPPort == "" {
+			errs = append(errs, errors.New("SMTP_HOST and SMTP_PORT are required when MAILER is smtp"))
+		}
+	case MailerLog:
+	default:
+		errs = append(errs, fmt.Errorf("MAILER %q must be %q or %q", c.Mail.Mailer, MailerSMTP, MailerLog))
+	}
+	if c.Mail.From == "" {
+		errs = append(errs, errors.New("MAIL_FROM must not be empty"))
+	}
+	if c.Auth.PasswordMinLength < 1 || c.Auth.PasswordMinLength > MaxPasswordBytes {
+		errs = append(errs, fmt.Errorf("PASSWORD_MIN_LENGTH must be between 1 and %d", MaxPasswordBytes))
+	}
+	if c.Auth.PasswordResetTTL <= 0 {
+		errs = append(errs, errors.New("PASSWORD_RESET_TTL must be positive"))
+	}
+	if c.Auth.PasswordResetResendInterval < 0 {
+		errs = append(errs, errors.New("PASSWORD_RESET_RESEND_INTERVAL must not be negative"))
+	}
+	if c.Auth.EmailVerificationTTL <= 0 {
+		errs = append(errs, errors.New("EMAIL_VERIFICATION_TTL must be positive"))
+	}
+	if c.Auth.VerificationResendInterval < 0 {
+		errs = append(errs, errors.New("VERIFICATION_RESEND_INTERVAL must not be negative"))
+	}
+	if c.SMS.Sender != SMSLog {
+		errs = append(errs, fmt.Errorf("SMS_SENDER %q must be %q", c.SMS.Sender, SMSLog))
+	}
+	if c.Auth.OTPTTL <= 0 {
+		errs = append(errs, errors.New("OTP_TTL must be positive"))
+	}
+	if c.Auth.OTPMaxAttempts < 1 {
+		errs = append(errs, errors.New("OTP_MAX_ATTEMPTS must be at least 1"))
+	}
+	if c.Auth.OTPResendInterval < 0 {
+		errs = append(errs, errors.New("OTP_RESEND_INTERVAL must not be negative"))
+	}
+	if c.Auth.LoginFailureWindow <= 0 {
+		errs = append(errs, errors.New("LOGIN_FAILURE_WINDOW must be positive"))
+	}
+	if c.Auth.LoginBackoffAfter < 1 || c.Auth.LoginLockoutAfter < c.Auth.LoginBackoffAfter {
+		errs = append(errs, errors.New("LOGIN_BACKOFF_AFTER must be at least 1 and no more than LOGIN_LOCKOUT_AFTER"))
+	}
+	if c.Auth.LoginIPBackoffAfter < 1 || c.Auth.LoginIPLockoutAfter < c.Auth.LoginIPBackoffAfter {
+		errs = append(errs, errors.New("LOGIN_IP_BACKOFF_AFTER must be at least 1 and no more than LOGIN_IP_LOCKOUT_AFTER"))
+	}
+	if c.Auth.LoginBackoffBase <= 0 || c.Auth.LoginBackoffMax < c.Auth.LoginBackoffBase {
+		errs = append(errs, errors.New("LOGIN_BACKOFF_BASE must be positive and no more than LOGIN_BACKOFF_MAX"))
+	}
+	if c.Auth.LoginLockoutDuration <= 0 {
+		errs = append(errs, errors.New("LOGIN_LOCKOUT_DURATION must be positive"))
+	}
+	for _, group := range []struct {
+		env   string
+		limit ratelimit.Limit
+	}{
+		{"PUBLIC", c.RateLimit.Public},
+		{"AUTH", c.RateLimit.Auth},
+		{"PRIVATE", c.RateLimit.Private},
+		{"CHECKOUT", c.RateLimit.Checkout},
+	} {
+		if group.limit.Requests < 0 {
+			errs = append(errs, fmt.Errorf("RATE_LIMIT_%s_REQUESTS must not be negative", group.env))
+		}
+		if group.limit.Requests > 0 && group.limit.Period < time.Second {
+			errs = append(errs, fmt.Errorf("RATE_LIMIT_%s_PERIOD must be at least 1s", group.env))
+		}
+	}
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("SERVER_ADDR must not be empty"))
+	}
+	switch c.Server.Mode {
+	case ModeTLS:
+		if c.Server.TLSCertFile == "" || c.Server.TLSKeyFile == "" {
+			errs = append(errs, errors.New("TLS_CERT_FILE and TLS_KEY_FILE are required when SERVER_MODE is tls"))
+		}
+	case ModeHTTP:
+		if c.Server.RedirectAddr != "" {
+			errs = append(errs, errors.New("REDIRECT_ADDR is only supported when SERVER_MODE is tls"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("SERVER_MODE %q must be %q or %q", c.Server.Mode, ModeTLS, ModeHTTP))
+	}
+	if !strings.HasPrefix(c.App.PublicURL, "http://") && !strings.HasPrefix(c.App.PublicURL, "https://") {
+		errs = append(errs, fmt.Errorf("APP_PUBLIC_URL %q must be an absolute http(s) URL", c.App.PublicURL))
+	}
+	return errors.Join(errs...)
+}
+
+// envReader copies set environment variables into config fields, collecting
+// parse errors instead of stopping at the first one.
+type envReader struct {
+	errs []error
+}
+
+func (e *envReader) str(key string, dst *string) {
+	if v, ok := os.LookupEnv(key); ok {
+		*dst = v
+	}
+}
+
+func (e *envReader) int(key string, dst *int) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: invalid integer %q", key, v))
+		return
+	}
+	*dst = n
+}
+
+func (e *envReader) bool(key string, dst *bool) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: invalid boolean %q", key, v))
+		return
+	}
+	*dst = b
+}
+
+func (e *envReader) duration(key string, dst *time.Duration) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: invalid duration %q", key, v))
+		return
+	}
+	*dst = d
+}
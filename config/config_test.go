@@ -0,0 +1,281 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// inDir runs the rest of the test in a fresh working directory holding a
+// .env file with dotenv, unless it is empty.
+func inDir(t *testing.T, dotenv string) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	if dotenv != "" {
+		writeFile(t, filepath.Join(dir, ".env"), dotenv)
+	}
+	return dir
+}
+
+// unsetEnv clears keys for the test; .env values Load copies into the
+// environment are removed again afterwards.
+func unsetEnv(t *testing.T, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name               string
+		file, dotenv       string
+		env                map[string]string
+		wantAddr, wantHost string
+		wantLifetime       time.Duration
+	}{
+		{
+			name:     "defaults",
+			wantAddr: ":8080", wantLifetime: 30 * time.Minute,
+		},
+		{
+			name:     "file over defaults",
+			file:     "server:\n  addr: \":9000\"\ndatabase:\n  host: filehost\n  conn_max_lifetime: 1h\n",
+			wantAddr: ":9000", wantHost: "filehost", wantLifetime: time.Hour,
+		},
+		{
+			name:     ".env over file",
+			file:     "server:\n  addr: \":9000\"\ndatabase:\n  host: filehost\n",
+			dotenv:   "SERVER_ADDR=:9100\nDB_CONN_MAX_LIFETIME=2h\n",
+			wantAddr: ":9100", wantHost: "filehost", wantLifetime: 2 * time.Hour,
+		},
+		{
+			name:     "environment over .env",
+			file:     "server:\n  addr: \":9000\"\n",
+			dotenv:   "SERVER_ADDR=:9100\nDB_HOST=dotenvhost\n",
+			env:      map[string]string{"SERVER_ADDR": ":9200"},
+			wantAddr: ":9200", wantHost: "dotenvhost", wantLifetime: 30 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetEnv(t, "CONFIG_FILE", "SERVER_ADDR", "DB_HOST", "DB_CONN_MAX_LIFETIME")
+			dir := inDir(t, tt.dotenv)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			path := ""
+			if tt.file != "" {
+				path = filepath.Join(dir, "config.yaml")
+				writeFile(t, path, tt.file)
+			}
+
+			cfg, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Server.Addr != tt.wantAddr || cfg.Database.Host != tt.wantHost || cfg.Database.ConnMaxLifetime != tt.wantLifetime {
+				t.Errorf("addr, host, lifetime = %q, %q, %v; want %q, %q, %v",
+					cfg.Server.Addr, cfg.Database.Host, cfg.Database.ConnMaxLifetime, tt.wantAddr, tt.wantHost, tt.wantLifetime)
+			}
+		})
+	}
+}
+
+func TestLoadReadsConfigFileFromEnvironment(t *testing.T) {
+	dir := inDir(t, "")
+	path := filepath.Join(dir, "config.json")
+	writeFile(t, path, `{"cors": {"allowed_origin": "https://app.example.com"}}`)
+	t.Setenv("CONFIG_FILE", path)
+	unsetEnv(t, "CORS_ALLOWED_ORIGIN")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.CORS.AllowedOrigin != "https://app.example.com" {
+		t.Errorf("allowed origin = %q, want the one from the JSON file", cfg.CORS.AllowedOrigin)
+	}
+}
+
+func TestLoadRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		want []string
+	}{
+		{
+			name: "invalid integer and duration",
+			env:  map[string]string{"DB_MAX_OPEN_CONNS": "many", "DB_CONNECT_RETRY_DELAY": "5"},
+			want: []string{`DB_MAX_OPEN_CONNS: invalid integer "many"`, `DB_CONNECT_RETRY_DELAY: invalid duration "5"`},
+		},
+		{
+			name: "invalid boolean",
+			env:  map[string]string{"REQUIRE_VERIFIED_EMAIL": "sometimes"},
+			want: []string{`REQUIRE_VERIFIED_EMAIL: invalid boolean "sometimes"`},
+		},
+		{
+			name: "malformed file",
+			file: "server: [",
+			want: []string{"failed to parse config file"},
+		},
+		{
+			name: "wrong type in file",
+			file: "database:\n  max_open_conns: lots\n",
+			want: []string{"failed to parse config file"},
+		},
+		{
+			name: "missing file",
+			env:  map[string]string{"CONFIG_FILE": "does-not-exist.yaml"},
+			want: []string{"failed to read config file"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetEnv(t, "CONFIG_FILE", "DB_MAX_OPEN_CONNS", "DB_CONNECT_RETRY_DELAY", "REQUIRE_VERIFIED_EMAIL")
+			dir := inDir(t, "")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			path := ""
+			if tt.file != "" {
+				path = filepath.Join(dir, "config.yaml")
+				writeFile(t, path, tt.file)
+			}
+
+			_, err := Load(path)
+			if err == nil {
+				t.Fatal("Load succeeded")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Load = %v, want it to mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+// validConfig returns the defaults with every required setting filled in.
+func validConfig() *Config {
+	cfg := Default()
+	cfg.Database.Host, cfg.Database.Port, cfg.Database.User, cfg.Database.Name = "localhost", "5432", "foodhaven", "foodhaven"
+	cfg.Session.Key = "0123456789abcdef0123456789abcdef"
+	cfg.Stripe.SecretKey = "sk_test_123"
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	if err := validConfig().Validate(); err != nil {
+		t.Fatalf("Validate = %v, want nil", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   []string
+	}{
+		{"no session key", func(c *Config) { c.Session.Key = "" }, []string{"SESSION_KEY is missing"}},
+		{"no Stripe key", func(c *Config) { c.Stripe.SecretKey = "" }, []string{"STRIPE_SECRET_KEY is required"}},
+		{
+			"fake payments without a secret",
+			func(c *Config) { c.Payment.Provider, c.Stripe.SecretKey = PaymentFake, "" },
+			[]string{"FAKE_PAYMENT_WEBHOOK_SECRET is required"},
+		},
+		{"unknown payment provider", func(c *Config) { c.Payment.Provider = "paypal" }, []string{`PAYMENT_PROVIDER "paypal"`}},
+		{"no address", func(c *Config) { c.Server.Addr = "" }, []string{"SERVER_ADDR must not be empty"}},
+		{"SMTP without a host", func(c *Config) { c.Mail.Mailer = MailerSMTP }, []string{"SMTP_HOST and SMTP_PORT are required"}},
+		{"unknown mailer", func(c *Config) { c.Mail.Mailer = "pigeon" }, []string{`MAILER "pigeon"`}},
+		{"no sender", func(c *Config) { c.Mail.From = "" }, []string{"MAIL_FROM must not be empty"}},
+		{"password minimum too long", func(c *Config) { c.Auth.PasswordMinLength = 73 }, []string{"PASSWORD_MIN_LENGTH must be between 1 and 72"}},
+		{"no reset TTL", func(c *Config) { c.Auth.PasswordResetTTL = 0 }, []string{"PASSWORD_RESET_TTL must be positive"}},
+		{
+			"negative reset resend interval",
+			func(c *Config) { c.Auth.PasswordResetResendInterval = -time.Second },
+			[]string{"PASSWORD_RESET_RESEND_INTERVAL must not be negative"},
+		},
+		{"no verification TTL", func(c *Config) { c.Auth.EmailVerificationTTL = 0 }, []string{"EMAIL_VERIFICATION_TTL must be positive"}},
+		{
+			"negative resend interval",
+			func(c *Config) { c.Auth.VerificationResendInterval = -time.Second },
+			[]string{"VERIFICATION_RESEND_INTERVAL must not be negative"},
+		},
+		{"unknown SMS sender", func(c *Config) { c.SMS.Sender = "pager" }, []string{`SMS_SENDER "pager"`}},
+		{"no OTP TTL", func(c *Config) { c.Auth.OTPTTL = 0 }, []string{"OTP_TTL must be positive"}},
+		{"no OTP attempts", func(c *Config) { c.Auth.OTPMaxAttempts = 0 }, []string{"OTP_MAX_ATTEMPTS must be at least 1"}},
+		{
+			"negative OTP resend interval",
+			func(c *Config) { c.Auth.OTPResendInterval = -time.Second },
+			[]string{"OTP_RESEND_INTERVAL must not be negative"},
+		},
+		{"no failure window", func(c *Config) { c.Auth.LoginFailureWindow = 0 }, []string{"LOGIN_FAILURE_WINDOW must be positive"}},
+		{
+			"lockout before backoff",
+			func(c *Config) { c.Auth.LoginBackoffAfter, c.Auth.LoginLockoutAfter = 5, 4 },
+			[]string{"LOGIN_BACKOFF_AFTER must be at least 1 and no more than LOGIN_LOCKOUT_AFTER"},
+		},
+		{
+			"backoff longer than its maximum",
+			func(c *Config) { c.Auth.LoginBackoffBase = 2 * c.Auth.LoginBackoffMax },
+			[]string{"LOGIN_BACKOFF_BASE must be positive and no more than LOGIN_BACKOFF_MAX"},
+		},
+		{"no lockout duration", func(c *Config) { c.Auth.LoginLockoutDuration = 0 }, []string{"LOGIN_LOCKOUT_DURATION must be positive"}},
+		{
+			"negative rate limit",
+			func(c *Config) { c.RateLimit.Auth.Requests = -1 },
+			[]string{"RATE_LIMIT_AUTH_REQUESTS must not be negative"},
+		},
+		{
+			"rate limit period too short",
+			func(c *Config) { c.RateLimit.Checkout.Period = time.Millisecond },
+			[]string{"RATE_LIMIT_CHECKOUT_PERIOD must be at least 1s"},
+		},
+		{"unknown mode", func(c *Config) { c.Server.Mode = "quic" }, []string{`SERVER_MODE "quic"`}},
+		{"TLS without a key", func(c *Config) { c.Server.TLSKeyFile = "" }, []string{"TLS_CERT_FILE and TLS_KEY_FILE are required"}},
+		{
+			"redirect in HTTP mode",
+			func(c *Config) { c.Server.Mode, c.Server.RedirectAddr = ModeHTTP, ":80" },
+			[]string{"REDIRECT_ADDR is only supported"},
+		},
+		{"relative public URL", func(c *Config) { c.App.PublicURL = "foodhaven.run.place" }, []string{"APP_PUBLIC_URL"}},
+		{"no database", func(c *Config) { c.Database.Host = "" }, []string{"DB_HOST"}},
+		{
+			"every problem at once",
+			func(c *Config) { c.Session.Key, c.Stripe.SecretKey, c.Database.Name = "", "", "" },
+			[]string{"SESSION_KEY", "STRIPE_SECRET_KEY", "DB_NAME"},
+		},
+	}
+	for _, tt := range tests {
+		cfg := validConfig()
+		tt.modify(cfg)
+		err := cfg.Validate()
+		if err == nil {
+			t.Errorf("%s: Validate succeeded", tt.name)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: Validate = %v, want it to mention %q", tt.name, err, want)
+			}
+		}
+	}
+}
@@ -1,37 +1,99 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
-	"os"
+	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
 )
 
-func ConnectDB() (*sql.DB, error) {
-	host     := os.Getenv("DB_HOST")
-	port     := os.Getenv("DB_PORT")
-	user     := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname   := os.Getenv("DB_NAME")
+// Config describes how to reach Postgres and how the shared pool is sized.
+type Config struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
 
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", host, port, user, password, dbname)
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
 
-	var db *sql.DB
-	var err error
-	for attempts := 1; attempts <= 5; attempts++ {
-		db, err = sql.Open("postgres", connStr)
+	ConnectAttempts int           `yaml:"connect_attempts"`
+	RetryDelay      time.Duration `yaml:"retry_delay"`
+}
+
+// Validate reports connection settings that must be provided.
+func (c Config) Validate() error {
+	var missing []string
+	for _, f := range []struct{ name, value string }{
+		{"DB_HOST", c.Host},
+		{"DB_PORT", c.Port},
+		{"DB_USER", c.User},
+		{"DB_NAME", c.Name},
+	} {
+		if f.value == "" {
+			missing = append(missing, f.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing database settings: %s", strings.Join(missing, ", "))
+	}
+	if c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns {
+		return fmt.Errorf("DB_MAX_IDLE_CONNS (%d) must not exceed DB_MAX_OPEN_CONNS (%d)", c.MaxIdleConns, c.MaxOpenConns)
+	}
+	return nil
+}
+
+func (c Config) dsn() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s", c.Host, c.Port, c.User, c.Password, c.Name, c.SSLMode)
+}
+
+// Open builds the application-wide connection pool. It is meant to be called
+// once at startup; the retries only cover the database coming up alongside
+// the server, never individual requests.
+func Open(ctx context.Context, cfg Config) (*sql.DB, error) {
+	db, err := sql.Open("postgres", cfg.dsn())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	attempts := cfg.ConnectAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err = db.PingContext(pingCtx)
+		cancel()
 		if err == nil {
-			err = db.Ping()
-			if err == nil {
-				return db, nil
-			}
+			return db, nil
+		}
+		if attempt == attempts {
+			break
 		}
 
-		log.Printf("Attempt %d: Failed to connect to the database. Retrying in 2 seconds...\n", attempts)
-		time.Sleep(2 * time.Second)
+		log.Printf("Attempt %d: Failed to connect to the database. Retrying in %v...\n", attempt, cfg.RetryDelay)
+		select {
+		case <-ctx.Done():
+			db.Close()
+			return nil, ctx.Err()
+		case <-time.After(cfg.RetryDelay):
+		}
 	}
-	return nil, fmt.Errorf("failed to connect to the database after 5 attempts: %w", err)
+
+	db.Close()
+	return nil, fmt.Errorf("failed to connect to the database after %d attempts: %w", attempts, err)
 }
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{Host: "db", Port: "5432", User: "app", Name: "app", MaxOpenConns: 25, MaxIdleConns: 10}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate = %v, want nil", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{"no host or name", func(c *Config) { c.Host, c.Name = "", "" }, "missing database settings: DB_HOST, DB_NAME"},
+		{"idle above open", func(c *Config) { c.MaxIdleConns = 30 }, "DB_MAX_IDLE_CONNS (30) must not exceed DB_MAX_OPEN_CONNS (25)"},
+	}
+	for _, tt := range tests {
+		cfg := valid
+		tt.modify(&cfg)
+		if err := cfg.Validate(); err == nil || err.Error() != tt.want {
+			t.Errorf("%s: Validate = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+	// An unlimited pool allows any number of idle connections.
+	unlimited := valid
+	unlimited.MaxOpenConns = 0
+	if err := unlimited.Validate(); err != nil {
+		t.Errorf("unlimited pool: Validate = %v, want nil", err)
+	}
+}
+
+// unreachable points at a port nothing listens on, so connecting fails fast.
+func unreachable(attempts int, delay time.Duration) Config {
+	return Config{
+		Host: "127.0.0.1", Port: "1", User: "app", Name: "app", SSLMode: "disable",
+		ConnectAttempts: attempts, RetryDelay: delay,
+	}
+}
+
+func TestOpenGivesUpAfterConnectAttempts(t *testing.T) {
+	_, err := Open(context.Background(), unreachable(3, time.Millisecond))
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Errorf("Open = %v, want failure after 3 attempts", err)
+	}
+}
+
+func TestOpenStopsRetryingWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := Open(ctx, unreachable(5, time.Hour))
+	if err != context.DeadlineExceeded {
+		t.Errorf("Open = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Open took %v after the context ended", elapsed)
+	}
+}
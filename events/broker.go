@@ -0,0 +1,189 @@
+// Package events fans order updates out to the clients following them. It is
+// in-process: each server instance only sees the updates it makes itself.
+package events
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types sent on an order's stream.
+const (
+	// TypeStatus carries a models.OrderStatusChange.
+	TypeStatus = "status"
+	// TypeETA carries a models.OrderETA.
+	TypeETA = "eta"
+	// TypeLocation carries a models.CourierLocation.
+	TypeLocation = "location"
+)
+
+const (
+	// retainedEvents is how many recent events, across all orders, are kept
+	// for clients reconnecting with Last-Event-ID.
+	retainedEvents = 1024
+	// subscriberBuffer is how far a subscriber may fall behind before it is
+	// dropped; it reconnects and catches up from the retained events.
+	subscriberBuffer = 32
+)
+
+// Event is one update to an order.
+type Event struct {
+	// ID is unique for the life of the process and orders events; clients
+	// send it back as Last-Event-ID.
+	ID      string
+	OrderID int
+	Type    string
+	// Data is sent to clients JSON-encoded.
+	Data interface{}
+
+	seq uint64
+}
+
+// Broker is an in-process publish/subscribe hub for order events.
+type Broker struct {
+	// epoch tells IDs from a previous process apart from this one's.
+	epoch string
+
+	mu     sync.Mutex
+	seq    uint64
+	recent []Event
+	// dropped is the seq of the newest event no longer in recent.
+	dropped uint64
+	subs    map[int]map[*Subscription]struct{}
+	closed  bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		epoch: strconv.FormatInt(time.Now().UnixNano(), 36),
+		subs:  make(map[int]map[*Subscription]struct{}),
+	}
+}
+
+// Subscription receives the events of one order. C is closed when the
+// subscriber falls too far behind or the broker shuts down.
+type Subscription struct {
+	C <-chan Event
+
+	ch      chan Event
+	orderID int
+	broker  *Broker
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
+
+// Publish sends an event about orderID to its subscribers and retains it for
+// reconnecting clients.
+func (b *Broker) Publish(orderID int, eventType string, data interface{}) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	event := Event{
+		ID:      fmt.Sprintf("%s-%d", b.epoch, b.seq),
+		OrderID: orderID,
+		Type:    eventType,
+		Data:    data,
+		seq:     b.seq,
+	}
+	if len(b.recent) == retainedEvents {
+		b.dropped = b.recent[0].seq
+		b.recent = append(b.recent[:0], b.recent[1:]...)
+	}
+	b.recent = append(b.recent, event)
+
+	for sub := range b.subs[orderID] {
+		select {
+		case sub.ch <- event:
+		default:
+			b.remove(sub)
+		}
+	}
+	return event
+}
+
+// Subscribe starts delivering orderID's events. When lastEventID is set it
+// also returns the retained events for the order published after it; ok is
+// false when it cannot, because lastEventID is empty, from another process
+// or too old, and the caller should send the order's current state instead.
+// ok is also false, with a nil subscription, once the broker is closed.
+func (b *Broker) Subscribe(orderID int, lastEventID string) (sub *Subscription, missed []Event, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, nil, false
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	sub = &Subscription{C: ch, ch: ch, orderID: orderID, broker: b}
+	if b.subs[orderID] == nil {
+		b.subs[orderID] = make(map[*Subscription]struct{})
+	}
+	b.subs[orderID][sub] = struct{}{}
+
+	last, ok := b.parseID(lastEventID)
+	if !ok || last < b.dropped || last > b.seq {
+		return sub, nil, false
+	}
+	for _, event := range b.recent {
+		if event.seq > last && event.OrderID == orderID {
+			missed = append(missed, event)
+		}
+	}
+	return sub, missed, true
+}
+
+// Latest returns the newest retained event of eventType for orderID.
+func (b *Broker) Latest(orderID int, eventType string) (Event, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := len(b.recent) - 1; i >= 0; i-- {
+		if event := b.recent[i]; event.OrderID == orderID && event.Type == eventType {
+			return event, true
+		}
+	}
+	return Event{}, false
+}
+
+// Close ends every subscription and refuses new ones, so that long-lived
+// streams do not hold up a graceful shutdown.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for _, subs := range b.subs {
+		for sub := range subs {
+			b.remove(sub)
+		}
+	}
+}
+
+// remove must be called with b.mu held.
+func (b *Broker) remove(sub *Subscription) {
+	subs := b.subs[sub.orderID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subs, sub.orderID)
+	}
+	close(sub.ch)
+}
+
+func (b *Broker) parseID(id string) (uint64, bool) {
+	epoch, seq, found := strings.Cut(id, "-")
+	if !found || epoch != b.epoch {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	return n, err == nil
+}
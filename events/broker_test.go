@@ -0,0 +1,164 @@
+package events
+
+import (
+	"testing"
+)
+
+// receive returns the next event on sub without blocking.
+func receive(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	select {
+	case event, open := <-sub.C:
+		if !open {
+			t.Fatal("subscription closed")
+		}
+		return event
+	default:
+		t.Fatal("no event delivered")
+		return Event{}
+	}
+}
+
+func wantClosed(t *testing.T, sub *Subscription) {
+	t.Helper()
+	for {
+		select {
+		case _, open := <-sub.C:
+			if !open {
+				return
+			}
+		default:
+			t.Fatal("subscription still open")
+		}
+	}
+}
+
+func TestSubscribeDeliversTheOrdersEvents(t *testing.T) {
+	b := NewBroker()
+	sub, _, _ := b.Subscribe(1, "")
+	other, _, _ := b.Subscribe(2, "")
+
+	published := b.Publish(1, TypeETA, "soon")
+	if got := receive(t, sub); got.ID != published.ID || got.Type != TypeETA || got.Data != "soon" {
+		t.Errorf("got %+v, want %+v", got, published)
+	}
+	select {
+	case event := <-other.C:
+		t.Errorf("order 2 received order 1's event %+v", event)
+	default:
+	}
+
+	sub.Close()
+	sub.Close()
+	wantClosed(t, sub)
+	b.Publish(1, TypeETA, "later")
+	if len(b.subs[1]) != 0 {
+		t.Errorf("closed subscription still registered")
+	}
+}
+
+func TestSlowSubscribersAreDropped(t *testing.T) {
+	b := NewBroker()
+	slow, _, _ := b.Subscribe(1, "")
+	for i := 0; i < subscriberBuffer; i++ {
+		b.Publish(1, TypeStatus, i)
+	}
+	fast, _, _ := b.Subscribe(1, "")
+
+	last := b.Publish(1, TypeStatus, "overflow")
+	for i := 0; i < subscriberBuffer; i++ {
+		if got := receive(t, slow); got.Data != i {
+			t.Fatalf("event %d: got %v", i, got.Data)
+		}
+	}
+	wantClosed(t, slow)
+	if got := receive(t, fast); got.ID != last.ID {
+		t.Errorf("fast subscriber got %+v, want %+v", got, last)
+	}
+
+	// The dropped client catches up by reconnecting.
+	_, missed, ok := b.Subscribe(1, b.recent[subscriberBuffer-1].ID)
+	if !ok || len(missed) != 1 || missed[0].ID != last.ID {
+		t.Errorf("missed = %+v, %v; want the overflowing event", missed, ok)
+	}
+}
+
+func TestSubscribeReplaysFromLastEventID(t *testing.T) {
+	b := NewBroker()
+	first := b.Publish(1, TypeStatus, "accepted")
+	b.Publish(2, TypeStatus, "other order")
+	second := b.Publish(1, TypeETA, "soon")
+	third := b.Publish(1, TypeStatus, "preparing")
+
+	tests := []struct {
+		name        string
+		lastEventID string
+		want        []Event
+		ok          bool
+	}{
+		{"first connection", "", nil, false},
+		{"after the first event", first.ID, []Event{second, third}, true},
+		{"up to date", third.ID, nil, true},
+		{"another process", "0-1", nil, false},
+		{"malformed", "nonsense", nil, false},
+		{"from the future", b.epoch + "-99", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, missed, ok := b.Subscribe(1, tt.lastEventID)
+			defer sub.Close()
+			if ok != tt.ok {
+				t.Errorf("ok = %v, want %v", ok, tt.ok)
+			}
+			if len(missed) != len(tt.want) {
+				t.Fatalf("missed %d events, want %d: %+v", len(missed), len(tt.want), missed)
+			}
+			for i := range missed {
+				if missed[i].ID != tt.want[i].ID {
+					t.Errorf("missed[%d] = %s, want %s", i, missed[i].ID, tt.want[i].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestSubscribeCannotReplayEventsNoLongerRetained(t *testing.T) {
+	b := NewBroker()
+	first := b.Publish(1, TypeStatus, "accepted")
+	// Retaining the next event only is enough to catch up from first.
+	for i := 0; i < retainedEvents; i++ {
+		b.Publish(2, TypeStatus, i)
+	}
+	if _, _, ok := b.Subscribe(1, first.ID); !ok {
+		t.Fatal("could not replay although every later event is retained")
+	}
+	b.Publish(2, TypeStatus, "one too many")
+	if _, _, ok := b.Subscribe(1, first.ID); ok {
+		t.Error("replayed although events after Last-Event-ID were dropped")
+	}
+}
+
+func TestLatest(t *testing.T) {
+	b := NewBroker()
+	if _, ok := b.Latest(1, TypeETA); ok {
+		t.Error("found an ETA before any was published")
+	}
+	b.Publish(1, TypeETA, "soon")
+	want := b.Publish(1, TypeETA, "later")
+	b.Publish(1, TypeStatus, "preparing")
+	b.Publish(2, TypeETA, "other order")
+	if got, ok := b.Latest(1, TypeETA); !ok || got.ID != want.ID {
+		t.Errorf("Latest = %+v, %v; want %+v", got, ok, want)
+	}
+}
+
+func TestCloseEndsSubscriptions(t *testing.T) {
+	b := NewBroker()
+	sub, _, _ := b.Subscribe(1, "")
+	b.Close()
+	wantClosed(t, sub)
+	sub.Close()
+	if sub, _, ok := b.Subscribe(1, ""); sub != nil || ok {
+		t.Errorf("subscribed after Close: %v, %v", sub, ok)
+	}
+}
@@ -0,0 +1,43 @@
+package events
+
+import (
+	"context"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
+)
+
+// PublishOrderChanges wraps orders so that every status change made through
+// it is published to broker as a TypeStatus event.
+func PublishOrderChanges(orders store.OrderStore, broker *Broker) store.OrderStore {
+	return publishingOrderStore{OrderStore: orders, broker: broker}
+}
+
+type publishingOrderStore struct {
+	store.OrderStore
+	broker *Broker
+}
+
+func (s publishingOrderStore) ApplyPaymentUpdate(ctx context.Context, update store.PaymentUpdate) (*models.OrderStatusChange, error) {
+	change, err := s.OrderStore.ApplyPaymentUpdate(ctx, update)
+	s.publish(change, err)
+	return change, err
+}
+
+func (s publishingOrderStore) Transition(ctx context.Context, change *models.OrderStatusChange) error {
+	err := s.OrderStore.Transition(ctx, change)
+	s.publish(change, err)
+	return err
+}
+
+func (s publishingOrderStore) AddRefund(ctx context.Context, refund *models.Refund) (*models.OrderStatusChange, error) {
+	change, err := s.OrderStore.AddRefund(ctx, refund)
+	s.publish(change, err)
+	return change, err
+}
+
+func (s publishingOrderStore) publish(change *models.OrderStatusChange, err error) {
+	if err == nil && change != nil {
+		s.broker.Publish(change.OrderID, TypeStatus, *change)
+	}
+}
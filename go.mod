@@ -9,6 +9,7 @@ require (
 	github.com/lib/pq v1.10.9
 	github.com/stripe/stripe-go/v81 v81.1.1
 	golang.org/x/crypto v0.30.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require github.com/stretchr/testify v1.8.4 // indirect
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/middleware"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
+)
+
+var (
+	// postalCodePattern matches a six digit Indian PIN code.
+	postalCodePattern = regexp.MustCompile(`^[1-9][0-9]{5}$`)
+	// phonePattern matches a ten digit Indian mobile number, optionally
+	// with the country code.
+	phonePattern = regexp.MustCompile(`^(\+91)?[6-9][0-9]{9}$`)
+)
+
+// invalidAddressError rejects an address the user has to correct. Its
+// message is meant for the client.
+type invalidAddressError string
+
+func (e invalidAddressError) Error() string { return string(e) }
+
+// validateAddress tidies up the user-entered fields of address and checks
+// that it can be delivered to.
+func validateAddress(address *models.Address) error {
+	address.Name = strings.TrimSpace(address.Name)
+	address.Street = strings.TrimSpace(address.Street)
+	address.City = strings.TrimSpace(address.City)
+	address.PostalCode = strings.ReplaceAll(strings.TrimSpace(address.PostalCode), " ", "")
+	address.Phone = strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(address.Phone))
+
+	switch {
+	case address.Street == "" || address.City == "":
+		return invalidAddressError("Street and city are required")
+	case !postalCodePattern.MatchString(address.PostalCode):
+		return invalidAddressError("Postal code must be 6 digits")
+	case !phonePattern.MatchString(address.Phone):
+		return invalidAddressError("Phone must be a 10 digit mobile number")
+	}
+	return nil
+}
+
+// SetPrimaryAddress makes one of the user's addresses their primary one and
+// returns all of them.
+func (h *Handler) SetPrimaryAddress(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPut {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	addressID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "Invalid address ID")
+		return
+	}
+
+	if err := h.Store.Addresses.SetPrimary(r.Context(), user.Id, addressID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			WriteError(w, r, http.StatusNotFound, "Address not found or not authorized")
+			return
+		}
+		log.Printf("Error setting primary address %d: %v", addressID, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to update address")
+		return
+	}
+
+	addresses, err := h.Store.Addresses.List(r.Context(), user.Id)
+	if err != nil {
+		log.Printf("Error fetching addresses: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "Database query error")
+		return
+	}
+
+	WriteSuccessMessage(w, r, addresses)
+}
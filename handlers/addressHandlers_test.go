@@ -0,0 +1,96 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+// primaryAddresses lists the client's addresses and returns the IDs of the
+// primary ones.
+func (c *testClient) primaryAddresses() []int {
+	c.t.Helper()
+	var addresses []models.Address
+	c.do("GET", "/private/user/getaddresses", "").wantCode(http.StatusOK).decode(&addresses)
+	var primary []int
+	for _, address := range addresses {
+		if address.IsPrimary {
+			primary = append(primary, address.ID)
+		}
+	}
+	return primary
+}
+
+func TestUsersHaveOnePrimaryAddress(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	home := c.addressID
+	if primary := c.primaryAddresses(); fmt.Sprint(primary) != fmt.Sprint([]int{home}) {
+		t.Fatalf("primary = %v, want the first address %d", primary, home)
+	}
+
+	var work models.Address
+	c.do("POST", "/private/user/addaddress", `{"name":"Work","street":"2 FC Road","city":"Pune","postalCode":"411 004","phone":"+91 98765-43210"}`).
+		wantCode(http.StatusOK).decode(&work)
+	if work.IsPrimary || work.PostalCode != "411004" || work.Phone != "+919876543210" {
+		t.Errorf("work = %+v, want a tidied-up secondary address", work)
+	}
+
+	var addresses []models.Address
+	c.do("PUT", fmt.Sprintf("/private/user/setprimaryaddress/%d", work.ID), "").wantCode(http.StatusOK).decode(&addresses)
+	if len(addresses) != 2 || addresses[0].IsPrimary || !addresses[1].IsPrimary {
+		t.Errorf("addresses = %+v, want work as the only primary", addresses)
+	}
+
+	// Editing cannot leave the user without a primary address.
+	c.do("PUT", fmt.Sprintf("/private/user/editaddress/%d", work.ID),
+		`{"name":"Office","street":"2 FC Road","city":"Pune","postalCode":"411004","phone":"9876543210","is_primary":false}`).wantCode(http.StatusOK)
+	if primary := c.primaryAddresses(); fmt.Sprint(primary) != fmt.Sprint([]int{work.ID}) {
+		t.Errorf("primary = %v after editing, want %d", primary, work.ID)
+	}
+
+	// Deleting the primary promotes the oldest remaining address.
+	c.do("DELETE", fmt.Sprintf("/private/user/deleteaddress/%d", work.ID), "").wantCode(http.StatusOK)
+	if primary := c.primaryAddresses(); fmt.Sprint(primary) != fmt.Sprint([]int{home}) {
+		t.Errorf("primary = %v after deleting, want %d", primary, home)
+	}
+}
+
+func TestSetPrimaryAddressNeedsTheUsersOwnAddress(t *testing.T) {
+	srv := newTestServer(t)
+	asha, ravi := srv.signUp("asha@example.com"), srv.signUp("ravi@example.com")
+	ravi.do("POST", "/private/user/addaddress", `{"name":"Work","street":"2 FC Road","city":"Pune","postalCode":"411004","phone":"9876543210"}`).
+		wantCode(http.StatusOK)
+
+	ravi.do("PUT", fmt.Sprintf("/private/user/setprimaryaddress/%d", asha.addressID), "").
+		wantError(http.StatusNotFound, "Address not found or not authorized")
+	ravi.do("PUT", "/private/user/setprimaryaddress/abc", "").wantError(http.StatusBadRequest, "Invalid address ID")
+	if primary := ravi.primaryAddresses(); fmt.Sprint(primary) != fmt.Sprint([]int{ravi.addressID}) {
+		t.Errorf("ravi's primary = %v, want %d", primary, ravi.addressID)
+	}
+	if primary := asha.primaryAddresses(); fmt.Sprint(primary) != fmt.Sprint([]int{asha.addressID}) {
+		t.Errorf("asha's primary = %v, want %d", primary, asha.addressID)
+	}
+}
+
+func TestAddressesAreValidated(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+
+	tests := []struct {
+		body, want string
+	}{
+		{`{"street":" ","city":"Pune","postalCode":"411001","phone":"9876543210"}`, "Street and city are required"},
+		{`{"street":"1 MG Road","city":"","postalCode":"411001","phone":"9876543210"}`, "Street and city are required"},
+		{`{"street":"1 MG Road","city":"Pune","postalCode":"41100","phone":"9876543210"}`, "Postal code must be 6 digits"},
+		{`{"street":"1 MG Road","city":"Pune","postalCode":"011001","phone":"9876543210"}`, "Postal code must be 6 digits"},
+		{`{"street":"1 MG Road","city":"Pune","postalCode":"411001","phone":"12345"}`, "Phone must be a 10 digit mobile number"},
+		{`{"street":"1 MG Road","city":"Pune","postalCode":"411001","phone":"5876543210"}`, "Phone must be a 10 digit mobile number"},
+	}
+	for _, tt := range tests {
+		c.do("POST", "/private/user/addaddress", tt.body).wantError(http.StatusBadRequest, tt.want)
+		c.do("PUT", fmt.Sprintf("/private/user/editaddress/%d", c.addressID), tt.body).wantError(http.StatusBadRequest, tt.want)
+	}
+}
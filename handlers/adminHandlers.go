@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/middleware"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
+)
+
+// The handlers in this file are only reachable by admins; main registers
+// them behind middleware.Authorize.
+
+// SetUserRole changes another user's role. Admins cannot change their own,
+// so there is always at least one admin left.
+func (h *Handler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPut {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	admin, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	userID, ok := pathIDOrWriteError(w, r, "id", "Invalid user ID")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if !models.IsRole(req.Role) {
+		WriteError(w, r, http.StatusBadRequest, "Unknown role")
+		return
+	}
+	if userID == admin.Id {
+		WriteError(w, r, http.StatusForbidden, "Admins cannot change their own role")
+		return
+	}
+
+	err := h.Store.Users.SetRole(r.Context(), userID, req.Role)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error setting role of user %d: %v", userID, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to set role")
+		return
+	}
+
+	log.Printf("Admin %d set the role of user %d to %s", admin.Id, userID, req.Role)
+	WriteSuccessMessage(w, r, "Role updated")
+}
+
+// AddRestaurantOwner lets a restaurant owner account act on a restaurant's
+// orders.
+func (h *Handler) AddRestaurantOwner(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	restaurantID, ok := pathIDOrWriteError(w, r, "id", "Invalid restaurant ID")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		UserID int `json:"user_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	owner, err := h.Store.Users.GetByID(r.Context(), req.UserID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusBadRequest, "User not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching user %d: %v", req.UserID, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to add owner")
+		return
+	}
+	if owner.Role != models.RoleRestaurantOwner {
+		WriteError(w, r, http.StatusConflict, "User is not a restaurant owner")
+		return
+	}
+
+	err = h.Store.Restaurants.AddOwner(r.Context(), restaurantID, owner.Id)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, "Restaurant not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error adding owner %d to restaurant %d: %v", owner.Id, restaurantID, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to add owner")
+		return
+	}
+	WriteSuccessMessage(w, r, "Owner added")
+}
+
+// RemoveRestaurantOwner takes a restaurant away from an owner account.
+func (h *Handler) RemoveRestaurantOwner(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	restaurantID, ok := pathIDOrWriteError(w, r, "id", "Invalid restaurant ID")
+	if !ok {
+		return
+	}
+	userID, ok := pathIDOrWriteError(w, r, "user_id", "Invalid user ID")
+	if !ok {
+		return
+	}
+
+	err := h.Store.Restaurants.RemoveOwner(r.Context(), restaurantID, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, "User does not own this restaurant")
+		return
+	}
+	if err != nil {
+		log.Printf("Error removing owner %d from restaurant %d: %v", userID, restaurantID, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to remove owner")
+		return
+	}
+	WriteSuccessMessage(w, r, "Owner removed")
+}
+
+// pathIDOrWriteError reads a positive ID from the route variable key,
+// answering the request with message when it is not one.
+func pathIDOrWriteError(w http.ResponseWriter, r *http.Request, key, message string) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)[key])
+	if err != nil || id <= 0 {
+		WriteError(w, r, http.StatusBadRequest, message)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,91 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+func TestStaffRoutesRequireARole(t *testing.T) {
+	srv := newTestServer(t)
+	customer := srv.signUp("asha@example.com")
+	orderID := customer.checkout(10, 1)
+	srv.markPaid(orderID)
+
+	for _, req := range []struct{ method, path, body string }{
+		{"POST", fmt.Sprintf("/private/orders/%d/refund", orderID), `{"amount":10}`},
+		{"POST", fmt.Sprintf("/private/orders/%d/eta", orderID), `{"eta_minutes":20}`},
+		{"POST", fmt.Sprintf("/private/orders/%d/location", orderID), `{"lat":18.5,"lng":73.8}`},
+		{"PUT", fmt.Sprintf("/private/admin/users/%d/role", customer.user.Id), `{"role":"admin"}`},
+		{"POST", "/private/admin/restaurants/1/owners", fmt.Sprintf(`{"user_id":%d}`, customer.user.Id)},
+	} {
+		customer.do(req.method, req.path, req.body).wantError(http.StatusForbidden, "Forbidden: Insufficient role")
+	}
+	srv.client().do("PUT", "/private/admin/users/1/role", `{"role":"admin"}`).
+		wantError(http.StatusUnauthorized, "Unauthorized: User not authenticated")
+}
+
+func TestRestaurantOwnersActOnlyOnTheirRestaurantsOrders(t *testing.T) {
+	srv := newTestServer(t)
+	customer := srv.signUp("asha@example.com")
+	owner := srv.signUpAs("owner@example.com", models.RoleRestaurantOwner, 1)
+	other := srv.signUpAs("other@example.com", models.RoleRestaurantOwner, 2)
+	courier := srv.signUpAs("courier@example.com", models.RoleCourier)
+
+	orderID := customer.checkout(10, 1)
+	srv.markPaid(orderID)
+	path := fmt.Sprintf("/private/orders/%d", orderID)
+
+	owner.do("GET", path, "").wantCode(http.StatusOK)
+	courier.do("GET", path, "").wantCode(http.StatusOK)
+	other.do("GET", path, "").wantError(http.StatusNotFound, "Order not found")
+	other.do("POST", path+"/refund", `{"amount":10}`).wantError(http.StatusNotFound, "Order not found")
+	other.do("POST", path+"/cancel", `{}`).wantError(http.StatusNotFound, "Order not found")
+
+	var orders models.OrderPage
+	other.do("GET", "/private/orders", "").wantCode(http.StatusOK).decode(&orders)
+	if len(orders.Orders) != 0 {
+		t.Errorf("other restaurant's owner lists %+v", orders.Orders)
+	}
+
+	var refund struct {
+		Status string        `json:"status"`
+		Refund models.Refund `json:"refund"`
+	}
+	owner.do("POST", path+"/refund", `{"amount":10,"reason":"Missing chutney"}`).wantCode(http.StatusOK).decode(&refund)
+	if refund.Status != models.OrderPartiallyRefunded || refund.Refund.Amount != 10 || refund.Refund.ActorUserID != owner.user.Id {
+		t.Errorf("refund = %+v", refund)
+	}
+}
+
+func TestAdminSetsRoles(t *testing.T) {
+	srv := newTestServer(t)
+	admin := srv.signUpAs("admin@example.com", models.RoleAdmin)
+	ravi := srv.signUp("ravi@example.com")
+	rolePath := fmt.Sprintf("/private/admin/users/%d/role", ravi.user.Id)
+	ownersPath := "/private/admin/restaurants/1/owners"
+	owner := fmt.Sprintf(`{"user_id":%d}`, ravi.user.Id)
+
+	admin.do("PUT", rolePath, `{"role":"superuser"}`).wantError(http.StatusBadRequest, "Unknown role")
+	admin.do("PUT", "/private/admin/users/999/role", `{"role":"courier"}`).wantError(http.StatusNotFound, "User not found")
+	admin.do("PUT", fmt.Sprintf("/private/admin/users/%d/role", admin.user.Id), `{"role":"customer"}`).
+		wantError(http.StatusForbidden, "Admins cannot change their own role")
+	admin.do("POST", ownersPath, owner).wantError(http.StatusConflict, "User is not a restaurant owner")
+
+	customer := srv.signUp("asha@example.com")
+	orderID := customer.checkout(10, 1)
+	ravi.do("GET", fmt.Sprintf("/private/orders/%d", orderID), "").wantCode(http.StatusNotFound)
+
+	// A new role applies from the user's next request.
+	admin.do("PUT", rolePath, `{"role":"restaurant_owner"}`).wantCode(http.StatusOK)
+	admin.do("POST", ownersPath, owner).wantCode(http.StatusOK)
+	ravi.do("GET", fmt.Sprintf("/private/orders/%d", orderID), "").wantCode(http.StatusOK)
+
+	var user models.User
+	ravi.do("GET", "/private/user/getuser", "").wantCode(http.StatusOK).decode(&user)
+	if user.Role != models.RoleRestaurantOwner {
+		t.Errorf("role = %q, want restaurant_owner", user.Role)
+	}
+}
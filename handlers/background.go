@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+)
+
+// backgroundTimeout bounds work a request leaves running after it has been
+// answered.
+const backgroundTimeout = 30 * time.Second
+
+// inBackground runs fn after r is answered, so that how long fn takes, or
+// whether it runs at all, does not show in the response time. Errors are
+// logged as "Error <what>".
+func (h *Handler) inBackground(r *http.Request, what string, fn func(ctx context.Context) error) {
+	ctx := context.WithoutCancel(r.Context())
+	h.background.Add(1)
+	go func() {
+		defer h.background.Done()
+		ctx, cancel := context.WithTimeout(ctx, backgroundTimeout)
+		defer cancel()
+		if err := fn(ctx); err != nil {
+			log.Printf("Error %s: %v", what, err)
+		}
+	}()
+}
+
+// Wait blocks until the work requests left running in the background has
+// finished. main calls it on shutdown, before closing the database.
+func (h *Handler) Wait() {
+	h.background.Wait()
+}
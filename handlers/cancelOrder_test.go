@@ -0,0 +1,143 @@
+package handlers_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+type cancelResponse struct {
+	Status string         `json:"status"`
+	Refund *models.Refund `json:"refund"`
+}
+
+func TestCustomerCancelRefundsThePayment(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	intruder := srv.signUp("ravi@example.com")
+	orderID := c.checkout(10, 1)
+	srv.markPaid(orderID)
+	path := fmt.Sprintf("/private/orders/%d/cancel", orderID)
+
+	intruder.do("POST", path, "").wantError(http.StatusNotFound, "Order not found")
+	c.do("POST", path, `{"amount":50}`).wantError(http.StatusForbidden, "Cancelled orders are refunded in full")
+	c.do("POST", path, `{"amount":150}`).wantError(http.StatusBadRequest, "Invalid refund amount")
+
+	var res cancelResponse
+	c.do("POST", path, `{"reason":"Changed my mind"}`).wantCode(http.StatusOK).decode(&res)
+	if res.Status != models.OrderRefunded || res.Refund == nil || res.Refund.Amount != 100 {
+		t.Errorf("cancel = %+v, want refunded with 100 back", res)
+	}
+	if order := srv.order(orderID); order.Status != models.OrderRefunded || len(order.Refunds) != 1 {
+		t.Errorf("order = %s with refunds %+v, want refunded once", order.Status, order.Refunds)
+	}
+	c.do("POST", path, "").wantError(http.StatusConflict, "Order cannot move from refunded to cancelled")
+}
+
+func TestCancelAfterAcceptance(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	restaurant := srv.signUpAs("kitchen@example.com", models.RoleRestaurantOwner, 1)
+	orderID := c.checkout(10, 1, 11, 1)
+	srv.markPaid(orderID)
+	restaurant.do("POST", fmt.Sprintf("/private/orders/%d/status", orderID), `{"status":"accepted"}`).wantCode(http.StatusOK)
+	path := fmt.Sprintf("/private/orders/%d/cancel", orderID)
+
+	c.do("POST", path, "").wantError(http.StatusConflict, "Order cannot move from accepted to cancelled")
+
+	// The restaurant may keep part of the payment.
+	var res cancelResponse
+	restaurant.do("POST", path, `{"amount":100,"reason":"Out of idli"}`).wantCode(http.StatusOK).decode(&res)
+	if res.Status != models.OrderPartiallyRefunded || res.Refund == nil || res.Refund.Amount != 100 {
+		t.Errorf("cancel = %+v, want partially refunded with 100 back", res)
+	}
+	if refunds := srv.order(orderID).Refunds; len(refunds) != 1 || refunds[0].ActorUserID != restaurant.user.Id {
+		t.Errorf("refunds = %+v, want one by the restaurant", refunds)
+	}
+}
+
+func TestCancelUnpaidOrderClosesTheCheckout(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	orderID := c.checkout(10, 1)
+	path := fmt.Sprintf("/private/orders/%d/cancel", orderID)
+
+	// The fake's checkouts are paid as soon as they open; the order is
+	// pending until the webhook says so.
+	c.do("POST", path, "").wantError(http.StatusConflict, "Order has just been paid, please try again")
+
+	srv.payments.fail(nil, errors.New("gateway down"))
+	c.do("POST", path, "").wantError(http.StatusBadGateway, "Checkout could not be closed")
+	srv.payments.fail(nil, nil)
+	if status := srv.order(orderID).Status; status != models.OrderPending {
+		t.Errorf("status = %s after failed cancels, want pending", status)
+	}
+
+	srv.fake.Expire(srv.order(orderID).SessionID)
+	var res cancelResponse
+	c.do("POST", path, "").wantCode(http.StatusOK).decode(&res)
+	if res.Status != models.OrderCancelled || res.Refund != nil {
+		t.Errorf("cancel = %+v, want cancelled without a refund", res)
+	}
+}
+
+func TestRefundOrder(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	admin := srv.signUpAs("admin@example.com", models.RoleAdmin)
+	orderID := c.checkout(10, 1)
+	path := fmt.Sprintf("/private/orders/%d/refund", orderID)
+
+	c.do("POST", path, `{"amount":10}`).wantError(http.StatusForbidden, "Forbidden: Insufficient role")
+	admin.do("POST", path, `{"amount":10}`).wantError(http.StatusConflict, "Order pending has no payment to refund")
+	srv.markPaid(orderID)
+
+	var res cancelResponse
+	admin.do("POST", path, `{"amount":10,"reason":"Missing chutney"}`).wantCode(http.StatusOK).decode(&res)
+	if res.Status != models.OrderPartiallyRefunded || res.Refund == nil || res.Refund.Amount != 10 {
+		t.Errorf("refund = %+v, want 10 back", res)
+	}
+	admin.do("POST", path, `{"amount":95}`).wantError(http.StatusBadRequest, "Invalid refund amount")
+
+	// No amount refunds whatever is left.
+	admin.do("POST", path, "{}").wantCode(http.StatusOK).decode(&res)
+	if res.Status != models.OrderRefunded || res.Refund == nil || res.Refund.Amount != 90 {
+		t.Errorf("refund = %+v, want the remaining 90 back", res)
+	}
+	if order := srv.order(orderID); order.RefundedAmount() != 100 || len(order.Refunds) != 2 {
+		t.Errorf("order refunds = %+v, want 100 in two refunds", order.Refunds)
+	}
+}
+
+func TestCancelRetriesAFailedRefund(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	intruder := srv.signUp("ravi@example.com")
+	orderID := c.checkout(10, 1)
+	srv.markPaid(orderID)
+	path := fmt.Sprintf("/private/orders/%d/cancel", orderID)
+
+	srv.payments.fail(errors.New("gateway down"), nil)
+	c.do("POST", path, `{"reason":"Changed my mind"}`).wantError(http.StatusBadGateway, "Refund could not be issued")
+	srv.payments.fail(nil, nil)
+	if order := srv.order(orderID); order.Status != models.OrderCancelled || len(order.Refunds) != 0 {
+		t.Fatalf("order = %s with refunds %+v, want cancelled without refunds", order.Status, order.Refunds)
+	}
+
+	intruder.do("POST", path, "").wantError(http.StatusNotFound, "Order not found")
+	c.do("POST", path, `{"amount":50}`).wantError(http.StatusForbidden, "Cancelled orders are refunded in full")
+
+	var res cancelResponse
+	c.do("POST", path, "").wantCode(http.StatusOK).decode(&res)
+	if res.Status != models.OrderRefunded || res.Refund == nil || res.Refund.Amount != 100 || res.Refund.Reason != "Changed my mind" {
+		t.Errorf("retry = %+v, want refunded in full for the original reason", res)
+	}
+	c.do("POST", path, "").wantError(http.StatusConflict, "Order cannot move from refunded to cancelled")
+
+	if n := len(srv.order(orderID).Refunds); n != 1 {
+		t.Errorf("%d refunds, want 1", n)
+	}
+}
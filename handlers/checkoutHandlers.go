@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/middleware"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/payment"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
+)
+
+// CheckoutCart starts checkout for the user's active cart. The order is a
+// snapshot of the synced cart priced from the menu, so the client does not
+// resend its items; the body only names the delivery address.
+func (h *Handler) CheckoutCart(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+	if !h.verifiedOrWriteError(w, r, user) {
+		return
+	}
+
+	var req struct {
+		AddressID int `json:"address_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	address, ok := h.deliveryAddressOrWriteError(w, r, user.Id, req.AddressID)
+	if !ok {
+		return
+	}
+
+	cart, err := h.Store.Carts.GetActive(r.Context(), user.Id)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, "No active cart")
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching cart for user %d: %v", user.Id, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to fetch cart")
+		return
+	}
+	if len(cart.Items) == 0 {
+		WriteError(w, r, http.StatusBadRequest, "Cart is empty")
+		return
+	}
+
+	priced, ok := h.priceOrWriteError(w, r, user.Id, cart.Items)
+	if !ok {
+		return
+	}
+
+	h.startCheckout(w, r, user.Id, cart.ID, address, priced)
+}
+
+// deliveryAddressOrWriteError snapshots one of the user's addresses for an
+// order, answering the request itself when there is none to use.
+func (h *Handler) deliveryAddressOrWriteError(w http.ResponseWriter, r *http.Request, userID, addressID int) (*models.DeliveryAddress, bool) {
+	if addressID <= 0 {
+		WriteError(w, r, http.StatusBadRequest, "address_id is required")
+		return nil, false
+	}
+	address, err := h.Store.Addresses.Get(r.Context(), userID, addressID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusBadRequest, "Address not found")
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Error fetching address %d for user %d: %v", addressID, userID, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to fetch address")
+		return nil, false
+	}
+	return models.NewDeliveryAddress(address), true
+}
+
+// priceOrWriteError prices items, answering the request itself when that
+// fails.
+func (h *Handler) priceOrWriteError(w http.ResponseWriter, r *http.Request, userID int, items []models.OrderItem) (quote, bool) {
+	priced, err := h.priceItems(r.Context(), items)
+	var invalid invalidItemsError
+	if errors.As(err, &invalid) {
+		WriteError(w, r, http.StatusBadRequest, invalid.Error())
+		return quote{}, false
+	}
+	if err != nil {
+		log.Printf("Error pricing checkout for user %d: %v", userID, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to price order")
+		return quote{}, false
+	}
+	return priced, true
+}
+
+// startCheckout saves a pending order for priced and opens a checkout session
+// for it with the payment provider. cartID links the order to the cart it was taken from, or is
+// 0 when the items came in the request. address is where the order is delivered.
+func (h *Handler) startCheckout(w http.ResponseWriter, r *http.Request, userID, cartID int, address *models.DeliveryAddress, priced quote) {
+	// The order is saved first so the checkout session can carry its ID;
+	// payment webhooks use it to find the order again.
+	order := models.Order{
+		UserID:       userID,
+		CartID:       cartID,
+		RestaurantID: priced.RestaurantID,
+		Address:      address,
+		Items:        priced.Items,
+		TotalAmount:  priced.TotalAmount(),
+		Currency:     "INR",
+		Status:       models.OrderPending,
+	}
+	if err := h.Store.Orders.Create(r.Context(), &order); err != nil {
+		log.Printf("Error saving order: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to save order")
+		return
+	}
+
+	req := payment.SessionRequest{
+		OrderID:   order.OrderID,
+		Currency:  order.Currency,
+		ReturnURL: h.Config.App.PublicURL + "/return",
+	}
+	// Only set when the request came through Idempotent. A retry after a
+	// server error creates another order, so the key names the order too.
+	if key := providerIdempotencyKey(r); key != "" {
+		req.IdempotencyKey = key + ":" + strconv.Itoa(order.OrderID)
+	}
+	for _, item := range priced.Items {
+		req.LineItems = append(req.LineItems, payment.LineItem{
+			Name:        item.Name,
+			Description: fmt.Sprintf("Item from Restaurant ID: %d", item.RestaurantID),
+			ImageURL:    h.Config.App.ImageBaseURL + item.CloudImageID,
+			UnitAmount:  toPaise(item.Price),
+			Quantity:    int64(item.Quantity),
+		})
+	}
+
+	s, err := h.Payments.CreateSession(r.Context(), req)
+	if err != nil {
+		log.Printf("Error creating checkout session: %v", err)
+		failed := store.PaymentUpdate{OrderID: order.OrderID, Status: models.OrderFailed}
+		if _, err := h.Store.Orders.ApplyPaymentUpdate(r.Context(), failed); err != nil {
+			log.Printf("Error marking order %d as failed: %v", order.OrderID, err)
+		}
+		WriteError(w, r, http.StatusInternalServerError, "Failed to create checkout session")
+		return
+	}
+
+	if err := h.Store.Orders.AttachSession(r.Context(), order.OrderID, s.ID); err != nil {
+		log.Printf("Error saving session for order %d: %v", order.OrderID, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to save order")
+		return
+	}
+
+	// Return response
+	response := struct {
+		ClientSecret string  `json:"clientSecret"`
+		OrderID      int     `json:"orderId"`
+		Amount       float64 `json:"amount"`
+	}{
+		ClientSecret: s.ClientSecret,
+		OrderID:      order.OrderID,
+		Amount:       order.TotalAmount,
+	}
+
+	WriteSuccessMessage(w, r, response)
+}
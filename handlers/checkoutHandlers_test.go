@@ -0,0 +1,195 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/ratelimit"
+)
+
+func TestCheckoutCartNeedsItems(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	address := fmt.Sprintf(`{"address_id":%d}`, c.addressID)
+
+	c.do("POST", "/private/payment/checkout-cart", address).wantError(http.StatusNotFound, "No active cart")
+
+	var cart struct {
+		ID int `json:"cart_id"`
+	}
+	c.do("GET", "/private/user/getcart", "").wantCode(http.StatusOK).decode(&cart)
+	c.do("POST", "/private/payment/checkout-cart", address).wantError(http.StatusBadRequest, "Cart is empty")
+
+	c.do("POST", fmt.Sprintf("/private/user/synccart/%d", cart.ID), `{"items":[]}`).wantCode(http.StatusOK)
+	c.do("POST", "/private/payment/checkout-cart", address).wantError(http.StatusBadRequest, "Cart is empty")
+}
+
+func TestCheckoutPricesItemsFromTheMenu(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+
+	// Client prices, names and amounts are ignored.
+	res := c.do("POST", "/private/payment/create-checkout-session", fmt.Sprintf(`{"amount":1,"address_id":%d,"items":[
+		{"id":10,"quantity":2,"price":1,"name":"Free dosa"},
+		{"id":11,"quantity":1,"price":0.01}]}`, c.addressID)).wantCode(http.StatusOK)
+	var session struct {
+		ClientSecret string  `json:"clientSecret"`
+		OrderID      int     `json:"orderId"`
+		Amount       float64 `json:"amount"`
+	}
+	res.decode(&session)
+	if session.Amount != 240.1 || session.ClientSecret == "" {
+		t.Errorf("session = %+v, want 240.1 with a client secret", session)
+	}
+
+	order := c.order(session.OrderID)
+	if order.Status != "pending" || order.TotalAmount != 240.1 || order.SessionID != fmt.Sprintf("cs_fake_%d", order.OrderID) {
+		t.Errorf("order = %+v, want a pending order of 240.1 linked to its session", order)
+	}
+
+	c.do("POST", "/private/payment/create-checkout-session", fmt.Sprintf(`{"address_id":%d,"items":[{"id":99,"quantity":1}]}`, c.addressID)).
+		wantError(http.StatusBadRequest, "Unknown item 99")
+}
+
+func TestCheckoutCartPricesTheCartFromTheMenu(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+
+	var cart struct {
+		ID int `json:"cart_id"`
+	}
+	c.do("GET", "/private/user/getcart", "").wantCode(http.StatusOK).decode(&cart)
+	c.do("POST", fmt.Sprintf("/private/user/synccart/%d", cart.ID),
+		`{"items":[{"id":20,"quantity":2,"price":1,"restrauntId":2}]}`).wantCode(http.StatusOK)
+
+	var session struct {
+		OrderID int     `json:"orderId"`
+		Amount  float64 `json:"amount"`
+	}
+	c.do("POST", "/private/payment/checkout-cart", fmt.Sprintf(`{"address_id":%d}`, c.addressID)).
+		wantCode(http.StatusOK).decode(&session)
+	if session.Amount != 499 {
+		t.Errorf("amount = %v, want 499", session.Amount)
+	}
+	if order := c.order(session.OrderID); order.TotalAmount != 499 || order.CartID != cart.ID {
+		t.Errorf("order = %+v, want 499 from cart %d", order, cart.ID)
+	}
+}
+
+func TestSessionStatusOnlyReports(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	paidOrder, expiredOrder := c.checkout(10, 1), c.checkout(11, 1)
+
+	type sessionStatus struct {
+		Status      string `json:"status"`
+		OrderID     int    `json:"order_id"`
+		OrderStatus string `json:"order_status"`
+	}
+	var status sessionStatus
+	c.do("GET", fmt.Sprintf("/private/payment/session-status?session_id=cs_fake_%d", paidOrder), "").
+		wantCode(http.StatusOK).decode(&status)
+	// The webhook has not arrived yet, so the order is still pending.
+	if status != (sessionStatus{"complete", paidOrder, models.OrderPending}) || c.order(paidOrder).Status != models.OrderPending {
+		t.Errorf("paid: %+v, order %s; want complete and pending", status, c.order(paidOrder).Status)
+	}
+	srv.markPaid(paidOrder)
+	c.do("GET", fmt.Sprintf("/private/payment/session-status?session_id=cs_fake_%d", paidOrder), "").
+		wantCode(http.StatusOK).decode(&status)
+	if status.OrderStatus != models.OrderCompleted {
+		t.Errorf("after the webhook: order %s, want completed", status.OrderStatus)
+	}
+
+	var cart, after struct {
+		ID int `json:"cart_id"`
+	}
+	c.do("GET", "/private/user/getcart", "").wantCode(http.StatusOK).decode(&cart)
+	srv.fake.Expire(fmt.Sprintf("cs_fake_%d", expiredOrder))
+	c.do("GET", fmt.Sprintf("/private/payment/session-status?session_id=cs_fake_%d", expiredOrder), "").
+		wantCode(http.StatusOK).decode(&status)
+	if status.Status != "expired" || c.order(expiredOrder).Status != models.OrderPending {
+		t.Errorf("expired: session %s, order %s; want expired and pending", status.Status, c.order(expiredOrder).Status)
+	}
+	c.do("GET", "/private/user/getcart", "").wantCode(http.StatusOK).decode(&after)
+	if after.ID != cart.ID {
+		t.Error("checking an unpaid session deactivated the cart")
+	}
+
+	c.do("GET", "/private/payment/session-status", "").wantError(http.StatusBadRequest, "Missing session_id")
+	c.do("GET", "/private/payment/session-status?session_id=cs_unknown", "").
+		wantError(http.StatusNotFound, "Checkout session not found")
+}
+
+func TestSessionStatusHidesOtherCustomersSessions(t *testing.T) {
+	srv := newTestServer(t)
+	asha, ravi := srv.signUp("asha@example.com"), srv.signUp("ravi@example.com")
+	orderID := asha.checkout(10, 1)
+
+	ravi.do("GET", fmt.Sprintf("/private/payment/session-status?session_id=cs_fake_%d", orderID), "").
+		wantError(http.StatusNotFound, "Checkout session not found")
+}
+
+func TestCheckoutSnapshotsTheDeliveryAddress(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	orderID := c.checkout(10, 1)
+
+	want := models.DeliveryAddress{AddressID: c.addressID, Name: "Home", Street: "1 MG Road", City: "Pune", PostalCode: "411001", Phone: "9876543210"}
+	var order models.Order
+	c.do("GET", fmt.Sprintf("/private/orders/%d", orderID), "").wantCode(http.StatusOK).decode(&order)
+	if order.Address == nil || *order.Address != want {
+		t.Fatalf("address = %+v, want %+v", order.Address, want)
+	}
+
+	// Later edits and deletions leave the order's copy alone.
+	c.do("PUT", fmt.Sprintf("/private/user/editaddress/%d", c.addressID),
+		`{"name":"Work","street":"2 FC Road","city":"Pune","postalCode":"411004","phone":"9876543210"}`).wantCode(http.StatusOK)
+	c.do("GET", fmt.Sprintf("/private/orders/%d", orderID), "").wantCode(http.StatusOK).decode(&order)
+	if order.Address == nil || *order.Address != want {
+		t.Errorf("address = %+v after editing, want %+v", order.Address, want)
+	}
+	c.do("DELETE", fmt.Sprintf("/private/user/deleteaddress/%d", c.addressID), "").wantCode(http.StatusOK)
+	want.AddressID = 0
+	if order := srv.order(orderID); order.Address == nil || *order.Address != want {
+		t.Errorf("address = %+v after deleting, want %+v", order.Address, want)
+	}
+}
+
+func TestCheckoutNeedsOneOfTheUsersAddresses(t *testing.T) {
+	srv := newTestServer(t)
+	asha, ravi := srv.signUp("asha@example.com"), srv.signUp("ravi@example.com")
+	items := `"items":[{"id":10,"quantity":1}]`
+
+	asha.do("POST", "/private/payment/create-checkout-session", "{"+items+"}").
+		wantError(http.StatusBadRequest, "address_id is required")
+	asha.do("POST", "/private/payment/create-checkout-session", fmt.Sprintf(`{"address_id":%d,%s}`, ravi.addressID, items)).
+		wantError(http.StatusBadRequest, "Address not found")
+	asha.do("POST", "/private/payment/checkout-cart", fmt.Sprintf(`{"address_id":%d}`, ravi.addressID)).
+		wantError(http.StatusBadRequest, "Address not found")
+	if n := orderCount(asha); n != 0 {
+		t.Errorf("%d orders, want none", n)
+	}
+}
+
+func TestCheckoutIsRateLimitedPerUser(t *testing.T) {
+	srv := newTestServer(t, func(cfg *config.Config) {
+		cfg.RateLimit.Checkout = ratelimit.Limit{Requests: 2, Period: time.Hour}
+	})
+	asha := srv.signUp("asha@example.com")
+	ravi := srv.signUp("ravi@example.com")
+
+	asha.checkout(10, 1)
+	asha.checkout(10, 1)
+	res := asha.do("POST", "/private/payment/create-checkout-session", fmt.Sprintf(`{"items":[{"id":10,"quantity":1}],"address_id":%d}`, asha.addressID))
+	res.wantError(http.StatusTooManyRequests, "Too many requests")
+	if res.header.Get("Retry-After") == "" {
+		t.Error("no Retry-After")
+	}
+	// The tighter checkout limit leaves the other routes and users alone.
+	asha.do("GET", "/private/orders", "").wantCode(http.StatusOK)
+	ravi.checkout(10, 1)
+}
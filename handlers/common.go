@@ -1,21 +1,61 @@
 package handlers
+
 import (
 	"encoding/json"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/sessions"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/events"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/mail"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/payment"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/sms"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
 )
 
+// Handler carries the dependencies shared by the HTTP handlers, so they can be
+// wired against Postgres in main and against store.Memory in tests.
+type Handler struct {
+	Config   *config.Config
+	Store    *store.Store
+	Sessions *sessions.CookieStore
+	Payments payment.Provider
+	// Events streams order updates to clients. Status changes reach it
+	// through Store.Orders, which main wraps with events.PublishOrderChanges.
+	Events *events.Broker
+	// Mailer sends account emails such as password reset links.
+	Mailer mail.Mailer
+	// SMS sends text messages such as one-time login codes.
+	SMS sms.Sender
+
+	// ReadinessChecks are run by /readyz.
+	ReadinessChecks []ReadinessCheck
+
+	// background tracks work started by inBackground.
+	background sync.WaitGroup
+}
+
+func New(cfg *config.Config, st *store.Store, sessionStore *sessions.CookieStore, payments payment.Provider, broker *events.Broker, mailer mail.Mailer, sender sms.Sender) *Handler {
+	return &Handler{Config: cfg, Store: st, Sessions: sessionStore, Payments: payments, Events: broker, Mailer: mailer, SMS: sender}
+}
+
 type CustomUIResponse struct {
 	Status  string      `json:"status,omitempty"`
 	Message string      `json:"message,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
 }
 
-func setupResponse(w *http.ResponseWriter) {
+func (h *Handler) setupResponse(w *http.ResponseWriter) {
 	(*w).Header().Set("Content-Type", "application/json")
-	(*w).Header().Set("Access-Control-Allow-Origin", "http://localhost:3000")
+	(*w).Header().Set("Access-Control-Allow-Origin", h.Config.CORS.AllowedOrigin)
 	(*w).Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-	(*w).Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+	(*w).Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Idempotency-Key, Last-Event-ID")
 	(*w).Header().Set("Access-Control-Allow-Credentials", "true")
 }
 
@@ -37,6 +77,12 @@ func WriteError(w http.ResponseWriter, r *http.Request, code int, errresp interf
 	w.Write(body)
 }
 
+// setRetryAfter tells the client how long to wait, in whole seconds rounded
+// up, before trying again.
+func setRetryAfter(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+}
+
 func WriteSuccessMessage(w http.ResponseWriter, r *http.Request, data interface{}) {
 	log.Printf(
 		"%s %s ",
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/mail"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/middleware"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
+)
+
+// sendVerificationEmail emails user a link that confirms they own their
+// current email address.
+func (h *Handler) sendVerificationEmail(ctx context.Context, user models.User) error {
+	token, tokenHash, err := newToken()
+	if err != nil {
+		return err
+	}
+	ttl := h.Config.Auth.EmailVerificationTTL
+	verification := models.EmailVerification{
+		UserID:    user.Id,
+		Email:     user.Email,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := h.Store.Verifications.Create(ctx, &verification); err != nil {
+		return err
+	}
+
+	link := h.Config.App.PublicURL + "/verify-email?token=" + url.QueryEscape(token)
+	return h.Mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Verify your FoodHaven email",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm that this is your email address by opening this link within %d hours:\n\n%s\n\n"+
+			"If you did not create a FoodHaven account, you can ignore this email.\n", user.Name, int(ttl.Hours()), link),
+	})
+}
+
+// VerifyEmail marks the user's email verified using the token from a
+// verification link. It does not need a session, so the link works in any
+// browser.
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.Token == "" {
+		WriteError(w, r, http.StatusBadRequest, "Token is required")
+		return
+	}
+
+	userID, err := h.Store.Verifications.Verify(r.Context(), hashToken(req.Token))
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusBadRequest, "Verification link is invalid or has expired")
+		return
+	}
+	if err != nil {
+		log.Printf("Error verifying email: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to verify email")
+		return
+	}
+
+	log.Printf("Email verified for user %d", userID)
+	WriteSuccessMessage(w, r, "Email verified")
+}
+
+// ResendVerificationEmail sends the signed in user a new verification link,
+// at most once every Auth.VerificationResendInterval.
+func (h *Handler) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+	if user.EmailVerified {
+		WriteError(w, r, http.StatusConflict, "Email is already verified")
+		return
+	}
+
+	last, err := h.Store.Verifications.LastSentAt(r.Context(), user.Id)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		log.Printf("Error fetching last verification email for user %d: %v", user.Id, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to send verification email")
+		return
+	}
+	if wait := time.Until(last.Add(h.Config.Auth.VerificationResendInterval)); err == nil && wait > 0 {
+		setRetryAfter(w, wait)
+		WriteError(w, r, http.StatusTooManyRequests, "Please wait before requesting another verification email")
+		return
+	}
+
+	if err := h.sendVerificationEmail(r.Context(), user); err != nil {
+		log.Printf("Error sending verification email to user %d: %v", user.Id, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to send verification email")
+		return
+	}
+	WriteSuccessMessage(w, r, "Verification email sent")
+}
+
+// verifiedOrWriteError stops users with an unverified email from checking
+// out when Auth.RequireVerifiedEmail is set, answering the request itself.
+func (h *Handler) verifiedOrWriteError(w http.ResponseWriter, r *http.Request, user models.User) bool {
+	if h.Config.Auth.RequireVerifiedEmail && !user.EmailVerified {
+		WriteError(w, r, http.StatusForbidden, "Verify your email before checking out")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+func verifyBody(token string) string {
+	return fmt.Sprintf(`{"token":%q}`, token)
+}
+
+// emailVerified reports what getuser says about the client's email.
+func (c *testClient) emailVerified() bool {
+	c.t.Helper()
+	var user models.User
+	c.do("GET", "/private/user/getuser", "").wantCode(http.StatusOK).decode(&user)
+	return user.EmailVerified
+}
+
+func TestCheckoutNeedsAVerifiedEmail(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.register("asha@example.com")
+	if c.user.EmailVerified || c.emailVerified() {
+		t.Fatal("new account is already verified")
+	}
+	c.do("POST", "/private/payment/create-checkout-session", `{"items":[{"id":10,"quantity":1}]}`).
+		wantError(http.StatusForbidden, "Verify your email before checking out")
+	c.do("POST", "/private/payment/checkout-cart", "").
+		wantError(http.StatusForbidden, "Verify your email before checking out")
+
+	token := srv.mails.lastToken(t, "asha@example.com")
+	// The link works without a session.
+	srv.client().do("POST", "/public/user/verifyemail", verifyBody(token)).wantCode(http.StatusOK)
+	if !c.emailVerified() {
+		t.Error("email not verified")
+	}
+	srv.client().do("POST", "/public/user/verifyemail", verifyBody(token)).
+		wantError(http.StatusBadRequest, "Verification link is invalid or has expired")
+	c.do("POST", "/private/payment/checkout-cart", "").wantError(http.StatusBadRequest, "address_id is required")
+}
+
+func TestCheckoutWithoutRequiredVerification(t *testing.T) {
+	srv := newTestServer(t, func(cfg *config.Config) { cfg.Auth.RequireVerifiedEmail = false })
+	c := srv.register("asha@example.com")
+	c.do("POST", "/private/payment/checkout-cart", "").wantError(http.StatusBadRequest, "address_id is required")
+}
+
+func TestChangingTheEmailNeedsVerifyingAgain(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.register("asha@example.com")
+	oldToken := srv.mails.lastToken(t, "asha@example.com")
+
+	c.do("POST", "/private/user/edit", `{"name":"Asha","email":"asha.k@example.com","phone":"9000000001"}`).wantCode(http.StatusOK)
+	// A link sent to the old address no longer verifies the account.
+	srv.client().do("POST", "/public/user/verifyemail", verifyBody(oldToken)).
+		wantError(http.StatusBadRequest, "Verification link is invalid or has expired")
+	srv.client().do("POST", "/public/user/verifyemail", verifyBody(srv.mails.lastToken(t, "asha.k@example.com"))).
+		wantCode(http.StatusOK)
+	if !c.emailVerified() {
+		t.Fatal("new email not verified")
+	}
+
+	// Editing other details keeps the email verified; changing it does not.
+	c.do("POST", "/private/user/edit", `{"name":"Asha K","email":"asha.k@example.com","phone":"9000000001"}`).wantCode(http.StatusOK)
+	if !c.emailVerified() {
+		t.Error("renaming the user cleared the verification")
+	}
+	c.do("POST", "/private/user/edit", `{"name":"Asha K","email":"asha@example.com","phone":"9000000001"}`).wantCode(http.StatusOK)
+	if c.emailVerified() {
+		t.Error("changed email is still verified")
+	}
+}
+
+func TestVerificationLinksExpire(t *testing.T) {
+	srv := newTestServer(t, func(cfg *config.Config) { cfg.Auth.EmailVerificationTTL = time.Millisecond })
+	c := srv.register("asha@example.com")
+	time.Sleep(5 * time.Millisecond)
+
+	srv.client().do("POST", "/public/user/verifyemail", verifyBody(srv.mails.lastToken(t, "asha@example.com"))).
+		wantError(http.StatusBadRequest, "Verification link is invalid or has expired")
+	if c.emailVerified() {
+		t.Error("expired link verified the email")
+	}
+	srv.client().do("POST", "/public/user/verifyemail", verifyBody("")).wantError(http.StatusBadRequest, "Token is required")
+}
+
+func TestResendVerificationEmail(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.register("asha@example.com")
+
+	// Signing up sent the first email a moment ago.
+	res := c.do("POST", "/private/user/resendverification", "")
+	res.wantError(http.StatusTooManyRequests, "Please wait before requesting another verification email")
+	if res.header.Get("Retry-After") == "" {
+		t.Error("no Retry-After")
+	}
+	if n := len(srv.mails.sent("asha@example.com")); n != 1 {
+		t.Errorf("sent %d emails, want 1", n)
+	}
+
+	srv.cfg.Auth.VerificationResendInterval = 0
+	c.do("POST", "/private/user/resendverification", "").wantCode(http.StatusOK)
+	if n := len(srv.mails.sent("asha@example.com")); n != 2 {
+		t.Errorf("sent %d emails, want 2", n)
+	}
+	// Either link works, as long as it is for the current address.
+	srv.client().do("POST", "/public/user/verifyemail", verifyBody(srv.mails.lastToken(t, "asha@example.com"))).wantCode(http.StatusOK)
+	c.do("POST", "/private/user/resendverification", "").wantError(http.StatusConflict, "Email is already verified")
+}
@@ -1,18 +1,14 @@
 package handlers
 
 import (
-	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
 
-	db "github.com/vishal-sharma-001/FoodHaven-Backend/database"
 	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
 )
 
-var fetchfoodItemsQuery = "SELECT id, name, price, description, cloudimageid, category FROM FoodItems WHERE cloudimageid = $1"
-
-func GetFoodList(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetFoodList(w http.ResponseWriter, r *http.Request) {
 	id := r.URL.Query().Get("cloudimageid")
 
 	if id == "" {
@@ -20,17 +16,11 @@ func GetFoodList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	setupResponse(&w)
+	h.setupResponse(&w)
 
-	var response  CustomUIResponse
+	var response CustomUIResponse
 
-	dbClient, err := db.ConnectDB()
-	if err != nil {
-		log.Printf("Could not connect to the database: %v", err)
-	}
-	defer dbClient.Close()
-
-	foodItems, err := fetchFoodItems(dbClient, id)
+	items, err := h.Store.Food.ListByCloudImageID(r.Context(), id)
 	if err != nil {
 		response.Message = fmt.Sprintf("Error fetching food list. Error: [%s]", err.Error())
 		log.Printf(response.Message)
@@ -38,38 +28,13 @@ func GetFoodList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.Status = SUCCESS_STRING
-	response.Message = SUCCESS_STRING
-	response.Data = foodItems
-	WriteSuccessMessage(w, r, response)
-}
-
-func fetchFoodItems(dbClient *sql.DB, id string) (map[string][]models.FoodItems, error) {
-	var args []interface{}
-	args = append(args, id)
-
-	rows, err := dbClient.Query(fetchfoodItemsQuery, args...)
-	if err != nil {
-		log.Printf("Error executing SQL command: [%v]", err.Error())
-		return nil, err
-	}
-	defer rows.Close()
-
 	categorizedFoodItems := make(map[string][]models.FoodItems)
-
-	for rows.Next() {
-		var item models.FoodItems
-		if err := rows.Scan(&item.Id, &item.Name, &item.Price, &item.Description, &item.CloudImageID, &item.Category); err != nil {
-			log.Printf("Error scanning result rows: [%v]\n", err)
-			return nil, err
-		}
+	for _, item := range items {
 		categorizedFoodItems[item.Category] = append(categorizedFoodItems[item.Category], item)
 	}
 
-	if err := rows.Err(); err != nil {
-		log.Printf("Error with rows iteration: [%v]", err.Error())
-		return nil, err
-	}
-
-	return categorizedFoodItems, nil
+	response.Status = SUCCESS_STRING
+	response.Message = SUCCESS_STRING
+	response.Data = categorizedFoodItems
+	WriteSuccessMessage(w, r, response)
 }
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func registerFoodRoutes(r *mux.Router, h *Handler) {
+	r.NotFoundHandler = http.NotFoundHandler()
+
+	r.HandleFunc("/fooditems", h.GetFoodList).Methods("GET")
+}
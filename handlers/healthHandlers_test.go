@@ -0,0 +1,78 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/handlers"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
+)
+
+type readyzResponse struct {
+	Status string `json:"status"`
+	Checks map[string]struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	} `json:"checks"`
+}
+
+func readyz(t *testing.T, checks ...handlers.ReadinessCheck) (int, readyzResponse) {
+	t.Helper()
+	h := handlers.New(config.Default(), store.NewMemory().Store(), nil, nil, nil, nil, nil)
+	h.ReadinessChecks = checks
+
+	rec := httptest.NewRecorder()
+	h.Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var body readyzResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, body
+}
+
+func check(name string, err error) handlers.ReadinessCheck {
+	return handlers.ReadinessCheck{Name: name, Check: func(ctx context.Context) error { return err }}
+}
+
+func TestReadyz(t *testing.T) {
+	code, body := readyz(t, check("database", nil), handlers.StripeCheck("sk_test_123"))
+	if code != http.StatusOK || body.Status != "ok" {
+		t.Errorf("healthy: %d %q, want 200 ok", code, body.Status)
+	}
+	if len(body.Checks) != 2 || body.Checks["database"].Status != "ok" || body.Checks["stripe"].Status != "ok" {
+		t.Errorf("healthy checks = %+v", body.Checks)
+	}
+
+	code, body = readyz(t, check("database", errors.New("dial tcp: connection refused")), handlers.StripeCheck("sk_test_123"))
+	if code != http.StatusServiceUnavailable || body.Status != "unavailable" {
+		t.Errorf("database down: %d %q, want 503 unavailable", code, body.Status)
+	}
+	if body.Checks["database"].Status != "unavailable" || body.Checks["stripe"].Status != "ok" {
+		t.Errorf("database down checks = %+v", body.Checks)
+	}
+	// The probe is public, so the cause is only logged.
+	if body.Checks["database"].Error != "" {
+		t.Errorf("database error %q is exposed", body.Checks["database"].Error)
+	}
+
+	code, body = readyz(t, handlers.StripeCheck(""))
+	if code != http.StatusServiceUnavailable || body.Checks["stripe"].Status != "unavailable" {
+		t.Errorf("no Stripe key: %d %+v, want 503", code, body.Checks)
+	}
+}
+
+func TestHealthzIgnoresDependencies(t *testing.T) {
+	h := handlers.New(config.Default(), store.NewMemory().Store(), nil, nil, nil, nil, nil)
+	h.ReadinessChecks = []handlers.ReadinessCheck{check("database", errors.New("down"))}
+
+	rec := httptest.NewRecorder()
+	h.Healthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 while the database is down", rec.Code)
+	}
+}
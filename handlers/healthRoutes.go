@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"github.com/gorilla/mux"
+)
+
+// registerHealthRoutes adds the probe endpoints. They must be registered on
+// the root router so they are matched before the index.html fallback.
+func registerHealthRoutes(r *mux.Router, h *Handler) {
+	r.HandleFunc("/healthz", h.Healthz).Methods("GET", "HEAD")
+	r.HandleFunc("/readyz", h.Readyz).Methods("GET", "HEAD")
+	r.HandleFunc("/version", h.Version).Methods("GET", "HEAD")
+}
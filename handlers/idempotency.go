@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/middleware"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
+)
+
+const (
+	// IdempotencyKeyHeader lets clients retry a request without repeating
+	// its effect.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// maxIdempotencyKeyLen leaves room for the user and order scope added
+	// before the key is forwarded to the payment provider, which allows 255
+	// bytes.
+	maxIdempotencyKeyLen = 200
+	maxIdempotentBody    = 1 << 20
+)
+
+type idempotencyContextKey struct{}
+
+// Idempotent wraps a handler that creates something so that retries carrying
+// the same Idempotency-Key header replay the first response instead of
+// running again. Server errors are not kept, so a retry after one runs the
+// handler again. Keys are scoped to the authenticated user; requests without
+// the header are passed through untouched.
+func (h *Handler) Idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" || r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+
+		h.setupResponse(&w)
+
+		if len(key) > maxIdempotencyKeyLen {
+			WriteError(w, r, http.StatusBadRequest, "Idempotency-Key is too long")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
+		if !ok {
+			WriteError(w, r, http.StatusUnauthorized, "User not found in context")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+		if err != nil {
+			WriteError(w, r, http.StatusRequestEntityTooLarge, "Request body too large")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := store.IdempotencyRecord{UserID: user.Id, Key: key, RequestHash: requestHash(r, body)}
+		existing, claimed, err := h.Store.Idempotency.Begin(r.Context(), record)
+		if err != nil {
+			log.Printf("Error claiming idempotency key for user %d: %v", user.Id, err)
+			WriteError(w, r, http.StatusInternalServerError, "Failed to process request")
+			return
+		}
+
+		if !claimed {
+			switch {
+			case existing.RequestHash != record.RequestHash:
+				WriteError(w, r, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+			case existing.StatusCode == 0:
+				w.Header().Set("Retry-After", "1")
+				WriteError(w, r, http.StatusConflict, "A request with this Idempotency-Key is still in progress")
+			default:
+				log.Printf("%s %s replaying response for idempotency key", r.Method, r.RequestURI)
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(existing.StatusCode)
+				w.Write(existing.ResponseBody)
+			}
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		ctx := context.WithValue(r.Context(), idempotencyContextKey{}, strconv.Itoa(user.Id)+":"+key)
+		completed := false
+		defer func() {
+			if !completed {
+				h.releaseIdempotencyKey(r, user.Id, key)
+			}
+		}()
+		next(rec, r.WithContext(ctx))
+
+		// Client errors are replayed like successes, but a server error may
+		// be temporary, so the key is released for the retry to run again.
+		if rec.status >= http.StatusInternalServerError {
+			return
+		}
+		completed = true
+		if err := h.Store.Idempotency.Complete(context.WithoutCancel(r.Context()), user.Id, key, rec.status, rec.body.Bytes()); err != nil {
+			log.Printf("Error saving idempotent response for user %d: %v", user.Id, err)
+		}
+	}
+}
+
+// releaseIdempotencyKey gives up a claim without saving a response, after a
+// server error or a panic.
+func (h *Handler) releaseIdempotencyKey(r *http.Request, userID int, key string) {
+	if err := h.Store.Idempotency.Release(context.WithoutCancel(r.Context()), userID, key); err != nil {
+		log.Printf("Error releasing idempotency key for user %d: %v", userID, err)
+	}
+}
+
+// providerIdempotencyKey returns the key to forward to the payment provider
+// for this request, or "" when the client sent none.
+func providerIdempotencyKey(r *http.Request) string {
+	key, _ := r.Context().Value(idempotencyContextKey{}).(string)
+	return key
+}
+
+// requestHash fingerprints a request so a key cannot be reused for a
+// different one.
+func requestHash(r *http.Request, body []byte) string {
+	sum := sha256.New()
+	io.WriteString(sum, r.Method+" "+r.URL.Path+"\n")
+	sum.Write(body)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// responseRecorder passes a response through while keeping a copy.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,142 @@
+package handlers_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
+)
+
+const checkoutPath = "/private/payment/create-checkout-session"
+
+func orderCount(c *testClient) int {
+	c.t.Helper()
+	orders, err := c.srv.store.Orders.List(context.Background(), store.OrderFilter{UserID: c.user.Id})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return len(orders)
+}
+
+func TestIdempotentCheckoutReplaysTheFirstResponse(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	body := fmt.Sprintf(`{"address_id":%d,"items":[{"id":10,"quantity":1}]}`, c.addressID)
+
+	first := c.do("POST", checkoutPath, body, "Idempotency-Key", "k1").wantCode(http.StatusOK)
+	if first.header.Get("Idempotent-Replayed") != "" {
+		t.Error("first response is marked as replayed")
+	}
+	retry := c.do("POST", checkoutPath, body, "Idempotency-Key", "k1").wantCode(http.StatusOK)
+	if retry.header.Get("Idempotent-Replayed") != "true" {
+		t.Error("retry is not marked as replayed")
+	}
+	if string(retry.body) != string(first.body) {
+		t.Errorf("retry = %s, want %s", retry.body, first.body)
+	}
+	if n := orderCount(c); n != 1 {
+		t.Errorf("%d orders after a retry, want 1", n)
+	}
+
+	// Errors are replayed as well; a new key is needed to try again.
+	bad := fmt.Sprintf(`{"address_id":%d,"items":[{"id":99,"quantity":1}]}`, c.addressID)
+	c.do("POST", checkoutPath, bad, "Idempotency-Key", "k2").wantError(http.StatusBadRequest, "Unknown item 99")
+	res := c.do("POST", checkoutPath, bad, "Idempotency-Key", "k2")
+	res.wantError(http.StatusBadRequest, "Unknown item 99")
+	if res.header.Get("Idempotent-Replayed") != "true" {
+		t.Error("replayed error is not marked as replayed")
+	}
+
+	c.do("POST", checkoutPath, body).wantCode(http.StatusOK)
+	c.do("POST", checkoutPath, body).wantCode(http.StatusOK)
+	if n := orderCount(c); n != 3 {
+		t.Errorf("%d orders, want 3: requests without a key always run", n)
+	}
+}
+
+func TestIdempotencyKeyMisuse(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	body := fmt.Sprintf(`{"address_id":%d,"items":[{"id":10,"quantity":1}]}`, c.addressID)
+
+	c.do("POST", checkoutPath, body, "Idempotency-Key", strings.Repeat("k", 201)).
+		wantError(http.StatusBadRequest, "Idempotency-Key is too long")
+
+	c.do("POST", checkoutPath, body, "Idempotency-Key", "k1").wantCode(http.StatusOK)
+	c.do("POST", checkoutPath, fmt.Sprintf(`{"address_id":%d,"items":[{"id":10,"quantity":2}]}`, c.addressID), "Idempotency-Key", "k1").
+		wantError(http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+	// The key is tied to the endpoint as well as the body.
+	c.do("POST", "/private/payment/checkout-cart", body, "Idempotency-Key", "k1").
+		wantError(http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+
+	// Another request still holds the key.
+	sum := sha256.Sum256([]byte("POST " + checkoutPath + "\n" + body))
+	_, claimed, err := srv.store.Idempotency.Begin(context.Background(), store.IdempotencyRecord{
+		UserID: c.user.Id, Key: "k3", RequestHash: hex.EncodeToString(sum[:]),
+	})
+	if err != nil || !claimed {
+		t.Fatalf("Begin = %v, %v", claimed, err)
+	}
+	res := c.do("POST", checkoutPath, body, "Idempotency-Key", "k3")
+	res.wantError(http.StatusConflict, "A request with this Idempotency-Key is still in progress")
+	if res.header.Get("Retry-After") == "" {
+		t.Error("no Retry-After on a key in progress")
+	}
+
+	// The claim lasts until the request holding it finishes, however long
+	// that takes.
+	c.do("POST", checkoutPath, body, "Idempotency-Key", "k3").wantCode(http.StatusConflict)
+	if err := srv.store.Idempotency.Release(context.Background(), c.user.Id, "k3"); err != nil {
+		t.Fatal(err)
+	}
+	c.do("POST", checkoutPath, body, "Idempotency-Key", "k3").wantCode(http.StatusOK)
+}
+
+func TestIdempotentRetryRunsAgainAfterAServerError(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	body := fmt.Sprintf(`{"address_id":%d,"items":[{"id":10,"quantity":1}]}`, c.addressID)
+
+	srv.payments.failCheckout(errors.New("gateway timeout"))
+	c.do("POST", checkoutPath, body, "Idempotency-Key", "k1").
+		wantError(http.StatusInternalServerError, "Failed to create checkout session")
+	srv.payments.failCheckout(nil)
+
+	res := c.do("POST", checkoutPath, body, "Idempotency-Key", "k1").wantCode(http.StatusOK)
+	if res.header.Get("Idempotent-Replayed") != "" {
+		t.Error("the server error was replayed")
+	}
+	var session struct {
+		OrderID int `json:"orderId"`
+	}
+	res.decode(&session)
+	if status := c.order(session.OrderID).Status; status != models.OrderPending {
+		t.Errorf("retried order is %s, want pending", status)
+	}
+	if c.do("POST", checkoutPath, body, "Idempotency-Key", "k1").wantCode(http.StatusOK).header.Get("Idempotent-Replayed") != "true" {
+		t.Error("the successful retry is not replayed")
+	}
+}
+
+func TestIdempotencyKeysAreScopedToTheUser(t *testing.T) {
+	srv := newTestServer(t)
+	asha, ravi := srv.signUp("asha@example.com"), srv.signUp("ravi@example.com")
+	body := `{"address_id":%d,"items":[{"id":10,"quantity":1}]}`
+
+	var first, second struct {
+		OrderID int `json:"orderId"`
+	}
+	asha.do("POST", checkoutPath, fmt.Sprintf(body, asha.addressID), "Idempotency-Key", "k1").wantCode(http.StatusOK).decode(&first)
+	res := ravi.do("POST", checkoutPath, fmt.Sprintf(body, ravi.addressID), "Idempotency-Key", "k1").wantCode(http.StatusOK)
+	res.decode(&second)
+	if res.header.Get("Idempotent-Replayed") != "" || first.OrderID == second.OrderID {
+		t.Errorf("second user got the first user's order %d", first.OrderID)
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+// loginWait returns how long a password login for email from ip must wait
+// because of earlier failures, or 0 when it may go ahead. It is checked
+// before the password so throttled attempts cost no bcrypt work.
+func (h *Handler) loginWait(ctx context.Context, email, ip string) (time.Duration, error) {
+	auth := h.Config.Auth
+	now := time.Now()
+	failures, err := h.Store.LoginAttempts.Failures(ctx, throttleEmail(email), ip, now.Add(-auth.LoginFailureWindow))
+	if err != nil {
+		return 0, err
+	}
+	account := h.failureDelay(failures.Account, auth.LoginBackoffAfter, auth.LoginLockoutAfter)
+	byIP := h.failureDelay(failures.IP, auth.LoginIPBackoffAfter, auth.LoginIPLockoutAfter)
+	return max(failures.AccountLast.Add(account).Sub(now), failures.IPLast.Add(byIP).Sub(now), 0), nil
+}
+
+// throttleEmail is the form of email attempts are counted under, so that
+// changing its case or padding it does not start a fresh count.
+func throttleEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// failureDelay is how long after the last of n failures another attempt is
+// allowed: nothing up to backoffAfter failures, then a doubling delay, then
+// the lockout from lockoutAfter on.
+func (h *Handler) failureDelay(n, backoffAfter, lockoutAfter int) time.Duration {
+	auth := h.Config.Auth
+	switch {
+	case n >= lockoutAfter:
+		return auth.LoginLockoutDuration
+	case n < backoffAfter:
+		return 0
+	}
+	delay := auth.LoginBackoffBase
+	for i := backoffAfter; i < n && delay < auth.LoginBackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, auth.LoginBackoffMax)
+}
+
+// recordLogin adds a login attempt to the audit log. A failure to record is
+// logged rather than failing the login.
+func (h *Handler) recordLogin(ctx context.Context, email, ip string, userID int, result string) {
+	attempt := models.LoginAttempt{Email: throttleEmail(email), IP: ip, UserID: userID, Result: result}
+	if err := h.Store.LoginAttempts.Record(ctx, &attempt); err != nil {
+		log.Printf("Error recording %s login for %q from %s: %v", result, email, ip, err)
+	}
+}
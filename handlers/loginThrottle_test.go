@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+)
+
+func TestFailureDelay(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.LoginBackoffBase = time.Second
+	cfg.Auth.LoginBackoffMax = 10 * time.Second
+	cfg.Auth.LoginLockoutDuration = time.Hour
+	h := &Handler{Config: cfg}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{6, 8 * time.Second},
+		{7, 10 * time.Second},
+		{9, 10 * time.Second},
+		{10, time.Hour},
+		{25, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := h.failureDelay(tt.failures, 3, 10); got != tt.want {
+			t.Errorf("failureDelay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
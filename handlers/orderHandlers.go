@@ -0,0 +1,668 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/middleware"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/payment"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
+)
+
+const (
+	defaultOrderPageSize = 20
+	maxOrderPageSize     = 100
+)
+
+// FetchOrders lists the user's orders, newest first, a page at a time.
+//
+// Query parameters: limit (1-100, default 20); cursor, the next_cursor of the
+// previous page; status, one or more comma-separated statuses; from and to,
+// RFC 3339 timestamps or YYYY-MM-DD dates bounding when the order was placed.
+// A date-only to includes that whole day.
+func (h *Handler) FetchOrders(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	// Retrieve authenticated user from context
+	user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	filter, err := orderFilterFromQuery(r.URL.Query())
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.UserID = user.Id
+	pageSize := filter.Limit
+	// One extra order tells whether there is another page.
+	filter.Limit++
+
+	orders, err := h.Store.Orders.List(r.Context(), filter)
+	if err != nil {
+		log.Printf("Error fetching orders: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to fetch orders")
+		return
+	}
+
+	page := models.OrderPage{Orders: orders}
+	if len(orders) > pageSize {
+		page.Orders = orders[:pageSize]
+		last := page.Orders[pageSize-1]
+		page.NextCursor = encodeOrderCursor(store.OrderCursor{CreatedAt: last.CreatedAt, OrderID: last.OrderID})
+	}
+	WriteSuccessMessage(w, r, page)
+}
+
+// invalidQueryError rejects query parameters. Its message is meant for the
+// client.
+type invalidQueryError string
+
+func (e invalidQueryError) Error() string { return string(e) }
+
+// orderFilterFromQuery reads the FetchOrders query parameters.
+func orderFilterFromQuery(query url.Values) (store.OrderFilter, error) {
+	filter := store.OrderFilter{Limit: defaultOrderPageSize}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxOrderPageSize {
+			return filter, invalidQueryError(fmt.Sprintf("limit must be between 1 and %d", maxOrderPageSize))
+		}
+		filter.Limit = limit
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := decodeOrderCursor(v)
+		if err != nil {
+			return filter, invalidQueryError("Invalid cursor")
+		}
+		filter.After = &cursor
+	}
+
+	for _, v := range query["status"] {
+		for _, status := range strings.Split(v, ",") {
+			status = strings.TrimSpace(status)
+			if !models.IsOrderStatus(status) {
+				return filter, invalidQueryError(fmt.Sprintf("Unknown order status %q", status))
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	var err error
+	if filter.From, err = parseOrderTime(query.Get("from"), false); err != nil {
+		return filter, invalidQueryError("from must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+	}
+	if filter.To, err = parseOrderTime(query.Get("to"), true); err != nil {
+		return filter, invalidQueryError("to must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, invalidQueryError("from must be before to")
+	}
+	return filter, nil
+}
+
+// parseOrderTime parses a from or to bound. A date is midnight UTC, or the
+// following midnight when it ends a range.
+func parseOrderTime(v string, end bool) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// Cursors are opaque to clients: the position of the last order of a page,
+// base64-encoded.
+func encodeOrderCursor(c store.OrderCursor) string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + strconv.Itoa(c.OrderID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeOrderCursor(s string) (store.OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return store.OrderCursor{}, err
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return store.OrderCursor{}, errors.New("malformed cursor")
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return store.OrderCursor{}, err
+	}
+	orderID, err := strconv.Atoi(id)
+	if err != nil {
+		return store.OrderCursor{}, err
+	}
+	return store.OrderCursor{CreatedAt: time.Unix(0, n), OrderID: orderID}, nil
+}
+
+// GetOrder returns one order with its items, refunds and status history.
+// Customers see their own orders; staff see any order.
+func (h *Handler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	orderID, ok := orderIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	order, ok := h.visibleOrderOrWriteError(w, r, user, orderID)
+	if !ok {
+		return
+	}
+
+	history, err := h.Store.Orders.History(r.Context(), orderID)
+	if err != nil {
+		log.Printf("Error fetching history of order %d: %v", orderID, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to fetch order")
+		return
+	}
+	order.History = history
+
+	WriteSuccessMessage(w, r, order)
+}
+
+// TransitionOrder moves an order to a new status. Restaurant owners may act
+// on orders from their restaurants and couriers and admins on any order,
+// within what the order state machine allows their role. Cancellation goes
+// through CancelOrder, which also refunds the payment, so it is refused here,
+// and customers, whose only move is cancelling, cannot use this endpoint.
+func (h *Handler) TransitionOrder(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	orderID, ok := orderIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status string `json:"status"`
+		Note   string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if !models.IsOrderStatus(req.Status) {
+		WriteError(w, r, http.StatusBadRequest, "Unknown order status")
+		return
+	}
+	if req.Status == models.OrderCancelled {
+		WriteError(w, r, http.StatusBadRequest, "Orders are cancelled through the cancel endpoint")
+		return
+	}
+
+	order, ok := h.visibleOrderOrWriteError(w, r, user, orderID)
+	if !ok {
+		return
+	}
+	actor := orderActor(user, order, req.Status)
+	if actor == models.ActorCustomer {
+		WriteError(w, r, http.StatusForbidden, "Only staff can change an order's status")
+		return
+	}
+
+	change := models.OrderStatusChange{
+		OrderID:     orderID,
+		ToStatus:    req.Status,
+		Actor:       actor,
+		ActorUserID: user.Id,
+		Note:        req.Note,
+	}
+	if !h.transitionOrWriteError(w, r, &change) {
+		return
+	}
+	WriteSuccessMessage(w, r, change)
+}
+
+// CancelOrder cancels an order that has not been prepared yet and refunds
+// what was paid. Customers cancel their own orders before the restaurant
+// accepts them and always get everything back; restaurant owners and admins
+// may keep part of the payment by refunding a smaller amount. Orders not paid
+// yet have their checkout closed first.
+func (h *Handler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	orderID, ok := orderIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	// The body is optional.
+	var req struct {
+		Reason string `json:"reason"`
+		// Amount to refund in rupees; 0 refunds the whole payment.
+		Amount float64 `json:"amount"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	order, ok := h.visibleOrderOrWriteError(w, r, user, orderID)
+	if !ok {
+		return
+	}
+	// A cancellation whose refund could not be issued is finished by
+	// cancelling again; the order is then judged as it was when cancelled.
+	cancelled, retry, err := h.unrefundedCancellation(r.Context(), order)
+	if err != nil {
+		log.Printf("Error fetching history of order %d: %v", orderID, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to fetch order")
+		return
+	}
+	if retry {
+		order.Status = cancelled.FromStatus
+		if req.Reason == "" {
+			req.Reason = cancelled.Note
+		}
+	}
+	actor := orderActor(user, order, models.OrderCancelled)
+	if req.Amount < 0 || toPaise(req.Amount) > toPaise(order.TotalAmount-order.RefundedAmount()) {
+		WriteError(w, r, http.StatusBadRequest, "Invalid refund amount")
+		return
+	}
+	if req.Amount > 0 && actor == models.ActorCustomer {
+		WriteError(w, r, http.StatusForbidden, "Cancelled orders are refunded in full")
+		return
+	}
+
+	var change models.OrderStatusChange
+	if retry {
+		if !models.CanTransitionOrder(cancelled.FromStatus, models.OrderCancelled, actor) {
+			WriteError(w, r, http.StatusConflict, "Order cannot move from "+models.OrderCancelled+" to "+models.OrderCancelled)
+			return
+		}
+		change = cancelled
+	} else {
+		if !h.closeCheckoutOrWriteError(w, r, order) {
+			return
+		}
+		change = models.OrderStatusChange{
+			OrderID:     orderID,
+			ToStatus:    models.OrderCancelled,
+			Actor:       actor,
+			ActorUserID: user.Id,
+			Note:        req.Reason,
+		}
+		if !h.transitionOrWriteError(w, r, &change) {
+			return
+		}
+	}
+
+	response := struct {
+		OrderID int            `json:"order_id"`
+		Status  string         `json:"status"`
+		Refund  *models.Refund `json:"refund,omitempty"`
+	}{OrderID: orderID, Status: change.ToStatus}
+
+	if !isOrderPaid(change.FromStatus) || order.PaymentID == "" {
+		WriteSuccessMessage(w, r, response)
+		return
+	}
+
+	providerReason := ""
+	if actor == models.ActorCustomer {
+		providerReason = payment.ReasonRequestedByCustomer
+	}
+	refund := models.Refund{
+		OrderID:     orderID,
+		Amount:      req.Amount,
+		Reason:      req.Reason,
+		ActorUserID: user.Id,
+	}
+	// An order is cancelled once, so the cancellation refund can be keyed
+	// on the order alone; a retry after a failed refund reuses the key so
+	// the payment cannot be refunded twice.
+	order.Status = change.ToStatus
+	status, ok := h.refundOrWriteError(w, r, order, &refund, providerReason, fmt.Sprintf("cancel:%d", orderID))
+	if !ok {
+		return
+	}
+	response.Status, response.Refund = status, &refund
+	WriteSuccessMessage(w, r, response)
+}
+
+// RefundOrder returns part or all of an order's payment without changing its
+// fulfilment, for example when a delivered order was missing an item. The
+// route lets only restaurant owners and admins through; owners may refund
+// orders from their own restaurants.
+func (h *Handler) RefundOrder(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	orderID, ok := orderIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+		// Amount to refund in rupees; 0 refunds whatever is left.
+		Amount float64 `json:"amount"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	order, ok := h.visibleOrderOrWriteError(w, r, user, orderID)
+	if !ok {
+		return
+	}
+	if !models.IsOrderRefundable(order.Status) || order.PaymentID == "" {
+		WriteError(w, r, http.StatusConflict, "Order "+order.Status+" has no payment to refund")
+		return
+	}
+	if req.Amount < 0 || toPaise(req.Amount) > toPaise(order.TotalAmount-order.RefundedAmount()) {
+		WriteError(w, r, http.StatusBadRequest, "Invalid refund amount")
+		return
+	}
+
+	refund := models.Refund{
+		OrderID:     orderID,
+		Amount:      req.Amount,
+		Reason:      req.Reason,
+		ActorUserID: user.Id,
+	}
+	status, ok := h.refundOrWriteError(w, r, order, &refund, "", providerIdempotencyKey(r))
+	if !ok {
+		return
+	}
+
+	response := struct {
+		OrderID int           `json:"order_id"`
+		Status  string        `json:"status"`
+		Refund  models.Refund `json:"refund"`
+	}{OrderID: orderID, Status: status, Refund: refund}
+	WriteSuccessMessage(w, r, response)
+}
+
+// orderActor decides who user acts as when moving order to status. Staff act
+// through their role; staff who order food for themselves are the customer
+// of that order.
+func orderActor(user models.User, order models.Order, status string) models.OrderActor {
+	actor := models.ActorForRole(user.Role)
+	if order.UserID == user.Id && !models.CanTransitionOrder(order.Status, status, actor) {
+		actor = models.ActorCustomer
+	}
+	return actor
+}
+
+// canViewOrder reports whether user may see order: customers see their own
+// orders, restaurant owners also orders from restaurants they own, and
+// couriers and admins every order.
+func (h *Handler) canViewOrder(ctx context.Context, user models.User, order models.Order) (bool, error) {
+	if order.UserID == user.Id {
+		return true, nil
+	}
+	switch models.ActorForRole(user.Role) {
+	case models.ActorCourier, models.ActorAdmin:
+		return true, nil
+	case models.ActorRestaurant:
+		// Checkout records the restaurant the order was priced for; what
+		// the menu says now does not matter.
+		if order.RestaurantID == 0 {
+			return false, nil
+		}
+		return h.Store.Restaurants.OwnsAny(ctx, user.Id, []int{order.RestaurantID})
+	default:
+		return false, nil
+	}
+}
+
+// visibleOrderOrWriteError fetches an order user may see, answering the
+// request itself otherwise. Orders the user may not see are reported as
+// missing.
+func (h *Handler) visibleOrderOrWriteError(w http.ResponseWriter, r *http.Request, user models.User, orderID int) (models.Order, bool) {
+	order, ok := h.orderOrWriteError(w, r, orderID)
+	if !ok {
+		return models.Order{}, false
+	}
+	visible, err := h.canViewOrder(r.Context(), user, order)
+	if err != nil {
+		log.Printf("Error checking access of user %d to order %d: %v", user.Id, orderID, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to fetch order")
+		return models.Order{}, false
+	}
+	if !visible {
+		WriteError(w, r, http.StatusNotFound, "Order not found")
+		return models.Order{}, false
+	}
+	return order, true
+}
+
+// transitionOrWriteError applies change, answering the request itself when
+// that fails.
+func (h *Handler) transitionOrWriteError(w http.ResponseWriter, r *http.Request, change *models.OrderStatusChange) bool {
+	err := h.Store.Orders.Transition(r.Context(), change)
+	var illegal *store.TransitionError
+	switch {
+	case errors.As(err, &illegal):
+		WriteError(w, r, http.StatusConflict, "Order cannot move from "+illegal.From+" to "+illegal.To)
+		return false
+	case errors.Is(err, store.ErrNotFound):
+		WriteError(w, r, http.StatusNotFound, "Order not found")
+		return false
+	case err != nil:
+		log.Printf("Error moving order %d to %s: %v", change.OrderID, change.ToStatus, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to update order")
+		return false
+	}
+
+	log.Printf("Order %d moved from %s to %s by %s %d", change.OrderID, change.FromStatus, change.ToStatus, change.Actor, change.ActorUserID)
+	return true
+}
+
+// refundOrWriteError refunds refund.Amount of order's payment, or the rest of
+// it when the amount is 0, and records the refund. order.Status must be
+// current. It returns the order's resulting status, answering the request
+// itself when that fails.
+func (h *Handler) refundOrWriteError(w http.ResponseWriter, r *http.Request, order models.Order, refund *models.Refund, providerReason, idempotencyKey string) (string, bool) {
+	issued, err := h.Payments.Refund(r.Context(), payment.RefundRequest{
+		PaymentID:      order.PaymentID,
+		Amount:         toPaise(refund.Amount),
+		Reason:         providerReason,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		log.Printf("Error refunding order %d: %v", order.OrderID, err)
+		WriteError(w, r, http.StatusBadGateway, "Refund could not be issued")
+		return "", false
+	}
+
+	refund.ProviderRefundID = issued.ID
+	refund.Amount = float64(issued.Amount) / 100
+	refund.Status = issued.Status
+	// The money has moved, so the record must be written even if the
+	// client has gone away.
+	change, err := h.Store.Orders.AddRefund(context.WithoutCancel(r.Context()), refund)
+	if err != nil {
+		log.Printf("Error recording refund %s of order %d: %v", issued.ID, order.OrderID, err)
+		WriteError(w, r, http.StatusInternalServerError, "Refund was issued but could not be recorded")
+		return "", false
+	}
+
+	log.Printf("Refunded %.2f of order %d (%s)", refund.Amount, order.OrderID, issued.ID)
+	if change == nil {
+		return order.Status, true
+	}
+	return change.ToStatus, true
+}
+
+// isOrderPaid reports whether an order cancelled from status had been paid
+// for, and so has something to refund.
+func isOrderPaid(status string) bool {
+	return status == models.OrderCompleted || status == models.OrderAccepted
+}
+
+// unrefundedCancellation returns the status change that cancelled order when
+// the order had been paid for but nothing was refunded, which happens when the
+// refund failed after the cancellation was saved.
+func (h *Handler) unrefundedCancellation(ctx context.Context, order models.Order) (models.OrderStatusChange, bool, error) {
+	if order.Status != models.OrderCancelled || order.PaymentID == "" || len(order.Refunds) > 0 {
+		return models.OrderStatusChange{}, false, nil
+	}
+	history, err := h.Store.Orders.History(ctx, order.OrderID)
+	if err != nil {
+		return models.OrderStatusChange{}, false, err
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if change := history[i]; change.ToStatus == models.OrderCancelled {
+			return change, isOrderPaid(change.FromStatus), nil
+		}
+	}
+	return models.OrderStatusChange{}, false, nil
+}
+
+// closeCheckoutOrWriteError expires the checkout session of an order that has
+// not been paid yet, so the customer cannot pay for it once it is cancelled.
+// It answers the request itself when the session could not be closed or was
+// paid in the meantime.
+func (h *Handler) closeCheckoutOrWriteError(w http.ResponseWriter, r *http.Request, order models.Order) bool {
+	if order.SessionID == "" || (order.Status != models.OrderPending && order.Status != models.OrderFailed) {
+		return true
+	}
+	s, err := h.Payments.ExpireSession(r.Context(), order.SessionID)
+	switch {
+	case errors.Is(err, payment.ErrNotFound):
+		return true
+	case err != nil:
+		log.Printf("Error expiring checkout session %s of order %d: %v", order.SessionID, order.OrderID, err)
+		WriteError(w, r, http.StatusBadGateway, "Checkout could not be closed")
+		return false
+	case s.Status == payment.SessionComplete:
+		// The payment webhook will mark the order paid; it can then be
+		// cancelled with a refund.
+		WriteError(w, r, http.StatusConflict, "Order has just been paid, please try again")
+		return false
+	}
+	return true
+}
+
+// orderIDFromPath reads the {id} route variable, answering the request itself
+// when it is not a valid order ID.
+func orderIDFromPath(w http.ResponseWriter, r *http.Request) (int, bool) {
+	orderID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || orderID <= 0 {
+		WriteError(w, r, http.StatusBadRequest, "Invalid order ID")
+		return 0, false
+	}
+	return orderID, true
+}
+
+// orderOrWriteError loads an order, answering the request itself when that
+// fails.
+func (h *Handler) orderOrWriteError(w http.ResponseWriter, r *http.Request, orderID int) (models.Order, bool) {
+	order, err := h.Store.Orders.Get(r.Context(), orderID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, "Order not found")
+		return models.Order{}, false
+	}
+	if err != nil {
+		log.Printf("Error fetching order %d: %v", orderID, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to fetch order")
+		return models.Order{}, false
+	}
+	return order, true
+}
@@ -0,0 +1,229 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+func TestTransitionOrderFollowsTheStateMachine(t *testing.T) {
+	srv := newTestServer(t)
+	customer := srv.signUp("asha@example.com")
+	restaurant := srv.signUpAs("kitchen@example.com", models.RoleRestaurantOwner, 1)
+	courier := srv.signUpAs("courier@example.com", models.RoleCourier)
+
+	orderID := customer.checkout(10, 1)
+	path := fmt.Sprintf("/private/orders/%d/status", orderID)
+
+	restaurant.do("POST", path, `{"status":"accepted"}`).
+		wantError(http.StatusConflict, "Order cannot move from pending to accepted")
+	srv.markPaid(orderID)
+
+	customer.do("POST", path, `{"status":"accepted"}`).
+		wantError(http.StatusForbidden, "Only staff can change an order's status")
+	restaurant.do("POST", path, `{"status":"shipped"}`).wantError(http.StatusBadRequest, "Unknown order status")
+	restaurant.do("POST", path, `{"status":"cancelled"}`).
+		wantError(http.StatusBadRequest, "Orders are cancelled through the cancel endpoint")
+	courier.do("POST", path, `{"status":"accepted"}`).
+		wantError(http.StatusConflict, "Order cannot move from completed to accepted")
+
+	want := []struct {
+		client   *testClient
+		status   string
+		from     string
+		actor    models.OrderActor
+		wantCode int
+	}{
+		{restaurant, models.OrderAccepted, models.OrderCompleted, models.ActorRestaurant, http.StatusOK},
+		{restaurant, models.OrderPreparing, models.OrderAccepted, models.ActorRestaurant, http.StatusOK},
+		{restaurant, models.OrderOutForDelivery, "", "", http.StatusConflict},
+		{courier, models.OrderOutForDelivery, models.OrderPreparing, models.ActorCourier, http.StatusOK},
+		{courier, models.OrderDelivered, models.OrderOutForDelivery, models.ActorCourier, http.StatusOK},
+	}
+	for _, w := range want {
+		res := w.client.do("POST", path, fmt.Sprintf(`{"status":%q,"note":"by %s"}`, w.status, w.client.user.Email)).wantCode(w.wantCode)
+		if w.wantCode != http.StatusOK {
+			continue
+		}
+		var change models.OrderStatusChange
+		res.decode(&change)
+		if change.FromStatus != w.from || change.ToStatus != w.status || change.Actor != w.actor ||
+			change.ActorUserID != w.client.user.Id || change.Note != "by "+w.client.user.Email {
+			t.Errorf("change = %+v, want %s -> %s by %s %d", change, w.from, w.status, w.actor, w.client.user.Id)
+		}
+	}
+
+	order, err := srv.store.Orders.Get(context.Background(), orderID)
+	if err != nil || order.Status != models.OrderDelivered {
+		t.Errorf("order = %s, %v; want delivered", order.Status, err)
+	}
+}
+
+func TestTransitionOrderHidesOtherCustomersOrders(t *testing.T) {
+	srv := newTestServer(t)
+	asha := srv.signUp("asha@example.com")
+	other := srv.signUpAs("owner@example.com", models.RoleRestaurantOwner, 2)
+	orderID := asha.checkout(10, 1)
+	srv.markPaid(orderID)
+
+	other.do("POST", fmt.Sprintf("/private/orders/%d/status", orderID), `{"status":"accepted"}`).
+		wantError(http.StatusNotFound, "Order not found")
+	other.do("POST", "/private/orders/999/status", `{"status":"accepted"}`).wantError(http.StatusNotFound, "Order not found")
+	other.do("POST", "/private/orders/abc/status", `{"status":"accepted"}`).wantError(http.StatusBadRequest, "Invalid order ID")
+}
+
+func TestRestaurantOwnersManageTheOrdersRestaurantsOrders(t *testing.T) {
+	srv := newTestServer(t)
+	asha := srv.signUp("asha@example.com")
+	kitchen := srv.signUpAs("kitchen@example.com", models.RoleRestaurantOwner, 1)
+	pizza := srv.signUpAs("pizza@example.com", models.RoleRestaurantOwner, 2)
+	accepted, refunded := asha.checkout(10, 1), asha.checkout(10, 1)
+	srv.markPaid(accepted)
+	srv.markPaid(refunded)
+	if got := srv.order(accepted).RestaurantID; got != 1 {
+		t.Fatalf("order restaurant = %d, want 1", got)
+	}
+
+	// Moving the dish to another restaurant's menu does not hand its orders
+	// over with it.
+	srv.mem.AddFoodItem(models.FoodItems{Id: 10, Name: "Dosa", Price: 100, CloudImageID: "img2", Category: "Mains"})
+	statusPath := fmt.Sprintf("/private/orders/%d/status", accepted)
+	refundPath := fmt.Sprintf("/private/orders/%d/refund", refunded)
+	pizza.do("POST", statusPath, `{"status":"accepted"}`).wantError(http.StatusNotFound, "Order not found")
+	pizza.do("POST", refundPath, `{"amount":10}`).wantError(http.StatusNotFound, "Order not found")
+
+	kitchen.do("POST", statusPath, `{"status":"accepted"}`).wantCode(http.StatusOK)
+	kitchen.do("POST", refundPath, `{"amount":10}`).wantCode(http.StatusOK)
+	if order := srv.order(refunded); order.RefundedAmount() != 10 {
+		t.Errorf("order refunds = %+v, want 10 refunded by the kitchen", order.Refunds)
+	}
+}
+
+func TestStaffActAsCustomersOfTheirOwnOrders(t *testing.T) {
+	srv := newTestServer(t)
+	restaurant := srv.signUpAs("kitchen@example.com", models.RoleRestaurantOwner, 1)
+	orderID := restaurant.checkout(20, 1)
+	srv.markPaid(orderID)
+
+	// A move their role cannot make is theirs to make as the customer, and
+	// customers only cancel, through the cancel endpoint.
+	restaurant.do("POST", fmt.Sprintf("/private/orders/%d/status", orderID), `{"status":"delivered"}`).
+		wantError(http.StatusForbidden, "Only staff can change an order's status")
+	restaurant.do("POST", fmt.Sprintf("/private/orders/%d/cancel", orderID), "").wantCode(http.StatusOK)
+
+	var order models.Order
+	restaurant.do("GET", fmt.Sprintf("/private/orders/%d", orderID), "").wantCode(http.StatusOK).decode(&order)
+	if order.Status != models.OrderRefunded {
+		t.Errorf("status = %s, want refunded", order.Status)
+	}
+}
+
+// fetchOrders fetches a page of the client's order history.
+func (c *testClient) fetchOrders(query string) models.OrderPage {
+	c.t.Helper()
+	var page models.OrderPage
+	c.do("GET", "/private/orders?"+query, "").wantCode(http.StatusOK).decode(&page)
+	return page
+}
+
+func TestFetchOrdersPagesNewestFirst(t *testing.T) {
+	srv := newTestServer(t)
+	asha, ravi := srv.signUp("asha@example.com"), srv.signUp("ravi@example.com")
+	var want []int
+	for i := 0; i < 5; i++ {
+		want = append([]int{asha.checkout(10, 1)}, want...)
+	}
+	ravi.checkout(20, 1)
+
+	var got []int
+	page := asha.fetchOrders("limit=2")
+	for pages := 1; ; pages++ {
+		for _, order := range page.Orders {
+			got = append(got, order.OrderID)
+		}
+		if page.NextCursor == "" {
+			if pages != 3 {
+				t.Errorf("got %d pages, want 3", pages)
+			}
+			break
+		}
+		page = asha.fetchOrders("limit=2&cursor=" + page.NextCursor)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("orders = %v, want %v", got, want)
+	}
+
+	// A page that ends exactly at the last order has no next page.
+	if page := asha.fetchOrders("limit=5"); len(page.Orders) != 5 || page.NextCursor != "" {
+		t.Errorf("limit=5: %d orders, cursor %q; want 5 and none", len(page.Orders), page.NextCursor)
+	}
+	if page := ravi.fetchOrders(""); len(page.Orders) != 1 {
+		t.Errorf("ravi sees %d orders, want 1", len(page.Orders))
+	}
+	if page := srv.signUp("new@example.com").fetchOrders(""); page.Orders == nil || len(page.Orders) != 0 {
+		t.Errorf("new user: %+v, want an empty list", page)
+	}
+}
+
+func TestFetchOrdersFilters(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	before := time.Now().UTC().Add(-time.Second).Format(time.RFC3339)
+	pending := c.checkout(10, 1)
+	paid := c.checkout(20, 1)
+	srv.markPaid(paid)
+	cancelled := c.checkout(11, 1)
+	srv.fake.Expire(srv.order(cancelled).SessionID)
+	c.do("POST", fmt.Sprintf("/private/orders/%d/cancel", cancelled), "").wantCode(http.StatusOK)
+	today := time.Now().UTC().Format(time.DateOnly)
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"status=completed", []int{paid}},
+		{"status=pending,cancelled", []int{cancelled, pending}},
+		{"status=pending&status=completed&limit=1", []int{paid}},
+		{"from=" + before, []int{cancelled, paid, pending}},
+		{"to=" + before, nil},
+		{"from=" + today + "&to=" + today + "&status=pending", []int{pending}},
+	}
+	for _, tt := range tests {
+		var got []int
+		for _, order := range c.fetchOrders(tt.query).Orders {
+			got = append(got, order.OrderID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	c.do("GET", "/private/orders?cursor=bm9wZQ", "").wantError(http.StatusBadRequest, "Invalid cursor")
+	c.do("GET", "/private/orders?status=shipped", "").wantCode(http.StatusBadRequest)
+	c.do("GET", "/private/orders?limit=500", "").wantError(http.StatusBadRequest, "limit must be between 1 and 100")
+}
+
+func TestGetOrder(t *testing.T) {
+	srv := newTestServer(t)
+	asha, ravi := srv.signUp("asha@example.com"), srv.signUp("ravi@example.com")
+	courier := srv.signUpAs("courier@example.com", models.RoleCourier)
+	orderID := asha.checkout(10, 2)
+	srv.markPaid(orderID)
+
+	var order models.Order
+	asha.do("GET", fmt.Sprintf("/private/orders/%d", orderID), "").wantCode(http.StatusOK).decode(&order)
+	if order.OrderID != orderID || len(order.Items) != 1 || order.Items[0].Quantity != 2 || order.TotalAmount != 200 {
+		t.Errorf("order = %+v", order)
+	}
+	if len(order.History) != 2 || order.History[0].ToStatus != models.OrderPending || order.History[1].ToStatus != models.OrderCompleted {
+		t.Errorf("history = %+v, want pending then completed", order.History)
+	}
+
+	courier.do("GET", fmt.Sprintf("/private/orders/%d", orderID), "").wantCode(http.StatusOK)
+	ravi.do("GET", fmt.Sprintf("/private/orders/%d", orderID), "").wantError(http.StatusNotFound, "Order not found")
+	ravi.do("GET", "/private/orders/999", "").wantError(http.StatusNotFound, "Order not found")
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
+)
+
+func TestOrderCursorRoundTrip(t *testing.T) {
+	want := store.OrderCursor{CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC), OrderID: 42}
+	got, err := decodeOrderCursor(encodeOrderCursor(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || got.OrderID != want.OrderID {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeOrderCursorRejectsTampering(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+	for _, cursor := range []string{
+		"not base64!",
+		encode("1714566600000000000"),
+		encode("yesterday:42"),
+		encode("1714566600000000000:x"),
+		encode(":"),
+		encode("1714566600000000000:42") + "=",
+	} {
+		if _, err := decodeOrderCursor(cursor); err == nil {
+			t.Errorf("decodeOrderCursor(%q) succeeded", cursor)
+		}
+	}
+}
+
+func TestOrderFilterFromQuery(t *testing.T) {
+	may1 := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	cursor := store.OrderCursor{CreatedAt: may1, OrderID: 7}
+
+	tests := []struct {
+		query   string
+		want    store.OrderFilter
+		wantErr string
+	}{
+		{"", store.OrderFilter{Limit: 20}, ""},
+		{"limit=1", store.OrderFilter{Limit: 1}, ""},
+		{"limit=100", store.OrderFilter{Limit: 100}, ""},
+		{"limit=0", store.OrderFilter{}, "limit must be between 1 and 100"},
+		{"limit=101", store.OrderFilter{}, "limit must be between 1 and 100"},
+		{"limit=ten", store.OrderFilter{}, "limit must be between 1 and 100"},
+		{"cursor=" + encodeOrderCursor(cursor), store.OrderFilter{Limit: 20, After: &cursor}, ""},
+		{"cursor=abc", store.OrderFilter{}, "Invalid cursor"},
+		{
+			"status=accepted,+preparing&status=delivered",
+			store.OrderFilter{Limit: 20, Statuses: []string{"accepted", "preparing", "delivered"}},
+			"",
+		},
+		{"status=shipped", store.OrderFilter{}, `Unknown order status "shipped"`},
+		{
+			// A date-only to includes the whole day.
+			"from=2024-05-01&to=2024-05-01",
+			store.OrderFilter{Limit: 20, From: may1, To: may1.AddDate(0, 0, 1)},
+			"",
+		},
+		{
+			"from=2024-05-01T10:00:00Z&to=2024-05-01T11:00:00Z&status=completed&limit=5",
+			store.OrderFilter{
+				Limit:    5,
+				Statuses: []string{"completed"},
+				From:     may1.Add(10 * time.Hour),
+				To:       may1.Add(11 * time.Hour),
+			},
+			"",
+		},
+		{"from=May+1", store.OrderFilter{}, "from must be an RFC 3339 timestamp or a YYYY-MM-DD date"},
+		{"to=2024-13-01", store.OrderFilter{}, "to must be an RFC 3339 timestamp or a YYYY-MM-DD date"},
+		{"from=2024-05-02&to=2024-05-01", store.OrderFilter{}, "from must be before to"},
+		{"from=2024-05-01T10:00:00Z&to=2024-05-01T10:00:00Z", store.OrderFilter{}, "from must be before to"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := orderFilterFromQuery(query)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.After != nil && tt.want.After != nil && got.After.CreatedAt.Equal(tt.want.After.CreatedAt) {
+				got.After.CreatedAt = tt.want.After.CreatedAt
+			}
+			if !got.From.Equal(tt.want.From) || !got.To.Equal(tt.want.To) {
+				t.Errorf("range = %v to %v, want %v to %v", got.From, got.To, tt.want.From, tt.want.To)
+			}
+			got.From, got.To = tt.want.From, tt.want.To
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/middleware"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
+)
+
+const loginOTPRequestedMessage = "If the phone is registered, a code has been sent"
+
+// hashCode keys the hash with the session key, so the million possible codes
+// cannot be tried against a leaked phone_otps table.
+func (h *Handler) hashCode(userID int, purpose, code string) string {
+	mac := hmac.New(sha256.New, []byte(h.Config.Session.Key))
+	fmt.Fprintf(mac, "%d:%s:%s", userID, purpose, code)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// otpWait returns how long the user must wait before being sent another
+// code for purpose.
+func (h *Handler) otpWait(ctx context.Context, userID int, purpose string) (time.Duration, error) {
+	last, err := h.Store.PhoneOTPs.LastSentAt(ctx, userID, purpose)
+	if errors.Is(err, store.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return time.Until(last.Add(h.Config.Auth.OTPResendInterval)), nil
+}
+
+// sendOTP texts user a new code for purpose to their current phone.
+func (h *Handler) sendOTP(ctx context.Context, user models.User, purpose string) error {
+	text, err := h.newOTP(ctx, user, purpose)
+	if err != nil {
+		return err
+	}
+	return h.SMS.Send(ctx, user.Phone, text)
+}
+
+// newOTP saves a new code for purpose and returns the text that carries it.
+func (h *Handler) newOTP(ctx context.Context, user models.User, purpose string) (string, error) {
+	code, err := newCode()
+	if err != nil {
+		return "", err
+	}
+	ttl := h.Config.Auth.OTPTTL
+	otp := models.PhoneOTP{
+		UserID:    user.Id,
+		Phone:     user.Phone,
+		Purpose:   purpose,
+		CodeHash:  h.hashCode(user.Id, purpose, code),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := h.Store.PhoneOTPs.Create(ctx, &otp); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s is your FoodHaven code. It expires in %d minutes. Do not share it with anyone.",
+		code, int(math.Ceil(ttl.Minutes()))), nil
+}
+
+// checkOTPOrWriteError checks code against the user's current code for
+// purpose, answering the request itself when it is not accepted.
+func (h *Handler) checkOTPOrWriteError(w http.ResponseWriter, r *http.Request, userID int, purpose, code string) bool {
+	err := h.Store.PhoneOTPs.Check(r.Context(), userID, purpose, h.hashCode(userID, purpose, code), h.Config.Auth.OTPMaxAttempts)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, store.ErrNotFound), errors.Is(err, store.ErrCodeMismatch):
+		WriteError(w, r, http.StatusUnauthorized, "Invalid or expired code")
+	case errors.Is(err, store.ErrTooManyAttempts):
+		WriteError(w, r, http.StatusTooManyRequests, "Too many attempts; request a new code")
+	default:
+		log.Printf("Error checking code for user %d: %v", userID, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to check code")
+	}
+	return false
+}
+
+// RequestLoginOTP texts a login code to a registered phone. Like
+// RequestPasswordReset it answers the same way whether or not the phone is
+// registered, and requests inside Auth.OTPResendInterval are dropped
+// silently for the same reason.
+func (h *Handler) RequestLoginOTP(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	var req struct {
+		Phone string `json:"phone"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.Phone == "" {
+		WriteError(w, r, http.StatusBadRequest, "Phone is required")
+		return
+	}
+
+	user, err := h.Store.Users.GetByPhone(r.Context(), req.Phone)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			log.Printf("Error looking up user for login code: %v", err)
+		}
+		WriteSuccessMessage(w, r, loginOTPRequestedMessage)
+		return
+	}
+
+	wait, err := h.otpWait(r.Context(), user.Id, models.OTPLogin)
+	switch {
+	case err != nil:
+		log.Printf("Error fetching last login code for user %d: %v", user.Id, err)
+	case wait > 0:
+		log.Printf("Login code for user %d not sent; requested again too soon", user.Id)
+	default:
+		text, err := h.newOTP(r.Context(), user, models.OTPLogin)
+		if err != nil {
+			log.Printf("Error creating login code for user %d: %v", user.Id, err)
+			break
+		}
+		// Sent after answering, so that registered phones are not answered
+		// more slowly than unknown ones.
+		h.inBackground(r, fmt.Sprintf("sending login code to user %d", user.Id), func(ctx context.Context) error {
+			return h.SMS.Send(ctx, user.Phone, text)
+		})
+	}
+	WriteSuccessMessage(w, r, loginOTPRequestedMessage)
+}
+
+// LogInWithOTP logs in with a phone number and the code texted to it. The
+// code also proves the user owns the phone, so it is marked verified.
+func (h *Handler) LogInWithOTP(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	var req struct {
+		Phone string `json:"phone"`
+		Code  string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.Phone == "" || req.Code == "" {
+		WriteError(w, r, http.StatusBadRequest, "Phone and code are required")
+		return
+	}
+
+	user, err := h.Store.Users.GetByPhone(r.Context(), req.Phone)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusUnauthorized, "Invalid or expired code")
+		return
+	}
+	if err != nil {
+		log.Printf("Error looking up user for login code: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to log in")
+		return
+	}
+	if !h.checkOTPOrWriteError(w, r, user.Id, models.OTPLogin, req.Code) {
+		return
+	}
+	user.PhoneVerified = true
+
+	h.logIn(w, r, user)
+}
+
+// RequestPhoneVerification texts the signed in user a code that confirms
+// they own their phone, at most once every Auth.OTPResendInterval.
+func (h *Handler) RequestPhoneVerification(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+	if user.PhoneVerified {
+		WriteError(w, r, http.StatusConflict, "Phone is already verified")
+		return
+	}
+
+	wait, err := h.otpWait(r.Context(), user.Id, models.OTPVerify)
+	if err != nil {
+		log.Printf("Error fetching last verification code for user %d: %v", user.Id, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to send verification code")
+		return
+	}
+	if wait > 0 {
+		setRetryAfter(w, wait)
+		WriteError(w, r, http.StatusTooManyRequests, "Please wait before requesting another code")
+		return
+	}
+
+	if err := h.sendOTP(r.Context(), user, models.OTPVerify); err != nil {
+		log.Printf("Error sending verification code to user %d: %v", user.Id, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to send verification code")
+		return
+	}
+	WriteSuccessMessage(w, r, "Verification code sent")
+}
+
+// VerifyPhone marks the signed in user's phone verified using the code from
+// RequestPhoneVerification.
+func (h *Handler) VerifyPhone(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.Code == "" {
+		WriteError(w, r, http.StatusBadRequest, "Code is required")
+		return
+	}
+
+	if !h.checkOTPOrWriteError(w, r, user.Id, models.OTPVerify, req.Code) {
+		return
+	}
+	WriteSuccessMessage(w, r, "Phone verified")
+}
@@ -0,0 +1,142 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+func phoneBody(phone string) string {
+	return fmt.Sprintf(`{"phone":%q}`, phone)
+}
+
+func otpLoginBody(phone, code string) string {
+	return fmt.Sprintf(`{"phone":%q,"code":%q}`, phone, code)
+}
+
+// phoneVerified reports what getuser says about the client's phone.
+func (c *testClient) phoneVerified() bool {
+	c.t.Helper()
+	var user models.User
+	c.do("GET", "/private/user/getuser", "").wantCode(http.StatusOK).decode(&user)
+	return user.PhoneVerified
+}
+
+func TestLogInWithOTP(t *testing.T) {
+	srv := newTestServer(t)
+	phone := srv.signUp("asha@example.com").user.Phone
+
+	var registered, unknown string
+	srv.client().do("POST", "/public/user/requestloginotp", phoneBody(phone)).wantCode(http.StatusOK).decode(&registered)
+	srv.client().do("POST", "/public/user/requestloginotp", phoneBody("9000000000")).wantCode(http.StatusOK).decode(&unknown)
+	if registered != unknown {
+		t.Errorf("answers differ: %q and %q", registered, unknown)
+	}
+	srv.h.Wait()
+	if texts := srv.texts.sent("9000000000"); len(texts) != 0 {
+		t.Errorf("sent %d texts to an unknown phone", len(texts))
+	}
+
+	c := srv.client()
+	c.do("POST", "/public/user/loginotp", otpLoginBody("9000000000", "123456")).
+		wantError(http.StatusUnauthorized, "Invalid or expired code")
+	code := srv.texts.lastCode(t, phone)
+	c.do("POST", "/public/user/loginotp", otpLoginBody(phone, code)).wantCode(http.StatusOK)
+	if !c.phoneVerified() {
+		t.Error("logging in with a code did not verify the phone")
+	}
+	// Codes are single use.
+	srv.client().do("POST", "/public/user/loginotp", otpLoginBody(phone, code)).
+		wantError(http.StatusUnauthorized, "Invalid or expired code")
+	srv.client().do("POST", "/public/user/loginotp", otpLoginBody(phone, "")).
+		wantError(http.StatusBadRequest, "Phone and code are required")
+}
+
+func TestRequestLoginOTPDoesNotWaitForTheText(t *testing.T) {
+	srv := newTestServer(t)
+	phone := srv.signUp("asha@example.com").user.Phone
+	srv.texts.hold = make(chan struct{})
+
+	// The answer does not wait for the SMS gateway, which would show how
+	// registered phones differ from unknown ones.
+	srv.client().do("POST", "/public/user/requestloginotp", phoneBody(phone)).wantCode(http.StatusOK)
+	if texts := srv.texts.sent(phone); len(texts) != 0 {
+		t.Fatalf("text sent before answering: %q", texts)
+	}
+	close(srv.texts.hold)
+	srv.h.Wait()
+	srv.client().do("POST", "/public/user/loginotp", otpLoginBody(phone, srv.texts.lastCode(t, phone))).wantCode(http.StatusOK)
+}
+
+func TestOTPAttemptLimit(t *testing.T) {
+	srv := newTestServer(t, func(cfg *config.Config) { cfg.Auth.OTPMaxAttempts = 2 })
+	phone := srv.signUp("asha@example.com").user.Phone
+	srv.client().do("POST", "/public/user/requestloginotp", phoneBody(phone)).wantCode(http.StatusOK)
+	srv.h.Wait()
+	code := srv.texts.lastCode(t, phone)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	for i := 0; i < 2; i++ {
+		srv.client().do("POST", "/public/user/loginotp", otpLoginBody(phone, wrong)).
+			wantError(http.StatusUnauthorized, "Invalid or expired code")
+	}
+	// Once the attempts are used up, not even the right code works.
+	srv.client().do("POST", "/public/user/loginotp", otpLoginBody(phone, code)).
+		wantError(http.StatusTooManyRequests, "Too many attempts; request a new code")
+}
+
+func TestOTPResendInterval(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	phone := c.user.Phone
+
+	c.do("POST", "/private/user/requestphoneverification", "").wantCode(http.StatusOK)
+	res := c.do("POST", "/private/user/requestphoneverification", "")
+	res.wantError(http.StatusTooManyRequests, "Please wait before requesting another code")
+	if res.header.Get("Retry-After") == "" {
+		t.Error("no Retry-After")
+	}
+	// Login codes answer as usual but are not sent again either.
+	srv.client().do("POST", "/public/user/requestloginotp", phoneBody(phone)).wantCode(http.StatusOK)
+	srv.client().do("POST", "/public/user/requestloginotp", phoneBody(phone)).wantCode(http.StatusOK)
+	srv.h.Wait()
+	if n := len(srv.texts.sent(phone)); n != 2 {
+		t.Errorf("sent %d texts, want 2", n)
+	}
+
+	srv.cfg.Auth.OTPResendInterval = 0
+	c.do("POST", "/private/user/requestphoneverification", "").wantCode(http.StatusOK)
+	c.do("POST", "/private/user/verifyphone", `{"code":""}`).wantError(http.StatusBadRequest, "Code is required")
+	c.do("POST", "/private/user/verifyphone", fmt.Sprintf(`{"code":%q}`, srv.texts.lastCode(t, phone))).wantCode(http.StatusOK)
+	if !c.phoneVerified() {
+		t.Error("phone not verified")
+	}
+	c.do("POST", "/private/user/requestphoneverification", "").wantError(http.StatusConflict, "Phone is already verified")
+}
+
+func TestOTPForAChangedPhone(t *testing.T) {
+	srv := newTestServer(t, func(cfg *config.Config) { cfg.Auth.OTPResendInterval = 0 })
+	c := srv.signUp("asha@example.com")
+	c.do("POST", "/private/user/requestphoneverification", "").wantCode(http.StatusOK)
+	code := srv.texts.lastCode(t, c.user.Phone)
+
+	c.do("POST", "/private/user/edit", `{"name":"Test User","email":"asha@example.com","phone":"9000000001"}`).wantCode(http.StatusOK)
+	// The code was sent to the old phone, so it proves nothing about the new one.
+	c.do("POST", "/private/user/verifyphone", fmt.Sprintf(`{"code":%q}`, code)).
+		wantError(http.StatusUnauthorized, "Invalid or expired code")
+	if c.phoneVerified() {
+		t.Error("phone verified with a code sent to the old phone")
+	}
+
+	c.do("POST", "/private/user/requestphoneverification", "").wantCode(http.StatusOK)
+	c.do("POST", "/private/user/verifyphone", fmt.Sprintf(`{"code":%q}`, srv.texts.lastCode(t, "9000000001"))).wantCode(http.StatusOK)
+	if !c.phoneVerified() {
+		t.Error("new phone not verified")
+	}
+}
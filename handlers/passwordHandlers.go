@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/middleware"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+// invalidPasswordError rejects a password that does not meet the policy. Its
+// message is meant for the client.
+type invalidPasswordError string
+
+func (e invalidPasswordError) Error() string { return string(e) }
+
+// validatePassword checks a new password against the policy: at least
+// Auth.PasswordMinLength characters, no longer than bcrypt can hash, with a
+// letter and a digit, and not the account's email.
+func (h *Handler) validatePassword(password, email string) error {
+	var hasLetter, hasDigit bool
+	for _, c := range password {
+		hasLetter = hasLetter || unicode.IsLetter(c)
+		hasDigit = hasDigit || unicode.IsDigit(c)
+	}
+
+	switch minLength := h.Config.Auth.PasswordMinLength; {
+	case len([]rune(password)) < minLength:
+		return invalidPasswordError(fmt.Sprintf("Password must be at least %d characters", minLength))
+	case len(password) > config.MaxPasswordBytes:
+		return invalidPasswordError(fmt.Sprintf("Password must be at most %d bytes", config.MaxPasswordBytes))
+	case !hasLetter || !hasDigit:
+		return invalidPasswordError("Password must contain a letter and a digit")
+	case email != "" && strings.EqualFold(password, email):
+		return invalidPasswordError("Password must not be your email")
+	}
+	return nil
+}
+
+// ChangePassword sets a new password for the signed in user after checking
+// their current one. Their other sessions are signed out and this one is
+// reissued.
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		WriteError(w, r, http.StatusBadRequest, "Current and new password are required")
+		return
+	}
+
+	// Wrong current passwords count as failed logins, so a stolen session
+	// does not allow more guesses than the login form.
+	ip := middleware.ClientIP(r, h.Config.Server.TrustProxy)
+	wait, err := h.loginWait(r.Context(), user.Email, ip)
+	if err != nil {
+		log.Printf("Error checking login failures for user %d from %s: %v", user.Id, ip, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to change password")
+		return
+	}
+	if wait > 0 {
+		h.recordLogin(r.Context(), user.Email, ip, user.Id, models.LoginThrottled)
+		setRetryAfter(w, wait)
+		WriteError(w, r, http.StatusTooManyRequests, "Too many failed password attempts; try again later")
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+		h.recordLogin(r.Context(), user.Email, ip, user.Id, models.LoginFailed)
+		WriteError(w, r, http.StatusForbidden, "Current password is incorrect")
+		return
+	}
+	if req.NewPassword == req.CurrentPassword {
+		WriteError(w, r, http.StatusBadRequest, "New password must be different from the current one")
+		return
+	}
+	if err := h.validatePassword(req.NewPassword, user.Email); err != nil {
+		WriteError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, "Failed to hash password")
+		return
+	}
+
+	version, err := h.Store.Users.UpdatePassword(r.Context(), user.Id, string(hashedPassword))
+	if err != nil {
+		log.Printf("Error updating password for user %d: %v", user.Id, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to change password")
+		return
+	}
+
+	// Every session, this one included, is now stale; reissue this one with
+	// the new version so the user stays signed in here.
+	session, err := h.Sessions.Get(r, "user_session")
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve session")
+		return
+	}
+	session.Values["sessionVersion"] = version
+	if err := session.Save(r, w); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, "Failed to save session")
+		return
+	}
+
+	log.Printf("Password changed for user %d", user.Id)
+	WriteSuccessMessage(w, r, "Password changed successfully")
+}
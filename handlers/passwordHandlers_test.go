@@ -0,0 +1,92 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+)
+
+func changePasswordBody(current, next string) string {
+	return fmt.Sprintf(`{"current_password":%q,"new_password":%q}`, current, next)
+}
+
+func TestChangePasswordSignsOutOtherSessions(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	other := srv.client()
+	other.do("POST", "/public/user/login", fmt.Sprintf(`{"email":"asha@example.com","password":%q}`, testPassword)).wantCode(http.StatusOK)
+
+	c.do("POST", "/private/user/changepassword", changePasswordBody(testPassword, "newsecret456")).wantCode(http.StatusOK)
+	c.do("GET", "/private/user/getuser", "").wantCode(http.StatusOK)
+	other.do("GET", "/private/user/getuser", "").wantCode(http.StatusUnauthorized)
+
+	srv.logIn("asha@example.com", testPassword).wantCode(http.StatusUnauthorized)
+	srv.logIn("asha@example.com", "newsecret456").wantCode(http.StatusOK)
+}
+
+func TestChangePasswordChecksTheCurrentPassword(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+
+	c.do("POST", "/private/user/changepassword", changePasswordBody("wrong123", "newsecret456")).
+		wantError(http.StatusForbidden, "Current password is incorrect")
+	c.do("POST", "/private/user/changepassword", changePasswordBody("", "newsecret456")).
+		wantError(http.StatusBadRequest, "Current and new password are required")
+	c.do("POST", "/private/user/changepassword", changePasswordBody(testPassword, testPassword)).
+		wantError(http.StatusBadRequest, "New password must be different from the current one")
+
+	// Nothing changed: the session is still valid and the password too.
+	c.do("GET", "/private/user/getuser", "").wantCode(http.StatusOK)
+	srv.logIn("asha@example.com", testPassword).wantCode(http.StatusOK)
+}
+
+func TestChangePasswordSharesTheLoginThrottle(t *testing.T) {
+	srv := newTestServer(t, func(cfg *config.Config) {
+		cfg.Auth.LoginBackoffAfter, cfg.Auth.LoginLockoutAfter = 2, 3
+		cfg.Auth.LoginBackoffBase, cfg.Auth.LoginBackoffMax = time.Hour, time.Hour
+	})
+	c := srv.signUp("asha@example.com")
+
+	for i := 0; i < 2; i++ {
+		c.do("POST", "/private/user/changepassword", changePasswordBody("wrong123", "newsecret456")).
+			wantError(http.StatusForbidden, "Current password is incorrect")
+	}
+	// The right password is refused until the delay is over, here and at
+	// the login form.
+	res := c.do("POST", "/private/user/changepassword", changePasswordBody(testPassword, "newsecret456"))
+	res.wantError(http.StatusTooManyRequests, "Too many failed password attempts; try again later")
+	if res.header.Get("Retry-After") == "" {
+		t.Error("no Retry-After")
+	}
+	srv.logIn("asha@example.com", testPassword).
+		wantError(http.StatusTooManyRequests, "Too many failed login attempts; try again later")
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha1@example.com")
+
+	tests := []struct {
+		password, want string
+	}{
+		{"short1", "Password must be at least 8 characters"},
+		{"onlyletters", "Password must contain a letter and a digit"},
+		{"12345678", "Password must contain a letter and a digit"},
+		{strings.Repeat("a1", 37), "Password must be at most 72 bytes"},
+		{"ASHA1@example.com", "Password must not be your email"},
+	}
+	for _, tt := range tests {
+		c.do("POST", "/private/user/changepassword", changePasswordBody(testPassword, tt.password)).
+			wantError(http.StatusBadRequest, tt.want)
+	}
+
+	// Password resets apply the policy too.
+	token := srv.requestReset("asha1@example.com")
+	srv.client().do("POST", "/public/user/resetpassword", resetBody(token, "short1")).
+		wantError(http.StatusBadRequest, "Password must be at least 8 characters")
+	srv.client().do("POST", "/public/user/resetpassword", resetBody(token, "pässwört1")).wantCode(http.StatusOK)
+}
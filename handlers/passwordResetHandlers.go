@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/mail"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
+)
+
+// resetRequestedMessage is the answer to every reset request, so that it
+// does not reveal which emails are registered.
+const resetRequestedMessage = "If the email is registered, a reset link has been sent to it"
+
+// RequestPasswordReset emails the user a single-use link to choose a new
+// password, at most once every Auth.PasswordResetResendInterval.
+func (h *Handler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.Email = strings.TrimSpace(req.Email); req.Email == "" {
+		WriteError(w, r, http.StatusBadRequest, "Email is required")
+		return
+	}
+
+	user, err := h.Store.Users.GetByEmail(r.Context(), req.Email)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteSuccessMessage(w, r, resetRequestedMessage)
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching user for password reset: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to request password reset")
+		return
+	}
+
+	// Too soon after the last link nothing is sent, but the answer is the
+	// same, as a 429 would tell the caller that the email is registered.
+	last, err := h.Store.PasswordResets.LastSentAt(r.Context(), user.Id)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		log.Printf("Error fetching last password reset for user %d: %v", user.Id, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to request password reset")
+		return
+	}
+	if err == nil && time.Since(last) < h.Config.Auth.PasswordResetResendInterval {
+		log.Printf("Password reset for user %d requested again too soon", user.Id)
+		WriteSuccessMessage(w, r, resetRequestedMessage)
+		return
+	}
+
+	msg, err := h.newPasswordResetEmail(r.Context(), user)
+	if err != nil {
+		log.Printf("Error creating password reset for user %d: %v", user.Id, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to request password reset")
+		return
+	}
+	// Sent after answering, so that registered emails are not answered
+	// more slowly than unknown ones.
+	h.inBackground(r, fmt.Sprintf("sending password reset email to user %d", user.Id), func(ctx context.Context) error {
+		return h.Mailer.Send(ctx, msg)
+	})
+
+	log.Printf("Password reset requested for user %d", user.Id)
+	WriteSuccessMessage(w, r, resetRequestedMessage)
+}
+
+// newPasswordResetEmail saves a reset for user and returns the email with
+// its single-use link.
+func (h *Handler) newPasswordResetEmail(ctx context.Context, user models.User) (mail.Message, error) {
+	token, tokenHash, err := newToken()
+	if err != nil {
+		return mail.Message{}, err
+	}
+	ttl := h.Config.Auth.PasswordResetTTL
+	reset := models.PasswordReset{UserID: user.Id, TokenHash: tokenHash, ExpiresAt: time.Now().Add(ttl)}
+	if err := h.Store.PasswordResets.Create(ctx, &reset); err != nil {
+		return mail.Message{}, err
+	}
+
+	link := h.Config.App.PublicURL + "/reset-password?token=" + url.QueryEscape(token)
+	return mail.Message{
+		To:      user.Email,
+		Subject: "Reset your FoodHaven password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse this link to choose a new password. It expires in %d minutes and can only be used once:\n\n%s\n\n"+
+			"If you did not ask to reset your password, you can ignore this email.\n", user.Name, int(ttl.Minutes()), link),
+	}, nil
+}
+
+// ResetPassword sets a new password using the token from a reset link. Every
+// session of the user is signed out.
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	var req struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.Token == "" || req.Password == "" {
+		WriteError(w, r, http.StatusBadRequest, "Token and password are required")
+		return
+	}
+	// The email is not known until the token is redeemed, so only the
+	// rest of the policy applies.
+	if err := h.validatePassword(req.Password, ""); err != nil {
+		WriteError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, "Failed to hash password")
+		return
+	}
+
+	userID, err := h.Store.PasswordResets.Redeem(r.Context(), hashToken(req.Token), string(hashedPassword))
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusBadRequest, "Reset link is invalid or has expired")
+		return
+	}
+	if err != nil {
+		log.Printf("Error resetting password: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	log.Printf("Password reset for user %d", userID)
+	WriteSuccessMessage(w, r, "Password has been reset")
+}
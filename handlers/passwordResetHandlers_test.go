@@ -0,0 +1,117 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+)
+
+// requestReset asks for a reset link for email and returns its token.
+func (s *testServer) requestReset(email string) string {
+	s.t.Helper()
+	s.client().do("POST", "/public/user/requestpasswordreset", fmt.Sprintf(`{"email":%q}`, email)).
+		wantCode(http.StatusOK)
+	s.h.Wait()
+	return s.mails.lastToken(s.t, email)
+}
+
+func resetBody(token, password string) string {
+	return fmt.Sprintf(`{"token":%q,"password":%q}`, token, password)
+}
+
+func (s *testServer) logIn(email, password string) *testResponse {
+	s.t.Helper()
+	return s.client().do("POST", "/public/user/login", fmt.Sprintf(`{"email":%q,"password":%q}`, email, password))
+}
+
+func TestPasswordResetSignsOutEverySession(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	c.do("GET", "/private/user/getuser", "").wantCode(http.StatusOK)
+
+	token := srv.requestReset("asha@example.com")
+	msgs := srv.mails.sent("asha@example.com")
+	msg := msgs[len(msgs)-1]
+	if !strings.HasPrefix(msg.Body, "Hi Test User,") || !strings.Contains(msg.Body, srv.cfg.App.PublicURL+"/reset-password?token=") {
+		t.Errorf("email = %q, want a reset link", msg.Body)
+	}
+
+	srv.client().do("POST", "/public/user/resetpassword", resetBody(token, "newsecret456")).wantCode(http.StatusOK)
+	c.do("GET", "/private/user/getuser", "").wantCode(http.StatusUnauthorized)
+	srv.logIn("asha@example.com", testPassword).wantCode(http.StatusUnauthorized)
+	srv.logIn("asha@example.com", "newsecret456").wantCode(http.StatusOK)
+}
+
+func TestPasswordResetLinksAreSingleUse(t *testing.T) {
+	srv := newTestServer(t, func(cfg *config.Config) { cfg.Auth.PasswordResetResendInterval = 0 })
+	srv.signUp("asha@example.com")
+	older := srv.requestReset("asha@example.com")
+	token := srv.requestReset("asha@example.com")
+
+	srv.client().do("POST", "/public/user/resetpassword", resetBody(token, "newsecret456")).wantCode(http.StatusOK)
+	srv.client().do("POST", "/public/user/resetpassword", resetBody(token, "again789")).
+		wantError(http.StatusBadRequest, "Reset link is invalid or has expired")
+	// Using one link spends the others sent before it.
+	srv.client().do("POST", "/public/user/resetpassword", resetBody(older, "again789")).
+		wantError(http.StatusBadRequest, "Reset link is invalid or has expired")
+	srv.logIn("asha@example.com", "newsecret456").wantCode(http.StatusOK)
+}
+
+func TestPasswordResetLinksExpire(t *testing.T) {
+	srv := newTestServer(t, func(cfg *config.Config) { cfg.Auth.PasswordResetTTL = time.Millisecond })
+	srv.signUp("asha@example.com")
+	token := srv.requestReset("asha@example.com")
+	time.Sleep(5 * time.Millisecond)
+
+	srv.client().do("POST", "/public/user/resetpassword", resetBody(token, "newsecret456")).
+		wantError(http.StatusBadRequest, "Reset link is invalid or has expired")
+	srv.logIn("asha@example.com", testPassword).wantCode(http.StatusOK)
+}
+
+func TestRequestPasswordResetDoesNotRevealAccounts(t *testing.T) {
+	srv := newTestServer(t)
+	srv.signUp("asha@example.com")
+
+	var registered, unknown string
+	srv.client().do("POST", "/public/user/requestpasswordreset", `{"email":"asha@example.com"}`).wantCode(http.StatusOK).decode(&registered)
+	srv.client().do("POST", "/public/user/requestpasswordreset", `{"email":"nobody@example.com"}`).wantCode(http.StatusOK).decode(&unknown)
+	if registered != unknown {
+		t.Errorf("answers differ: %q and %q", registered, unknown)
+	}
+	srv.h.Wait()
+	if msgs := srv.mails.sent("nobody@example.com"); len(msgs) != 0 {
+		t.Errorf("sent %d emails to an unknown address", len(msgs))
+	}
+
+	srv.client().do("POST", "/public/user/requestpasswordreset", `{"email":" "}`).wantError(http.StatusBadRequest, "Email is required")
+	srv.client().do("POST", "/public/user/resetpassword", resetBody("", "x")).
+		wantError(http.StatusBadRequest, "Token and password are required")
+	srv.client().do("POST", "/public/user/resetpassword", resetBody("forged", "newsecret456")).
+		wantError(http.StatusBadRequest, "Reset link is invalid or has expired")
+}
+
+func TestPasswordResetEmailsAreSpacedOut(t *testing.T) {
+	srv := newTestServer(t)
+	srv.signUp("asha@example.com")
+	token := srv.requestReset("asha@example.com")
+
+	// Asking again too soon gets the same answer but no new link.
+	var answer string
+	srv.client().do("POST", "/public/user/requestpasswordreset", `{"email":"asha@example.com"}`).wantCode(http.StatusOK).decode(&answer)
+	srv.h.Wait()
+	if answer != "If the email is registered, a reset link has been sent to it" {
+		t.Errorf("answer = %q", answer)
+	}
+	if got := srv.mails.lastToken(t, "asha@example.com"); got != token || len(srv.mails.sent("asha@example.com")) != 2 {
+		t.Errorf("a second reset email was sent; %d emails", len(srv.mails.sent("asha@example.com")))
+	}
+
+	srv.cfg.Auth.PasswordResetResendInterval = 0
+	if srv.requestReset("asha@example.com") == token {
+		t.Error("no new link once the interval has passed")
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+// maxItemQuantity caps a single line so a typo cannot produce a huge charge.
+const maxItemQuantity = 50
+
+// invalidItemsError rejects a request that cannot be priced. Its message is
+// meant for the client.
+type invalidItemsError string
+
+func (e invalidItemsError) Error() string { return string(e) }
+
+// quote is an order priced from the menu. Amounts are in paise so totals do
+// not accumulate floating point error.
+type quote struct {
+	RestaurantID int
+	// Items carry the current menu name, image and unit price.
+	Items    []models.OrderItem
+	Subtotal int64
+	Total    int64
+}
+
+// TotalAmount returns the total in rupees, as stored on orders.
+func (q quote) TotalAmount() float64 { return float64(q.Total) / 100 }
+
+func toPaise(rupees float64) int64 { return int64(math.Round(rupees * 100)) }
+
+// priceItems looks every requested item up in FoodItems and prices the order
+// from the menu. Only the ID and quantity of each requested item are trusted;
+// a restaurant ID, when given, must match the item's restaurant. Repeated IDs
+// are merged.
+func (h *Handler) priceItems(ctx context.Context, requested []models.OrderItem) (quote, error) {
+	if len(requested) == 0 {
+		return quote{}, invalidItemsError("No items to check out")
+	}
+
+	var ids []int
+	quantities := make(map[int]int)
+	for _, item := range requested {
+		if item.Quantity <= 0 {
+			return quote{}, invalidItemsError(fmt.Sprintf("Invalid quantity for item %d", item.ID))
+		}
+		if _, seen := quantities[item.ID]; !seen {
+			ids = append(ids, item.ID)
+		}
+		quantities[item.ID] += item.Quantity
+		if quantities[item.ID] > maxItemQuantity {
+			return quote{}, invalidItemsError(fmt.Sprintf("At most %d of item %d can be ordered", maxItemQuantity, item.ID))
+		}
+	}
+
+	menu, err := h.Store.Food.GetByIDs(ctx, ids)
+	if err != nil {
+		return quote{}, err
+	}
+
+	var q quote
+	for _, item := range requested {
+		food, ok := menu[item.ID]
+		if !ok {
+			return quote{}, invalidItemsError(fmt.Sprintf("Unknown item %d", item.ID))
+		}
+		if item.RestaurantID != 0 && item.RestaurantID != food.RestaurantID {
+			return quote{}, invalidItemsError(fmt.Sprintf("Item %d does not belong to restaurant %d", item.ID, item.RestaurantID))
+		}
+		if q.RestaurantID == 0 {
+			q.RestaurantID = food.RestaurantID
+		} else if q.RestaurantID != food.RestaurantID {
+			return quote{}, invalidItemsError("All items must be from the same restaurant")
+		}
+	}
+
+	for _, id := range ids {
+		food := menu[id]
+		q.Items = append(q.Items, models.OrderItem{
+			ID:           id,
+			Name:         food.Name,
+			Price:        food.Price,
+			CloudImageID: food.CloudImageID,
+			Quantity:     quantities[id],
+			RestaurantID: food.RestaurantID,
+		})
+		q.Subtotal += toPaise(food.Price) * int64(quantities[id])
+	}
+	// There are no delivery fees or taxes yet.
+	q.Total = q.Subtotal
+	return q, nil
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
+)
+
+// menuHandler serves restaurant 1 with Dosa (10, ₹100) and Idli (11,
+// ₹40.10), and restaurant 2 with Pizza (20, ₹249.50).
+func menuHandler() *Handler {
+	mem := store.NewMemory()
+	mem.AddRestaurant("Pune", models.Restaurants{Id: 1, Name: "Dosa Corner", CloudImageID: "img1"})
+	mem.AddRestaurant("Pune", models.Restaurants{Id: 2, Name: "Pizza Place", CloudImageID: "img2"})
+	mem.AddFoodItem(models.FoodItems{Id: 10, Name: "Dosa", Price: 100, CloudImageID: "img1"})
+	mem.AddFoodItem(models.FoodItems{Id: 11, Name: "Idli", Price: 40.1, CloudImageID: "img1"})
+	mem.AddFoodItem(models.FoodItems{Id: 20, Name: "Pizza", Price: 249.5, CloudImageID: "img2"})
+	return New(config.Default(), mem.Store(), nil, nil, nil, nil, nil)
+}
+
+func TestPriceItemsUsesMenuPrices(t *testing.T) {
+	h := menuHandler()
+
+	// Client prices and names are ignored and repeated items merged.
+	q, err := h.priceItems(context.Background(), []models.OrderItem{
+		{ID: 10, Quantity: 1, Price: 1, Name: "Free dosa"},
+		{ID: 11, Quantity: 1, Price: 0.01},
+		{ID: 10, Quantity: 1, RestaurantID: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Total != 24010 || q.TotalAmount() != 240.1 || q.RestaurantID != 1 {
+		t.Errorf("quote = %d paise (%v) from restaurant %d, want 24010 (240.1) from 1", q.Total, q.TotalAmount(), q.RestaurantID)
+	}
+	want := []models.OrderItem{
+		{ID: 10, Name: "Dosa", Price: 100, CloudImageID: "img1", Quantity: 2, RestaurantID: 1},
+		{ID: 11, Name: "Idli", Price: 40.1, CloudImageID: "img1", Quantity: 1, RestaurantID: 1},
+	}
+	if len(q.Items) != len(want) {
+		t.Fatalf("items = %+v, want %+v", q.Items, want)
+	}
+	for i := range want {
+		if q.Items[i] != want[i] {
+			t.Errorf("items[%d] = %+v, want %+v", i, q.Items[i], want[i])
+		}
+	}
+}
+
+func TestPriceItemsRejectsItemsThatCannotBePriced(t *testing.T) {
+	h := menuHandler()
+
+	tests := []struct {
+		items   []models.OrderItem
+		message string
+	}{
+		{nil, "No items to check out"},
+		{[]models.OrderItem{{ID: 10}}, "Invalid quantity for item 10"},
+		{[]models.OrderItem{{ID: 10, Quantity: -1}}, "Invalid quantity for item 10"},
+		{[]models.OrderItem{{ID: 10, Quantity: 30}, {ID: 10, Quantity: 21}}, "At most 50 of item 10 can be ordered"},
+		{[]models.OrderItem{{ID: 99, Quantity: 1}}, "Unknown item 99"},
+		{[]models.OrderItem{{ID: 10, Quantity: 1, RestaurantID: 2}}, "Item 10 does not belong to restaurant 2"},
+		{[]models.OrderItem{{ID: 10, Quantity: 1}, {ID: 20, Quantity: 1}}, "All items must be from the same restaurant"},
+	}
+	for _, tt := range tests {
+		_, err := h.priceItems(context.Background(), tt.items)
+		if _, ok := err.(invalidItemsError); !ok || err.Error() != tt.message {
+			t.Errorf("priceItems(%+v) = %v, want %q", tt.items, err, tt.message)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/middleware"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
+)
+
+// ReorderOrder copies one of the user's past orders into their active cart,
+// priced from the current menu, and reports what changed since the order was
+// placed. Items no longer on the menu, or now sold by another restaurant, are
+// left out.
+//
+// A cart holds items from one restaurant. Items already in the cart from the
+// same restaurant are kept and quantities added up; items from another
+// restaurant make the request fail with 409 unless the body is
+// {"replace": true}, which empties the cart first.
+func (h *Handler) ReorderOrder(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	orderID, ok := orderIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	// The body is optional.
+	var req struct {
+		Replace bool `json:"replace"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	order, ok := h.orderOrWriteError(w, r, orderID)
+	if !ok {
+		return
+	}
+	if order.UserID != user.Id {
+		WriteError(w, r, http.StatusNotFound, "Order not found")
+		return
+	}
+
+	ids := make([]int, 0, len(order.Items))
+	for _, item := range order.Items {
+		ids = append(ids, item.ID)
+	}
+	menu, err := h.Store.Food.GetByIDs(r.Context(), ids)
+	if err != nil {
+		log.Printf("Error fetching menu items for order %d: %v", orderID, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to fetch menu items")
+		return
+	}
+
+	result := models.ReorderResult{Changed: []models.ReorderItemChange{}, Removed: []models.ReorderItemChange{}}
+	var items []models.OrderItem
+	var restaurantID int
+	for _, item := range order.Items {
+		change := models.ReorderItemChange{ItemID: item.ID, Name: item.Name, OldPrice: item.Price}
+		current, ok := menu[item.ID]
+		switch {
+		case !ok:
+			change.Reason = models.ReorderUnavailable
+			result.Removed = append(result.Removed, change)
+			continue
+		case restaurantID != 0 && current.RestaurantID != restaurantID:
+			// Only orders placed before checkout enforced a single
+			// restaurant, or whose items have since moved, get here.
+			change.Reason = models.ReorderOtherRestaurant
+			result.Removed = append(result.Removed, change)
+			continue
+		}
+
+		restaurantID = current.RestaurantID
+		items = append(items, models.OrderItem{
+			ID:           item.ID,
+			Name:         current.Name,
+			Price:        current.Price,
+			CloudImageID: current.CloudImageID,
+			Quantity:     item.Quantity,
+			RestaurantID: current.RestaurantID,
+		})
+		if toPaise(current.Price) != toPaise(item.Price) {
+			change.Reason, change.NewPrice, change.Quantity = models.ReorderPriceChanged, current.Price, item.Quantity
+			result.Changed = append(result.Changed, change)
+		}
+	}
+	if len(items) == 0 {
+		WriteError(w, r, http.StatusConflict, "None of the items in this order are available")
+		return
+	}
+
+	cart, err := h.Store.Carts.GetActive(r.Context(), user.Id)
+	if errors.Is(err, store.ErrNotFound) {
+		cart, err = h.Store.Carts.Create(r.Context(), user.Id)
+	}
+	if err != nil {
+		log.Printf("Error fetching cart for user %d: %v", user.Id, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to fetch cart")
+		return
+	}
+
+	if len(cart.Items) > 0 && !req.Replace {
+		if cart.RestaurantID != restaurantID {
+			WriteError(w, r, http.StatusConflict, "Cart has items from another restaurant")
+			return
+		}
+		var capped map[int]bool
+		items, capped = mergeCartItems(cart.Items, items)
+		for _, item := range order.Items {
+			if capped[item.ID] {
+				result.Changed = append(result.Changed, models.ReorderItemChange{
+					ItemID:   item.ID,
+					Name:     item.Name,
+					Reason:   models.ReorderQuantityReduced,
+					OldPrice: item.Price,
+					NewPrice: menu[item.ID].Price,
+					Quantity: maxItemQuantity,
+				})
+			}
+		}
+	}
+
+	if err := h.Store.Carts.Sync(r.Context(), user.Id, cart.ID, items); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			// Checked out or replaced since it was read.
+			WriteError(w, r, http.StatusConflict, "Cart changed, please try again")
+		default:
+			log.Printf("Error syncing cart %d: %v", cart.ID, err)
+			WriteError(w, r, http.StatusInternalServerError, "Failed to update cart")
+		}
+		return
+	}
+
+	if result.Cart, err = h.Store.Carts.GetActive(r.Context(), user.Id); err != nil {
+		log.Printf("Error fetching cart for user %d: %v", user.Id, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to fetch cart")
+		return
+	}
+
+	log.Printf("Order %d reordered into cart %d for user %d", orderID, cart.ID, user.Id)
+	WriteSuccessMessage(w, r, result)
+}
+
+// mergeCartItems adds items to what is already in the cart, capping each
+// item at the quantity checkout accepts. It reports which items were capped.
+func mergeCartItems(inCart, items []models.OrderItem) (merged []models.OrderItem, capped map[int]bool) {
+	merged = append([]models.OrderItem(nil), inCart...)
+	capped = make(map[int]bool)
+	index := make(map[int]int, len(merged))
+	for i, item := range merged {
+		index[item.ID] = i
+	}
+
+	for _, item := range items {
+		i, ok := index[item.ID]
+		if !ok {
+			index[item.ID] = len(merged)
+			merged = append(merged, item)
+			continue
+		}
+		merged[i].Quantity += item.Quantity
+		if merged[i].Quantity > maxItemQuantity {
+			merged[i].Quantity = maxItemQuantity
+			capped[item.ID] = true
+		}
+	}
+	return merged, capped
+}
@@ -0,0 +1,75 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+func TestReorderCopiesThePastOrderIntoTheCart(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	orderID := c.checkout(10, 2, 11, 1)
+	srv.markPaid(orderID)
+
+	// Since the order: Dosa got dearer and Idli left the menu.
+	srv.mem.AddFoodItem(models.FoodItems{Id: 10, Name: "Dosa", Price: 120, CloudImageID: "img1", Category: "Mains"})
+	srv.mem.AddFoodItem(models.FoodItems{Id: 11, Name: "Idli", Price: 40.1, CloudImageID: "gone", Category: "Snacks"})
+
+	var result models.ReorderResult
+	c.do("POST", fmt.Sprintf("/private/orders/%d/reorder", orderID), "").wantCode(http.StatusOK).decode(&result)
+	if len(result.Cart.Items) != 1 || result.Cart.Items[0].ID != 10 || result.Cart.Items[0].Quantity != 2 ||
+		result.Cart.Items[0].Price != 120 || result.Cart.RestaurantID != 1 {
+		t.Errorf("cart = %+v, want 2 Dosa at 120 from restaurant 1", result.Cart)
+	}
+	if len(result.Changed) != 1 || result.Changed[0] != (models.ReorderItemChange{
+		ItemID: 10, Name: "Dosa", Reason: models.ReorderPriceChanged, OldPrice: 100, NewPrice: 120, Quantity: 2,
+	}) {
+		t.Errorf("changed = %+v, want Dosa's price", result.Changed)
+	}
+	if len(result.Removed) != 1 || result.Removed[0].ItemID != 11 || result.Removed[0].Reason != models.ReorderUnavailable {
+		t.Errorf("removed = %+v, want Idli as unavailable", result.Removed)
+	}
+
+	// Reordering again adds to the cart.
+	c.do("POST", fmt.Sprintf("/private/orders/%d/reorder", orderID), `{}`).wantCode(http.StatusOK).decode(&result)
+	if len(result.Cart.Items) != 1 || result.Cart.Items[0].Quantity != 4 {
+		t.Errorf("cart = %+v, want 4 Dosa", result.Cart.Items)
+	}
+}
+
+func TestReorderIntoACartFromAnotherRestaurant(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	orderID := c.checkout(20, 1)
+
+	var cart models.Cart
+	c.do("GET", "/private/user/getcart", "").wantCode(http.StatusOK).decode(&cart)
+	c.do("POST", fmt.Sprintf("/private/user/synccart/%d", cart.ID), `{"items":[{"id":10,"quantity":1}]}`).wantCode(http.StatusOK)
+
+	path := fmt.Sprintf("/private/orders/%d/reorder", orderID)
+	c.do("POST", path, "").wantError(http.StatusConflict, "Cart has items from another restaurant")
+
+	var result models.ReorderResult
+	c.do("POST", path, `{"replace":true}`).wantCode(http.StatusOK).decode(&result)
+	if len(result.Cart.Items) != 1 || result.Cart.Items[0].ID != 20 || result.Cart.RestaurantID != 2 {
+		t.Errorf("cart = %+v, want only the Pizza", result.Cart)
+	}
+}
+
+func TestReorderNeedsTheCustomersOwnOrder(t *testing.T) {
+	srv := newTestServer(t)
+	asha, ravi := srv.signUp("asha@example.com"), srv.signUp("ravi@example.com")
+	orderID := asha.checkout(10, 1)
+
+	ravi.do("POST", fmt.Sprintf("/private/orders/%d/reorder", orderID), "").wantError(http.StatusNotFound, "Order not found")
+	ravi.do("POST", "/private/orders/999/reorder", "").wantError(http.StatusNotFound, "Order not found")
+
+	var cart models.Cart
+	ravi.do("GET", "/private/user/getcart", "").wantCode(http.StatusOK).decode(&cart)
+	if len(cart.Items) != 0 {
+		t.Errorf("ravi's cart = %+v, want it untouched", cart.Items)
+	}
+}
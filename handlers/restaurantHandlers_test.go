@@ -0,0 +1,43 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+func TestRestaurantsAndMenus(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.client()
+
+	var cities struct {
+		Data []string `json:"data"`
+	}
+	c.do("GET", "/public/cities", "").wantCode(http.StatusOK).decode(&cities)
+	if len(cities.Data) != 1 || cities.Data[0] != "Pune" {
+		t.Errorf("cities = %v, want [Pune]", cities.Data)
+	}
+
+	var restaurants struct {
+		Data []models.Restaurants `json:"data"`
+	}
+	c.do("GET", "/public/restaurants?city=Pune", "").wantCode(http.StatusOK).decode(&restaurants)
+	if len(restaurants.Data) != 2 {
+		t.Errorf("restaurants in Pune = %+v, want 2", restaurants.Data)
+	}
+	c.do("GET", "/public/restaurants?city=Mumbai", "").wantCode(http.StatusOK).decode(&restaurants)
+	if len(restaurants.Data) != 0 {
+		t.Errorf("restaurants in Mumbai = %+v, want none", restaurants.Data)
+	}
+	c.do("GET", "/public/restaurants", "").wantCode(http.StatusBadRequest)
+
+	var menu struct {
+		Data map[string][]models.FoodItems `json:"data"`
+	}
+	c.do("GET", "/public/fooditems?cloudimageid=img1", "").wantCode(http.StatusOK).decode(&menu)
+	if len(menu.Data["Mains"]) != 1 || menu.Data["Mains"][0].Name != "Dosa" || len(menu.Data["Snacks"]) != 1 {
+		t.Errorf("menu = %+v, want Dosa under Mains and Idli under Snacks", menu.Data)
+	}
+	c.do("GET", "/public/fooditems", "").wantCode(http.StatusBadRequest)
+}
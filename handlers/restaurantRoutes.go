@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func registerRestaurantsRoutes(r *mux.Router, h *Handler) {
+	r.NotFoundHandler = http.NotFoundHandler()
+
+	r.HandleFunc("/restaurants", h.GetRestaurants).Methods("GET")
+	r.HandleFunc("/cities", h.GetCities).Methods("GET")
+}
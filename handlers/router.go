@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"github.com/gorilla/mux"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/middleware"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/ratelimit"
+)
+
+// NewRouter registers the API routes of h with their authentication, role
+// and rate limit middleware. main adds the UI on top; tests serve it as is.
+func NewRouter(h *Handler, cfg *config.Config) *mux.Router {
+	router := mux.NewRouter().StrictSlash(true)
+	registerHealthRoutes(router, h)
+	registerWebhookRoutes(router, h)
+
+	// Each group's limit applies to its routes and the nested groups'.
+	// Nested groups are registered last: they are subrouters without a
+	// prefix of their own, and only see requests their parent did not match.
+	limiter := ratelimit.NewMemory()
+	limits, trustProxy := cfg.RateLimit, cfg.Server.TrustProxy
+
+	publicRoutes := router.PathPrefix("/public").Subrouter()
+	publicRoutes.Use(middleware.RateLimit(limiter, "public", limits.Public, middleware.ByIP(trustProxy)))
+	registerFoodRoutes(publicRoutes, h)
+	registerRestaurantsRoutes(publicRoutes, h)
+	authRoutes := publicRoutes.NewRoute().Subrouter()
+	authRoutes.Use(middleware.RateLimit(limiter, "auth", limits.Auth, middleware.ByIP(trustProxy)))
+	registerUserRoutes(authRoutes, h)
+
+	protectedRoutes := router.PathPrefix("/private").Subrouter()
+	protectedRoutes.Use(middleware.Authenticate(h.Sessions, h.Store.Users))
+	protectedRoutes.Use(middleware.RateLimit(limiter, "private", limits.Private, middleware.ByUser(trustProxy)))
+	registerProtectedUserRoutes(protectedRoutes, h)
+	checkoutRoutes := protectedRoutes.NewRoute().Subrouter()
+	checkoutRoutes.Use(middleware.RateLimit(limiter, "checkout", limits.Checkout, middleware.ByUser(trustProxy)))
+	registerCheckoutRoutes(checkoutRoutes, h)
+	adminRoutes := protectedRoutes.PathPrefix("/admin").Subrouter()
+	adminRoutes.Use(middleware.Authorize(models.RoleAdmin))
+	registerAdminRoutes(adminRoutes, h)
+
+	return router
+}
@@ -0,0 +1,430 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/events"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/handlers"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/mail"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/payment"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
+)
+
+func TestMain(m *testing.M) {
+	// Handlers log every request.
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+const testPassword = "secret123"
+
+// testServer serves the application's routes, from the router main uses,
+// against store.Memory and the fake payment provider.
+type testServer struct {
+	t     *testing.T
+	cfg   *config.Config
+	mem   *store.Memory
+	store *store.Store
+	fake  *payment.Fake
+	// h is wired to the routes; Wait lets tests see what it sends after
+	// answering.
+	h *handlers.Handler
+	// payments wraps fake; handlers use it.
+	payments *flakyPayments
+	broker   *events.Broker
+	mails    *mailbox
+	texts    *outbox
+	url      string
+	// transport trusts the server's certificate.
+	transport http.RoundTripper
+	users     int
+}
+
+// newTestServer starts a server with two restaurants in Pune: 1 sells Dosa
+// (10, ₹100) and Idli (11, ₹40.10); 2 sells Pizza (20, ₹249.50). configure
+// adjusts the configuration before the handlers are built.
+func newTestServer(t *testing.T, configure ...func(*config.Config)) *testServer {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Payment.Provider = config.PaymentFake
+	cfg.Payment.FakeWebhookSecret = "fake_secret"
+	// Tests that exercise rate limiting set their own limits.
+	cfg.RateLimit = config.RateLimitConfig{}
+	for _, f := range configure {
+		f(cfg)
+	}
+
+	mem := store.NewMemory()
+	mem.AddRestaurant("Pune", models.Restaurants{Id: 1, Name: "Dosa Corner", CloudImageID: "img1"})
+	mem.AddRestaurant("Pune", models.Restaurants{Id: 2, Name: "Pizza Place", CloudImageID: "img2"})
+	mem.AddFoodItem(models.FoodItems{Id: 10, Name: "Dosa", Price: 100, CloudImageID: "img1", Category: "Mains"})
+	mem.AddFoodItem(models.FoodItems{Id: 11, Name: "Idli", Price: 40.1, CloudImageID: "img1", Category: "Snacks"})
+	mem.AddFoodItem(models.FoodItems{Id: 20, Name: "Pizza", Price: 249.5, CloudImageID: "img2", Category: "Mains"})
+
+	st := mem.Store()
+	broker := events.NewBroker()
+	st.Orders = events.PublishOrderChanges(st.Orders, broker)
+	fake := payment.NewFake(cfg.Payment.FakeWebhookSecret)
+	payments := &flakyPayments{Fake: fake}
+	sessionStore := sessions.NewCookieStore([]byte("0123456789abcdef0123456789abcdef"))
+	mails := &mailbox{}
+	texts := &outbox{}
+	h := handlers.New(cfg, st, sessionStore, payments, broker, mails, texts)
+
+	router := handlers.NewRouter(h, cfg)
+
+	// Sessions are secure cookies, which are only sent over TLS.
+	srv := httptest.NewTLSServer(router)
+	// Closing the broker ends open event streams, which srv.Close waits for.
+	t.Cleanup(srv.Close)
+	t.Cleanup(broker.Close)
+	t.Cleanup(h.Wait)
+	return &testServer{t: t, cfg: cfg, mem: mem, store: st, fake: fake, h: h, payments: payments, broker: broker, mails: mails, texts: texts, url: srv.URL, transport: srv.Client().Transport}
+}
+
+// client returns a client with its own cookie jar, so its own session.
+func (s *testServer) client() *testClient {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return &testClient{t: s.t, srv: s, http: &http.Client{Transport: s.transport, Jar: jar}}
+}
+
+// register signs up a customer without verifying their email and returns a
+// client signed in as them.
+func (s *testServer) register(email string) *testClient {
+	s.t.Helper()
+	s.users++
+	c := s.client()
+	res := c.do("POST", "/public/user/signup",
+		fmt.Sprintf(`{"name":"Test User","email":%q,"phone":"98765%05d","password":%q}`, email, s.users, testPassword))
+	res.wantCode(http.StatusOK)
+	res.decode(&c.user)
+	return c
+}
+
+// signUp registers a customer with a verified email and a delivery address,
+// and returns a client signed in as them.
+func (s *testServer) signUp(email string) *testClient {
+	s.t.Helper()
+	c := s.register(email)
+	c.do("POST", "/public/user/verifyemail", fmt.Sprintf(`{"token":%q}`, s.mails.lastToken(s.t, email))).wantCode(http.StatusOK)
+	c.user.EmailVerified = true
+
+	var address models.Address
+	res := c.do("POST", "/private/user/addaddress", `{"name":"Home","street":"1 MG Road","city":"Pune","postalCode":"411001","phone":"9876543210"}`)
+	res.wantCode(http.StatusOK)
+	res.decode(&address)
+	c.addressID = address.ID
+	return c
+}
+
+// signUpAs registers a user and grants them role. Restaurant owners own
+// restaurantIDs.
+func (s *testServer) signUpAs(email, role string, restaurantIDs ...int) *testClient {
+	s.t.Helper()
+	c := s.signUp(email)
+	s.mem.SetUserRole(c.user.Id, role)
+	c.user.Role = role
+	for _, id := range restaurantIDs {
+		if err := s.store.Restaurants.AddOwner(context.Background(), id, c.user.Id); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	return c
+}
+
+// markPaid applies the payment outcome the gateway would report for an
+// order's checkout.
+func (s *testServer) markPaid(orderID int) {
+	s.t.Helper()
+	_, err := s.store.Orders.ApplyPaymentUpdate(context.Background(), store.PaymentUpdate{
+		OrderID:   orderID,
+		PaymentID: fmt.Sprintf("pi_fake_%d", orderID),
+		Status:    models.OrderCompleted,
+	})
+	if err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+// order fetches an order straight from the store.
+func (s *testServer) order(orderID int) models.Order {
+	s.t.Helper()
+	order, err := s.store.Orders.Get(context.Background(), orderID)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return order
+}
+
+type testClient struct {
+	t    *testing.T
+	srv  *testServer
+	http *http.Client
+	user models.User
+	// addressID is the delivery address signUp added.
+	addressID int
+}
+
+// do sends a request with a JSON body and optional header name, value pairs.
+func (c *testClient) do(method, path, body string, header ...string) *testResponse {
+	c.t.Helper()
+	req, err := http.NewRequest(method, c.srv.url+path, strings.NewReader(body))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return &testResponse{t: c.t, req: method + " " + path, code: resp.StatusCode, header: resp.Header, body: data}
+}
+
+// checkout opens a checkout for items, given as item ID, quantity pairs, and
+// returns the new order's ID.
+func (c *testClient) checkout(items ...int) int {
+	c.t.Helper()
+	var list []string
+	for i := 0; i+1 < len(items); i += 2 {
+		list = append(list, fmt.Sprintf(`{"id":%d,"quantity":%d}`, items[i], items[i+1]))
+	}
+	res := c.do("POST", "/private/payment/create-checkout-session", fmt.Sprintf(`{"items":[%s],"address_id":%d}`, strings.Join(list, ","), c.addressID))
+	res.wantCode(http.StatusOK)
+	var session struct {
+		OrderID int `json:"orderId"`
+	}
+	res.decode(&session)
+	return session.OrderID
+}
+
+// order fetches one of the client's orders straight from the store.
+func (c *testClient) order(orderID int) models.Order {
+	c.t.Helper()
+	orders, err := c.srv.store.Orders.List(context.Background(), store.OrderFilter{UserID: c.user.Id})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	for _, order := range orders {
+		if order.OrderID == orderID {
+			return order
+		}
+	}
+	c.t.Fatalf("user %d has no order %d", c.user.Id, orderID)
+	return models.Order{}
+}
+
+type testResponse struct {
+	t      *testing.T
+	req    string
+	code   int
+	header http.Header
+	body   []byte
+}
+
+func (r *testResponse) wantCode(code int) *testResponse {
+	r.t.Helper()
+	if r.code != code {
+		r.t.Fatalf("%s: got %d %s, want %d", r.req, r.code, r.body, code)
+	}
+	return r
+}
+
+// wantError checks the status code and the error message, which handlers
+// write as a JSON string and middleware as plain text.
+func (r *testResponse) wantError(code int, message string) {
+	r.t.Helper()
+	r.wantCode(code)
+	if got := r.message(); got != message {
+		r.t.Fatalf("%s: got message %q, want %q", r.req, got, message)
+	}
+}
+
+func (r *testResponse) message() string {
+	return strings.Trim(strings.TrimSpace(string(r.body)), `"`)
+}
+
+func (r *testResponse) decode(v interface{}) {
+	r.t.Helper()
+	if err := json.Unmarshal(r.body, v); err != nil {
+		r.t.Fatalf("%s: decoding %s: %v", r.req, r.body, err)
+	}
+}
+
+// flakyPayments is a payment.Fake whose checkouts, refunds and session
+// expiries fail with the errors set on it.
+type flakyPayments struct {
+	*payment.Fake
+
+	mu        sync.Mutex
+	createErr error
+	refundErr error
+	expireErr error
+}
+
+// failCheckout makes new checkout sessions fail until it is called again
+// with nil.
+func (p *flakyPayments) failCheckout(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.createErr = err
+}
+
+func (p *flakyPayments) CreateSession(ctx context.Context, req payment.SessionRequest) (payment.Session, error) {
+	p.mu.Lock()
+	err := p.createErr
+	p.mu.Unlock()
+	if err != nil {
+		return payment.Session{}, err
+	}
+	return p.Fake.CreateSession(ctx, req)
+}
+
+// fail makes refunds and session expiries fail until it is called again
+// with nil errors.
+func (p *flakyPayments) fail(refundErr, expireErr error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refundErr, p.expireErr = refundErr, expireErr
+}
+
+func (p *flakyPayments) Refund(ctx context.Context, req payment.RefundRequest) (payment.Refund, error) {
+	p.mu.Lock()
+	err := p.refundErr
+	p.mu.Unlock()
+	if err != nil {
+		return payment.Refund{}, err
+	}
+	return p.Fake.Refund(ctx, req)
+}
+
+func (p *flakyPayments) ExpireSession(ctx context.Context, sessionID string) (payment.Session, error) {
+	p.mu.Lock()
+	err := p.expireErr
+	p.mu.Unlock()
+	if err != nil {
+		return payment.Session{}, err
+	}
+	return p.Fake.ExpireSession(ctx, sessionID)
+}
+
+// mailbox keeps the emails the handlers send.
+type mailbox struct {
+	mu   sync.Mutex
+	msgs []mail.Message
+}
+
+func (m *mailbox) Send(ctx context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.msgs = append(m.msgs, msg)
+	return nil
+}
+
+// sent returns the emails sent to to.
+func (m *mailbox) sent(to string) []mail.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var msgs []mail.Message
+	for _, msg := range m.msgs {
+		if msg.To == to {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+// lastToken returns the token of the link in the last email sent to to.
+func (m *mailbox) lastToken(t *testing.T, to string) string {
+	t.Helper()
+	msgs := m.sent(to)
+	if len(msgs) == 0 {
+		t.Fatalf("no email was sent to %s", to)
+	}
+	body := msgs[len(msgs)-1].Body
+	i := strings.Index(body, "token=")
+	if i < 0 {
+		t.Fatalf("no link in email %q", body)
+	}
+	token := body[i+len("token="):]
+	if end := strings.IndexAny(token, "&\r\n "); end >= 0 {
+		token = token[:end]
+	}
+	token, err := url.QueryUnescape(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// outbox keeps the text messages the handlers send.
+type outbox struct {
+	// hold, when set, makes sends wait until it is closed.
+	hold chan struct{}
+
+	mu    sync.Mutex
+	texts []sentText
+}
+
+type sentText struct {
+	phone, text string
+}
+
+func (o *outbox) Send(ctx context.Context, phone, text string) error {
+	if o.hold != nil {
+		<-o.hold
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.texts = append(o.texts, sentText{phone, text})
+	return nil
+}
+
+// sent returns the texts sent to phone.
+func (o *outbox) sent(phone string) []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var texts []string
+	for _, t := range o.texts {
+		if t.phone == phone {
+			texts = append(texts, t.text)
+		}
+	}
+	return texts
+}
+
+// lastCode returns the code in the last text sent to phone.
+func (o *outbox) lastCode(t *testing.T, phone string) string {
+	t.Helper()
+	texts := o.sent(phone)
+	if len(texts) == 0 {
+		t.Fatalf("no text was sent to %s", phone)
+	}
+	code, _, _ := strings.Cut(texts[len(texts)-1], " ")
+	return code
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/events"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/middleware"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+const (
+	// eventHeartbeatInterval keeps idle streams from being closed by
+	// proxies and lets the server notice clients that went away.
+	eventHeartbeatInterval = 15 * time.Second
+	// eventRetry is how long clients wait before reconnecting.
+	eventRetry = 3 * time.Second
+	maxETA     = 24 * time.Hour
+)
+
+// OrderEvents streams an order's status changes, ETA updates and courier
+// location as Server-Sent Events. A client that reconnects with Last-Event-ID
+// receives what it missed; one that cannot be caught up, or connects for the
+// first time, starts with the order's current state.
+func (h *Handler) OrderEvents(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	orderID, ok := orderIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	order, ok := h.visibleOrderOrWriteError(w, r, user, orderID)
+	if !ok {
+		return
+	}
+
+	sub, missed, caughtUp := h.Events.Subscribe(orderID, r.Header.Get("Last-Event-ID"))
+	if sub == nil {
+		WriteError(w, r, http.StatusServiceUnavailable, "Server is shutting down")
+		return
+	}
+	defer sub.Close()
+
+	if !caughtUp {
+		// Reload now that the subscription is live, so a change made
+		// since the first load is either in the snapshot or on sub.C.
+		if order, ok = h.orderOrWriteError(w, r, orderID); !ok {
+			return
+		}
+		missed = []events.Event{{
+			OrderID: orderID,
+			Type:    events.TypeStatus,
+			Data:    models.OrderStatusChange{OrderID: orderID, ToStatus: order.Status, CreatedAt: order.UpdatedAt},
+		}}
+		for _, eventType := range []string{events.TypeETA, events.TypeLocation} {
+			if event, ok := h.Events.Latest(orderID, eventType); ok {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	// The stream outlives the server's write timeout.
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("Error clearing write deadline for order %d events: %v", orderID, err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", eventRetry.Milliseconds())
+	for _, event := range missed {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		log.Printf("Error streaming order %d events: %v", orderID, err)
+		return
+	}
+
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event, open := <-sub.C:
+			if !open {
+				// Dropped for falling behind, or shutting down; the
+				// client reconnects with Last-Event-ID.
+				return
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes event in the text/event-stream format. Events without an
+// ID leave the client's Last-Event-ID as it was.
+func writeEvent(w io.Writer, event events.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	if event.ID != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
+
+// UpdateOrderETA lets the restaurant or courier tell the customer when an
+// order in fulfilment will arrive. The route admits only staff.
+func (h *Handler) UpdateOrderETA(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+	orderID, ok := orderIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		// Minutes from now until the order arrives.
+		Minutes int `json:"eta_minutes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	eta := time.Duration(req.Minutes) * time.Minute
+	if eta <= 0 || eta > maxETA {
+		WriteError(w, r, http.StatusBadRequest, "eta_minutes must be between 1 and 1440")
+		return
+	}
+
+	order, ok := h.visibleOrderOrWriteError(w, r, user, orderID)
+	if !ok {
+		return
+	}
+	if !models.IsOrderInFulfilment(order.Status) {
+		WriteError(w, r, http.StatusConflict, "Order "+order.Status+" is not being fulfilled")
+		return
+	}
+
+	now := time.Now().UTC()
+	update := models.OrderETA{OrderID: orderID, ETA: now.Add(eta), UpdatedBy: user.Id, UpdatedAt: now}
+	h.Events.Publish(orderID, events.TypeETA, update)
+	WriteSuccessMessage(w, r, update)
+}
+
+// UpdateCourierLocation lets the courier share where they are while an order
+// is out for delivery. The route admits only couriers and admins.
+func (h *Handler) UpdateCourierLocation(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+	orderID, ok := orderIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Latitude  *float64 `json:"latitude"`
+		Longitude *float64 `json:"longitude"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.Latitude == nil || req.Longitude == nil ||
+		*req.Latitude < -90 || *req.Latitude > 90 || *req.Longitude < -180 || *req.Longitude > 180 {
+		WriteError(w, r, http.StatusBadRequest, "Invalid latitude or longitude")
+		return
+	}
+
+	order, ok := h.visibleOrderOrWriteError(w, r, user, orderID)
+	if !ok {
+		return
+	}
+	if order.Status != models.OrderOutForDelivery {
+		WriteError(w, r, http.StatusConflict, "Order "+order.Status+" is not out for delivery")
+		return
+	}
+
+	location := models.CourierLocation{
+		OrderID:    orderID,
+		CourierID:  user.Id,
+		Latitude:   *req.Latitude,
+		Longitude:  *req.Longitude,
+		ReportedAt: time.Now().UTC(),
+	}
+	h.Events.Publish(orderID, events.TypeLocation, location)
+	WriteSuccessMessage(w, r, location)
+}
@@ -0,0 +1,191 @@
+package handlers_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+// sseEvent is one event read from an order's stream.
+type sseEvent struct {
+	id, event, data string
+}
+
+type eventStream struct {
+	t      *testing.T
+	resp   *http.Response
+	events chan sseEvent
+}
+
+// events opens orderID's event stream, resuming after lastEventID if set.
+func (c *testClient) events(orderID int, lastEventID string) *eventStream {
+	c.t.Helper()
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/private/orders/%d/events", c.srv.url, orderID), nil)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		c.t.Fatalf("GET events: got %d", resp.StatusCode)
+	}
+
+	s := &eventStream{t: c.t, resp: resp, events: make(chan sseEvent, 16)}
+	go func() {
+		defer close(s.events)
+		var event sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			field, value, _ := strings.Cut(scanner.Text(), ": ")
+			switch field {
+			case "id":
+				event.id = value
+			case "event":
+				event.event = value
+			case "data":
+				event.data = value
+			case "":
+				if event.event != "" {
+					s.events <- event
+				}
+				event = sseEvent{}
+			}
+		}
+	}()
+	return s
+}
+
+// next returns the next event, decoding its data into v.
+func (s *eventStream) next(eventType string, v interface{}) sseEvent {
+	s.t.Helper()
+	select {
+	case event, open := <-s.events:
+		if !open {
+			s.t.Fatal("event stream ended")
+		}
+		if event.event != eventType {
+			s.t.Fatalf("got %s event %s, want %s", event.event, event.data, eventType)
+		}
+		if err := json.Unmarshal([]byte(event.data), v); err != nil {
+			s.t.Fatalf("decoding %s: %v", event.data, err)
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		s.t.Fatalf("no %s event", eventType)
+		return sseEvent{}
+	}
+}
+
+func (s *eventStream) close() {
+	s.resp.Body.Close()
+}
+
+func TestOrderEventsStreamsStatusChanges(t *testing.T) {
+	srv := newTestServer(t)
+	customer := srv.signUp("asha@example.com")
+	restaurant := srv.signUpAs("kitchen@example.com", models.RoleRestaurantOwner, 1)
+	orderID := customer.checkout(10, 1)
+	srv.markPaid(orderID)
+
+	stream := customer.events(orderID, "")
+	var change models.OrderStatusChange
+	if event := stream.next("status", &change); event.id != "" || change.ToStatus != models.OrderCompleted {
+		t.Errorf("first event = %+v, want the current status without an ID", event)
+	}
+
+	path := fmt.Sprintf("/private/orders/%d/status", orderID)
+	restaurant.do("POST", path, `{"status":"accepted"}`).wantCode(http.StatusOK)
+	accepted := stream.next("status", &change)
+	if accepted.id == "" || change.FromStatus != models.OrderCompleted || change.ToStatus != models.OrderAccepted {
+		t.Errorf("got %+v %+v, want completed -> accepted with an ID", accepted, change)
+	}
+
+	restaurant.do("POST", fmt.Sprintf("/private/orders/%d/eta", orderID), `{"eta_minutes":30}`).wantCode(http.StatusOK)
+	var eta models.OrderETA
+	stream.next("eta", &eta)
+	if eta.UpdatedBy != restaurant.user.Id || time.Until(eta.ETA) < 29*time.Minute {
+		t.Errorf("eta = %+v, want in 30 minutes from %d", eta, restaurant.user.Id)
+	}
+	stream.close()
+
+	// A client that reconnects gets what it missed since its last event.
+	restaurant.do("POST", path, `{"status":"preparing"}`).wantCode(http.StatusOK)
+	resumed := customer.events(orderID, accepted.id)
+	resumed.next("eta", &eta)
+	resumed.next("status", &change)
+	if change.ToStatus != models.OrderPreparing {
+		t.Errorf("replayed %+v, want preparing", change)
+	}
+
+	// One that cannot be caught up starts again from the current state.
+	fresh := customer.events(orderID, "unknown-1")
+	fresh.next("status", &change)
+	if change.ToStatus != models.OrderPreparing {
+		t.Errorf("snapshot %+v, want preparing", change)
+	}
+	fresh.next("eta", &eta)
+}
+
+func TestOrderEventsChecksAccess(t *testing.T) {
+	srv := newTestServer(t)
+	asha, ravi := srv.signUp("asha@example.com"), srv.signUp("ravi@example.com")
+	courier := srv.signUpAs("courier@example.com", models.RoleCourier)
+	orderID := asha.checkout(10, 1)
+
+	ravi.do("GET", fmt.Sprintf("/private/orders/%d/events", orderID), "").wantError(http.StatusNotFound, "Order not found")
+	ravi.do("GET", "/private/orders/999/events", "").wantError(http.StatusNotFound, "Order not found")
+	srv.client().do("GET", fmt.Sprintf("/private/orders/%d/events", orderID), "").wantCode(http.StatusUnauthorized)
+
+	var change models.OrderStatusChange
+	courier.events(orderID, "").next("status", &change)
+	if change.ToStatus != models.OrderPending {
+		t.Errorf("courier saw %+v, want pending", change)
+	}
+}
+
+func TestTrackingUpdatesAreLimitedToStaff(t *testing.T) {
+	srv := newTestServer(t)
+	customer := srv.signUp("asha@example.com")
+	restaurant := srv.signUpAs("kitchen@example.com", models.RoleRestaurantOwner, 1)
+	courier := srv.signUpAs("courier@example.com", models.RoleCourier)
+	orderID := customer.checkout(10, 1)
+	eta := fmt.Sprintf("/private/orders/%d/eta", orderID)
+	location := fmt.Sprintf("/private/orders/%d/location", orderID)
+
+	customer.do("POST", eta, `{"eta_minutes":30}`).wantError(http.StatusForbidden, "Forbidden: Insufficient role")
+	restaurant.do("POST", eta, `{"eta_minutes":30}`).wantError(http.StatusConflict, "Order pending is not being fulfilled")
+	restaurant.do("POST", eta, `{"eta_minutes":0}`).wantError(http.StatusBadRequest, "eta_minutes must be between 1 and 1440")
+	restaurant.do("POST", location, `{"latitude":18.5,"longitude":73.8}`).
+		wantError(http.StatusForbidden, "Forbidden: Insufficient role")
+	courier.do("POST", location, `{"latitude":91,"longitude":73.8}`).wantError(http.StatusBadRequest, "Invalid latitude or longitude")
+	courier.do("POST", location, `{"latitude":18.5,"longitude":73.8}`).
+		wantError(http.StatusConflict, "Order pending is not out for delivery")
+
+	srv.markPaid(orderID)
+	status := fmt.Sprintf("/private/orders/%d/status", orderID)
+	restaurant.do("POST", status, `{"status":"accepted"}`).wantCode(http.StatusOK)
+	restaurant.do("POST", status, `{"status":"preparing"}`).wantCode(http.StatusOK)
+	courier.do("POST", status, `{"status":"out_for_delivery"}`).wantCode(http.StatusOK)
+
+	stream := customer.events(orderID, "")
+	courier.do("POST", location, `{"latitude":18.5,"longitude":73.8}`).wantCode(http.StatusOK)
+	var change models.OrderStatusChange
+	stream.next("status", &change)
+	var got models.CourierLocation
+	stream.next("location", &got)
+	if got.CourierID != courier.user.Id || got.Latitude != 18.5 || got.Longitude != 73.8 {
+		t.Errorf("location = %+v", got)
+	}
+}
@@ -1,37 +1,24 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
-	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
-	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 
-	"github.com/stripe/stripe-go/v81"
-	"github.com/stripe/stripe-go/v81/checkout/session"
-
-	db "github.com/vishal-sharma-001/FoodHaven-Backend/database"
 	"github.com/vishal-sharma-001/FoodHaven-Backend/middleware"
 	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/payment"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
 )
 
-const stripeSecretKey = "sk_test_51QTm8eLhrle3XiFesp5JSKKB0oMcGiRjpYSPlrt9FJ9RZjn3WpvW71HypVJfdhYNPOw5KjFy13JFK4q4ICPy4LqB00YHCpQVT6"
-const domain = "https://foodhaven.run.place"
-
-func init() {
-	stripe.Key = stripeSecretKey
-}
-
-func HandleSignUp(w http.ResponseWriter, r *http.Request, store *sessions.CookieStore) {
-	setupResponse(&w)
+func (h *Handler) HandleSignUp(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -47,6 +34,10 @@ func HandleSignUp(w http.ResponseWriter, r *http.Request, store *sessions.Cookie
 		WriteError(w, r, http.StatusBadRequest, "All fields are required")
 		return
 	}
+	if err := h.validatePassword(user.Password, user.Email); err != nil {
+		WriteError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -54,41 +45,38 @@ func HandleSignUp(w http.ResponseWriter, r *http.Request, store *sessions.Cookie
 		return
 	}
 
-	dbClient, err := db.ConnectDB()
-	if err != nil {
-		WriteError(w, r, http.StatusInternalServerError, "Database connection error")
+	user.Password = string(hashedPassword)
+	if err := h.Store.Users.Create(r.Context(), &user); err != nil {
+		switch {
+		case errors.Is(err, store.ErrEmailTaken):
+			WriteError(w, r, http.StatusConflict, "Email is already registered")
+		case errors.Is(err, store.ErrPhoneTaken):
+			WriteError(w, r, http.StatusConflict, "Phone number is already registered")
+		default:
+			log.Printf("Error registering user: %v", err)
+			WriteError(w, r, http.StatusInternalServerError, "Failed to register user")
+		}
 		return
 	}
-	defer dbClient.Close()
+	user.Password = ""
 
-	var userID int
-	query := "INSERT INTO users (name, email, phone, password) VALUES ($1, $2, $3, $4) RETURNING id"
-	err = dbClient.QueryRow(query, user.Name, user.Email, user.Phone, hashedPassword).Scan(&userID)
-	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
-			if strings.Contains(pqErr.Message, "users_email_key") {
-				WriteError(w, r, http.StatusConflict, "Email is already registered")
-			} else if strings.Contains(pqErr.Message, "users_phone_key") {
-				WriteError(w, r, http.StatusConflict, "Phone number is already registered")
-			} else {
-				WriteError(w, r, http.StatusInternalServerError, "Database error")
-			}
-			return
-		}
-		WriteError(w, r, http.StatusInternalServerError, "Failed to register user")
-		return
+	// Signing up does not wait for the email to be verified; the user can
+	// ask for another link if this one does not arrive.
+	if err := h.sendVerificationEmail(r.Context(), user); err != nil {
+		log.Printf("Error sending verification email to user %d: %v", user.Id, err)
 	}
 
-	session, err := store.Get(r, "user_session")
+	session, err := h.Sessions.Get(r, "user_session")
 	if err != nil {
 		WriteError(w, r, http.StatusInternalServerError, "Failed to create session")
 		return
 	}
 
-	session.Values["userId"] = userID
+	session.Values["userId"] = user.Id
 	session.Values["email"] = user.Email
 	session.Values["name"] = user.Name
 	session.Values["phone"] = user.Phone
+	session.Values["sessionVersion"] = user.SessionVersion
 
 	session.Options = &sessions.Options{
 		Path:     "/",
@@ -103,12 +91,11 @@ func HandleSignUp(w http.ResponseWriter, r *http.Request, store *sessions.Cookie
 		return
 	}
 
-	user.Id = userID
 	WriteSuccessMessage(w, r, user)
 }
 
-func HandleLogIn(w http.ResponseWriter, r *http.Request, store *sessions.CookieStore) {
-	setupResponse(&w)
+func (h *Handler) HandleLogIn(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -123,26 +110,46 @@ func HandleLogIn(w http.ResponseWriter, r *http.Request, store *sessions.CookieS
 		return
 	}
 
-	dbClient, err := db.ConnectDB()
+	ip := middleware.ClientIP(r, h.Config.Server.TrustProxy)
+	wait, err := h.loginWait(r.Context(), credentials.Email, ip)
 	if err != nil {
-		WriteError(w, r, http.StatusInternalServerError, "Database connection error")
+		log.Printf("Error checking login failures for %q from %s: %v", credentials.Email, ip, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to log in")
+		return
+	}
+	if wait > 0 {
+		h.recordLogin(r.Context(), credentials.Email, ip, 0, models.LoginThrottled)
+		setRetryAfter(w, wait)
+		WriteError(w, r, http.StatusTooManyRequests, "Too many failed login attempts; try again later")
 		return
 	}
-	defer dbClient.Close()
 
-	var user models.User
-	err = dbClient.QueryRow("SELECT id, name, email, phone, password FROM users WHERE email = $1", credentials.Email).Scan(&user.Id, &user.Name, &user.Email, &user.Phone, &user.Password)
+	user, err := h.Store.Users.GetByEmail(r.Context(), credentials.Email)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		log.Printf("Error looking up user for login: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to log in")
+		return
+	}
 	if err != nil {
+		h.recordLogin(r.Context(), credentials.Email, ip, 0, models.LoginFailed)
 		WriteError(w, r, http.StatusUnauthorized, "Invalid email or password")
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(credentials.Password)); err != nil {
+		h.recordLogin(r.Context(), credentials.Email, ip, user.Id, models.LoginFailed)
 		WriteError(w, r, http.StatusUnauthorized, "Invalid email or password")
 		return
 	}
+	h.recordLogin(r.Context(), credentials.Email, ip, user.Id, models.LoginSucceeded)
+
+	h.logIn(w, r, user)
+}
 
-	session, err := store.Get(r, "user_session")
+// logIn starts a session for user and answers the request with their
+// profile. It is shared by every way of logging in.
+func (h *Handler) logIn(w http.ResponseWriter, r *http.Request, user models.User) {
+	session, err := h.Sessions.Get(r, "user_session")
 	if err != nil {
 		WriteError(w, r, http.StatusInternalServerError, "Failed to create session")
 		return
@@ -152,6 +159,7 @@ func HandleLogIn(w http.ResponseWriter, r *http.Request, store *sessions.CookieS
 	session.Values["email"] = user.Email
 	session.Values["name"] = user.Name
 	session.Values["phone"] = user.Phone
+	session.Values["sessionVersion"] = user.SessionVersion
 
 	session.Options = &sessions.Options{
 		Path:     "/",
@@ -161,35 +169,35 @@ func HandleLogIn(w http.ResponseWriter, r *http.Request, store *sessions.CookieS
 		SameSite: http.SameSiteLaxMode, // Allows cross-origin cookies
 	}
 
-	log.Printf("------->Session Values: %v", session.Values)
-
 	if err := session.Save(r, w); err != nil {
 		WriteError(w, r, http.StatusInternalServerError, "Failed to save session")
 		return
 	}
 
+	user.Password = ""
 	WriteSuccessMessage(w, r, user)
 }
 
-func HandleGetUser(w http.ResponseWriter, r *http.Request) {
-	setupResponse(&w)
+func (h *Handler) HandleGetUser(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
 
 	user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
 	if !ok {
 		http.Error(w, "User not found in context", http.StatusUnauthorized)
 		return
 	}
+	user.Password = ""
 	WriteSuccessMessage(w, r, user)
 }
 
-func HandleLogOut(w http.ResponseWriter, r *http.Request, store *sessions.CookieStore) {
-	setupResponse(&w)
+func (h *Handler) HandleLogOut(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	session, err := store.Get(r, "user_session")
+	session, err := h.Sessions.Get(r, "user_session")
 	if err != nil {
 		WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve session")
 		return
@@ -206,8 +214,8 @@ func HandleLogOut(w http.ResponseWriter, r *http.Request, store *sessions.Cookie
 	WriteSuccessMessage(w, r, "Logged out successfully")
 }
 
-func HandleEditUser(w http.ResponseWriter, r *http.Request, store *sessions.CookieStore) {
-	setupResponse(&w)
+func (h *Handler) HandleEditUser(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -230,27 +238,22 @@ func HandleEditUser(w http.ResponseWriter, r *http.Request, store *sessions.Cook
 		return
 	}
 
-	dbClient, err := db.ConnectDB()
-	if err != nil {
-		WriteError(w, r, http.StatusInternalServerError, "Database connection error")
-		return
-	}
-	defer dbClient.Close()
-
-	query := `
-		UPDATE users 
-		SET name = $1, email = $2, phone = $3 
-		WHERE id = $4 
-		RETURNING id
-	`
-	err = dbClient.QueryRow(query, updatedUser.Name, updatedUser.Email, updatedUser.Phone, user.Id).
-		Scan(&updatedUser.Id)
-	if err != nil {
-		WriteError(w, r, http.StatusInternalServerError, "Failed to update user information")
+	updatedUser.Id = user.Id
+	updatedUser.Role = user.Role
+	updatedUser.EmailVerified = user.EmailVerified && updatedUser.Email == user.Email
+	if err := h.Store.Users.UpdateProfile(r.Context(), updatedUser); err != nil {
+		switch {
+		case errors.Is(err, store.ErrEmailTaken):
+			WriteError(w, r, http.StatusConflict, "Email is already registered")
+		case errors.Is(err, store.ErrPhoneTaken):
+			WriteError(w, r, http.StatusConflict, "Phone number is already registered")
+		default:
+			WriteError(w, r, http.StatusInternalServerError, "Failed to update user information")
+		}
 		return
 	}
 
-	session, err := store.Get(r, "user_session")
+	session, err := h.Sessions.Get(r, "user_session")
 	if err != nil {
 		WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve session")
 		return
@@ -265,11 +268,18 @@ func HandleEditUser(w http.ResponseWriter, r *http.Request, store *sessions.Cook
 		return
 	}
 
+	if updatedUser.Email != user.Email {
+		if err := h.sendVerificationEmail(r.Context(), updatedUser); err != nil {
+			log.Printf("Error sending verification email to user %d: %v", user.Id, err)
+		}
+	}
+
+	updatedUser.Password = ""
 	WriteSuccessMessage(w, r, updatedUser)
 }
 
-func GetUserAddresses(w http.ResponseWriter, r *http.Request) {
-	setupResponse(&w)
+func (h *Handler) GetUserAddresses(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
 
 	user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
 	if !ok {
@@ -277,43 +287,18 @@ func GetUserAddresses(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dbClient, err := db.ConnectDB()
-	if err != nil {
-		WriteError(w, r, http.StatusInternalServerError, "Database connection error")
-		return
-	}
-	defer dbClient.Close()
-
-	rows, err := dbClient.Query(`SELECT id, user_id, name, street, city, postal_code, phone, is_primary FROM addresses WHERE user_id = $1`, user.Id)
-
+	addresses, err := h.Store.Addresses.List(r.Context(), user.Id)
 	if err != nil {
+		log.Printf("Error fetching addresses: %v", err)
 		WriteError(w, r, http.StatusInternalServerError, "Database query error")
 		return
 	}
-	defer rows.Close()
-
-	var addresses []models.Address
-
-	for rows.Next() {
-		var address models.Address
-		err := rows.Scan(&address.ID, &address.UserID, &address.Name, &address.Street, &address.City, &address.PostalCode, &address.Phone, &address.IsPrimary)
-		if err != nil {
-			WriteError(w, r, http.StatusInternalServerError, "Error scanning row")
-			return
-		}
-		addresses = append(addresses, address)
-	}
-
-	if err := rows.Err(); err != nil {
-		WriteError(w, r, http.StatusInternalServerError, "Error reading rows")
-		return
-	}
 
 	WriteSuccessMessage(w, r, addresses)
 }
 
-func HandleAddAddress(w http.ResponseWriter, r *http.Request) {
-	setupResponse(&w)
+func (h *Handler) HandleAddAddress(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -336,28 +321,23 @@ func HandleAddAddress(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dbClient, err := db.ConnectDB()
-	if err != nil {
-		WriteError(w, r, http.StatusInternalServerError, "Database connection error")
+	if err := validateAddress(&address); err != nil {
+		WriteError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
-	defer dbClient.Close()
 
-	err = dbClient.QueryRow(`
-		INSERT INTO addresses (user_id, name, street, city, postal_code, phone, is_primary)
-		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
-		user.Id, address.Name, address.Street, address.City, address.PostalCode, address.Phone, address.IsPrimary).Scan(&address.ID)
-	if err != nil {
+	address.UserID = user.Id
+	if err := h.Store.Addresses.Create(r.Context(), &address); err != nil {
+		log.Printf("Error saving address: %v", err)
 		WriteError(w, r, http.StatusInternalServerError, "Failed to save address")
 		return
 	}
 
-	address.UserID = user.Id
 	WriteSuccessMessage(w, r, address)
 }
 
-func HandleEditAddress(w http.ResponseWriter, r *http.Request) {
-	setupResponse(&w)
+func (h *Handler) HandleEditAddress(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -393,41 +373,29 @@ func HandleEditAddress(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	address.ID = addressID
-
-	dbClient, err := db.ConnectDB()
-	if err != nil {
-		WriteError(w, r, http.StatusInternalServerError, "Database connection error")
-		return
-	}
-	defer dbClient.Close()
-
-	query := `
-		UPDATE addresses 
-		SET name = $1, street = $2, city = $3, postal_code = $4, phone = $5, is_primary = $6 
-		WHERE id = $7 AND user_id = $8`
-	result, err := dbClient.Exec(query, address.Name, address.Street, address.City, address.PostalCode, address.Phone, address.IsPrimary, address.ID, user.Id)
-	if err != nil {
-		WriteError(w, r, http.StatusInternalServerError, "Failed to update address")
+	if err := validateAddress(&address); err != nil {
+		WriteError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	affectedRows, err := result.RowsAffected()
-	if err != nil {
-		WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve rows affected")
-		return
-	}
+	address.ID = addressID
+	address.UserID = user.Id
 
-	if affectedRows == 0 {
-		WriteError(w, r, http.StatusNotFound, "Address not found or not authorized")
+	if err := h.Store.Addresses.Update(r.Context(), &address); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			WriteError(w, r, http.StatusNotFound, "Address not found or not authorized")
+			return
+		}
+		log.Printf("Error updating address %d: %v", addressID, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to update address")
 		return
 	}
 
 	WriteSuccessMessage(w, r, address)
 }
 
-func HandleDeleteAddress(w http.ResponseWriter, r *http.Request) {
-	setupResponse(&w)
+func (h *Handler) HandleDeleteAddress(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
 
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
@@ -458,35 +426,20 @@ func HandleDeleteAddress(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dbClient, err := db.ConnectDB()
-	if err != nil {
-		WriteError(w, r, http.StatusInternalServerError, "Database connection error")
-		return
-	}
-	defer dbClient.Close()
-
-	query := `DELETE FROM addresses WHERE id = $1 AND user_id = $2`
-	result, err := dbClient.Exec(query, addressID, user.Id)
-	if err != nil {
+	if err := h.Store.Addresses.Delete(r.Context(), user.Id, addressID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			WriteError(w, r, http.StatusNotFound, "Address not found or not authorized")
+			return
+		}
+		log.Printf("Error deleting address %d: %v", addressID, err)
 		WriteError(w, r, http.StatusInternalServerError, "Failed to delete address")
 		return
 	}
 
-	affectedRows, err := result.RowsAffected()
-	if err != nil {
-		WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve rows affected")
-		return
-	}
-
-	if affectedRows == 0 {
-		WriteError(w, r, http.StatusNotFound, "Address not found or not authorized")
-		return
-	}
-
 	WriteSuccessMessage(w, r, map[string]string{"message": "Address deleted successfully"})
 }
 
-func FetchCart(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) FetchCart(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -504,93 +457,41 @@ func FetchCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connect to the database
-	db, err := db.ConnectDB()
-	if err != nil {
-		WriteError(w, r, http.StatusInternalServerError, "Database connection error")
-		return
-	}
-	defer db.Close()
-
-	var cartID int
-	var restaurantID sql.NullInt64 // to handle nullable restaurant_id
-
-	// Fetch the cart ID and restaurant_id (if available)
-	err = db.QueryRow("SELECT id, restaurant_id FROM cart WHERE user_id = $1 AND is_active = TRUE", user.Id).Scan(&cartID, &restaurantID)
+	cart, err := h.Store.Carts.GetActive(r.Context(), user.Id)
+	if errors.Is(err, store.ErrNotFound) {
+		// No active cart found, create a new one with a default restaurant_id (NULL allowed)
+		log.Printf("No active cart found, creating a new one")
 
-	if err != nil {
-		if err == sql.ErrNoRows {
-			// No active cart found, create a new one with a default restaurant_id (NULL allowed)
-			log.Printf("No active cart found, creating a new one")
-
-			err = db.QueryRow("INSERT INTO cart (user_id, total_amount, is_active) VALUES ($1, 0, TRUE) RETURNING id", user.Id).Scan(&cartID)
-
-			if err != nil {
-				WriteError(w, r, http.StatusInternalServerError, "Failed to create new cart")
-				return
-			}
-
-			// Return the new cart with no items
-			response := map[string]interface{}{
-				"cart_id": cartID,
-				"items":   []models.OrderItem{},
-			}
-			WriteSuccessMessage(w, r, response)
+		cart, err = h.Store.Carts.Create(r.Context(), user.Id)
+		if err != nil {
+			WriteError(w, r, http.StatusInternalServerError, "Failed to create new cart")
 			return
 		}
 
-		// Handle other database errors
-		WriteError(w, r, http.StatusInternalServerError, err.Error())
+		// Return the new cart with no items
+		response := map[string]interface{}{
+			"cart_id": cart.ID,
+			"items":   cart.Items,
+		}
+		WriteSuccessMessage(w, r, response)
 		return
 	}
-
-	// Fetch items for the active cart
-	rows, err := db.Query(`
-		SELECT 
-			ci.item_id, 
-			fi.name, 
-			ci.quantity,
-			fi.price::numeric, -- Cast price to NUMERIC to avoid type issues
-			fi.cloudimageid
-		FROM cart_items ci
-		JOIN FoodItems fi ON ci.item_id = fi.id 
-		WHERE ci.cart_id = $1`, cartID)
-
 	if err != nil {
 		WriteError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
-	defer rows.Close()
-
-	items := []models.OrderItem{}
-	for rows.Next() {
-		var item models.OrderItem
-
-		// Scan cart item details
-		if err := rows.Scan(&item.ID, &item.Name, &item.Quantity, &item.Price, &item.CloudImageID); err != nil {
-			WriteError(w, r, http.StatusInternalServerError, err)
-			return
-		}
-
-		// If restaurant_id is available in the cart, set it for the item
-		if restaurantID.Valid {
-			item.RestaurantID = int(restaurantID.Int64)
-		}
-
-		items = append(items, item)
-	}
 
 	// Return the active cart with items
 	response := map[string]interface{}{
-		"cart_id": cartID,
-		"items":   items,
-		"restaurantid": int(restaurantID.Int64),
+		"cart_id":      cart.ID,
+		"items":        cart.Items,
+		"restaurantid": cart.RestaurantID,
 	}
 
 	WriteSuccessMessage(w, r, response)
 }
 
-func SyncCart(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) SyncCart(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -609,7 +510,6 @@ func SyncCart(w http.ResponseWriter, r *http.Request) {
 		WriteError(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
-	newItems := payload.Items
 
 	// Extract cart_id from URL
 	vars := mux.Vars(r)
@@ -626,127 +526,26 @@ func SyncCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connect to the database
-	db, err := db.ConnectDB()
-	if err != nil {
-		WriteError(w, r, http.StatusInternalServerError, "Database connection error")
-		return
-	}
-	defer db.Close()
-
-	// Start a transaction
-	tx, err := db.Begin()
-	if err != nil {
-		WriteError(w, r, http.StatusInternalServerError, "Failed to start transaction")
-		return
-	}
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
-			log.Printf("Transaction rollback due to error: %v", err)
-		} else {
-			if commitErr := tx.Commit(); commitErr != nil {
-				log.Printf("Transaction commit failed: %v", commitErr)
-			}
-		}
-	}()
-
-	// Verify that the cart belongs to the user and is active
-	var dbUserID int
-	err = tx.QueryRow("SELECT user_id FROM cart WHERE id = $1 AND is_active = TRUE", cartID).Scan(&dbUserID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			WriteError(w, r, http.StatusNotFound, "Cart not found or inactive")
-		} else {
-			WriteError(w, r, http.StatusInternalServerError, "Failed to fetch cart")
-		}
-		return
-	}
-	if dbUserID != user.Id {
-		WriteError(w, r, http.StatusForbidden, "Unauthorized to modify this cart")
-		return
-	}
-
-	// Fetch existing items from the database
-	existingItems := make(map[int]models.OrderItem)
-	rows, err := tx.Query("SELECT item_id, quantity FROM cart_items WHERE cart_id = $1", cartID)
-	if err != nil {
-		WriteError(w, r, http.StatusInternalServerError, "Failed to fetch existing cart items")
-		return
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var item models.OrderItem
-		if err := rows.Scan(&item.ID, &item.Quantity); err != nil {
-			WriteError(w, r, http.StatusInternalServerError, "Failed to parse existing cart items")
-			return
-		}
-		existingItems[item.ID] = item
-	}
-
-	// Synchronize items
-	totalAmount := 0.0
 	var restaurantID int
-	updatedItems := make(map[int]bool)
-
-	for _, newItem := range newItems {
+	for _, item := range payload.Items {
 		if restaurantID == 0 {
-			restaurantID = newItem.RestaurantID
-		} else if restaurantID != newItem.RestaurantID {
+			restaurantID = item.RestaurantID
+		} else if restaurantID != item.RestaurantID {
 			WriteError(w, r, http.StatusBadRequest, "All items must be from the same restaurant")
 			return
 		}
-
-		existingItem, exists := existingItems[newItem.ID]
-		if exists {
-			// Update the quantity if it differs
-			if existingItem.Quantity != newItem.Quantity {
-				_, err = tx.Exec(
-					"UPDATE cart_items SET quantity = $1, updated_at = NOW() WHERE cart_id = $2 AND item_id = $3",
-					newItem.Quantity, cartID, newItem.ID,
-				)
-				if err != nil {
-					WriteError(w, r, http.StatusInternalServerError, "Failed to update cart item")
-					return
-				}
-			}
-		} else {
-			// Insert new item
-			_, err = tx.Exec(
-				"INSERT INTO cart_items (cart_id, item_id, quantity, created_at, updated_at) VALUES ($1, $2, $3, NOW(), NOW())",
-				cartID, newItem.ID, newItem.Quantity,
-			)
-			if err != nil {
-				WriteError(w, r, http.StatusInternalServerError, "Failed to insert cart item")
-				return
-			}
-		}
-		totalAmount += float64(newItem.Quantity) * float64(newItem.Price)
-		updatedItems[newItem.ID] = true
-	}
-
-	// Remove items that are no longer in the cart
-	for existingID := range existingItems {
-		if !updatedItems[existingID] {
-			_, err = tx.Exec("DELETE FROM cart_items WHERE cart_id = $1 AND item_id = $2", cartID, existingID)
-			if err != nil {
-				WriteError(w, r, http.StatusInternalServerError, "Failed to delete old cart item")
-				return
-			}
-		}
 	}
 
-	// Update the cart with the total amount and restaurant ID
-	_, err = tx.Exec(
-		"UPDATE cart SET total_amount = $1, restaurant_id = $2, updated_at = NOW() WHERE id = $3",
-		totalAmount, restaurantID, cartID,
-	)
-	if err != nil {
-		WriteError(w, r, http.StatusInternalServerError, "Failed to update cart")
+	if err := h.Store.Carts.Sync(r.Context(), user.Id, cartID, payload.Items); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			WriteError(w, r, http.StatusNotFound, "Cart not found or inactive")
+		case errors.Is(err, store.ErrForbidden):
+			WriteError(w, r, http.StatusForbidden, "Unauthorized to modify this cart")
+		default:
+			log.Printf("Error syncing cart %d: %v", cartID, err)
+			WriteError(w, r, http.StatusInternalServerError, "Failed to update cart")
+		}
 		return
 	}
 
@@ -754,8 +553,8 @@ func SyncCart(w http.ResponseWriter, r *http.Request) {
 	WriteSuccessMessage(w, r, "Sync Successful")
 }
 
-func CreateCheckoutSession(w http.ResponseWriter, r *http.Request) {
-	setupResponse(&w)
+func (h *Handler) CreateCheckoutSession(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
 
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
@@ -781,160 +580,42 @@ func CreateCheckoutSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	lineItems := []*stripe.CheckoutSessionLineItemParams{}
-	for _, item := range req.Items {
-		lineItems = append(lineItems, &stripe.CheckoutSessionLineItemParams{
-			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
-				Currency: stripe.String("inr"),
-				ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
-					Name:        stripe.String(item.Name),
-					Description: stripe.String(fmt.Sprintf("Item from Restaurant ID: %d", item.RestaurantID)),
-					Images:      []*string{stripe.String(fmt.Sprintf("https://storage.cloud.google.com/foodhaven_bucket/Images/%s", item.CloudImageID))},
-				},
-				UnitAmount: stripe.Int64(int64(item.Price * 100)),
-			},
-			Quantity: stripe.Int64(int64(item.Quantity)),
-		})
-	}
-
-	params := &stripe.CheckoutSessionParams{
-		UIMode:    stripe.String("embedded"),
-		ReturnURL: stripe.String(domain + "/return?session_id={CHECKOUT_SESSION_ID}"),
-		LineItems: lineItems,
-		Mode:      stripe.String(string(stripe.CheckoutSessionModePayment)),
-	}
-
-	s, err := session.New(params)
-	if err != nil {
-		log.Printf("Error creating Stripe session: %v", err)
-		WriteError(w, r, http.StatusInternalServerError, "Failed to create checkout session")
+	if !h.verifiedOrWriteError(w, r, user) {
 		return
 	}
-
-	dbClient, err := db.ConnectDB()
-	if err != nil {
-		log.Printf("Database connection error: %v", err)
-		WriteError(w, r, http.StatusInternalServerError, "Database connection error")
+	address, ok := h.deliveryAddressOrWriteError(w, r, user.Id, req.AddressID)
+	if !ok {
 		return
 	}
-	defer dbClient.Close()
 
-	// Start a transaction
-	tx, err := dbClient.Begin()
-	if err != nil {
-		log.Printf("Failed to start database transaction: %v", err)
-		WriteError(w, r, http.StatusInternalServerError, "Transaction initialization error")
-		return
-	}
-
-	// Use defer to handle transaction commit/rollback
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			log.Printf("Panic occurred: %v. Transaction rolled back.", p)
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
-			log.Printf("Transaction rolled back due to error: %v", err)
-		} else {
-			if commitErr := tx.Commit(); commitErr != nil {
-				log.Printf("Failed to commit transaction: %v", commitErr)
-				WriteError(w, r, http.StatusInternalServerError, "Transaction commit error")
-			}
-		}
-	}()
-     
-	log.Printf("Request: %+v", req)
-	// Insert into orders table (payment_id is NULL)
-	var orderID int
-	err = tx.QueryRow(`
-		INSERT INTO orders (user_id, session_id, total_amount, currency, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, NOW(), NOW()) RETURNING order_id`,
-		user.Id, s.ID, req.Amount, "INR", "pending",
-	).Scan(&orderID)
-	if err != nil {
-		log.Printf("Error inserting order: %v", err)
-		WriteError(w, r, http.StatusInternalServerError, "Failed to save order")
+	// Prices come from the menu; the client's prices and amount are ignored.
+	priced, ok := h.priceOrWriteError(w, r, user.Id, req.Items)
+	if !ok {
 		return
 	}
-
-	// Insert items into order_items table
-	for _, item := range req.Items {
-		_, err = tx.Exec(`
-			INSERT INTO order_items (order_id, item_id, quantity, price, created_at)
-			VALUES ($1, $2, $3, $4, NOW())`,
-			orderID, item.ID, item.Quantity, item.Price,
-		)
-		if err != nil {
-			log.Printf("Error inserting order items for order ID %d: %v", orderID, err)
-			WriteError(w, r, http.StatusInternalServerError, "Failed to save order items")
-			return
-		}
-	}
-
-	// Return response
-	response := struct {
-		ClientSecret string `json:"clientSecret"`
-		OrderID      int    `json:"orderId"`
-	}{
-		ClientSecret: s.ClientSecret,
-		OrderID:      orderID,
+	if req.Amount != 0 && toPaise(float64(req.Amount)) != priced.Total {
+		log.Printf("Checkout for user %d: client amount %d differs from computed total %.2f", user.Id, req.Amount, priced.TotalAmount())
 	}
 
-	WriteSuccessMessage(w, r, response)
+	h.startCheckout(w, r, user.Id, 0, address, priced)
 }
 
+// RetrieveCheckoutSession reports the outcome of a checkout to the page the
+// customer returns to. It only reads: the payment webhook is what moves the
+// order and deactivates the cart.
+func (h *Handler) RetrieveCheckoutSession(w http.ResponseWriter, r *http.Request) {
+	h.setupResponse(&w)
+
+	if r.Method != http.MethodGet {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
 
-func RetrieveCheckoutSession(w http.ResponseWriter, r *http.Request) {
-    setupResponse(&w)
-
-    if r.Method != http.MethodGet {
-        WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
-        return
-    }
-
-    sessionID := r.URL.Query().Get("session_id")
-    if sessionID == "" {
-        WriteError(w, r, http.StatusBadRequest, "Missing session_id")
-        return
-    }
-
-    s, err := session.Get(sessionID, nil)
-    if err != nil {
-        log.Printf("Error retrieving checkout session: %v", err)
-        WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve checkout session")
-        return
-    }
-
-    dbClient, err := db.ConnectDB()
-    if err != nil {
-        log.Printf("Database connection error: %v", err)
-        WriteError(w, r, http.StatusInternalServerError, "Database connection error")
-        return
-    }
-    defer dbClient.Close()
-
-    // Determine the status to update in the orders table
-    var orderStatus string
-    switch s.Status {
-    case stripe.CheckoutSessionStatusComplete:
-        orderStatus = "completed"
-    case stripe.CheckoutSessionStatusExpired:
-        orderStatus = "expired"
-    default:
-        orderStatus = "failed"
-    }
-
-    // Update the order in the database
-    _, err = dbClient.Exec(
-        `UPDATE orders SET status = $1, payment_id = $2, updated_at = NOW() WHERE session_id = $3`,
-        orderStatus, s.ID, sessionID,
-    )
-    if err != nil {
-        log.Printf("Failed to update order for session %s: %v", sessionID, err)
-        WriteError(w, r, http.StatusInternalServerError, "Failed to update order status")
-        return
-    }
+	sessionID := r.URL.Query().Get("session_id")
+	if sessionID == "" {
+		WriteError(w, r, http.StatusBadRequest, "Missing session_id")
+		return
+	}
 
 	// Extract authenticated user
 	user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
@@ -943,127 +624,42 @@ func RetrieveCheckoutSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-    // Update the cart to set is_active = false
-    _, err = dbClient.Exec(`UPDATE cart SET is_active = false WHERE user_id = $1`, user.Id)
-    if err != nil {
-        log.Printf("Failed to update cart for user %v", err)
-        WriteError(w, r, http.StatusInternalServerError, "Failed to update cart status")
-        return
-    }
-
-    // Prepare the response
-    response := struct {
-        Status        string `json:"status"`
-        CustomerEmail string `json:"customer_email"`
-    }{
-        Status:        string(s.Status),
-        CustomerEmail: string(s.CustomerDetails.Email),
-    }
+	// Other customers' sessions are reported as unknown.
+	orders, err := h.Store.Orders.List(r.Context(), store.OrderFilter{UserID: user.Id, SessionID: sessionID, Limit: 1})
+	if err != nil {
+		log.Printf("Error fetching order for session %s: %v", sessionID, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve checkout session")
+		return
+	}
+	if len(orders) == 0 {
+		WriteError(w, r, http.StatusNotFound, "Checkout session not found")
+		return
+	}
+	order := orders[0]
 
-    WriteSuccessMessage(w, r, response)
-}
+	s, err := h.Payments.GetSession(r.Context(), sessionID)
+	if errors.Is(err, payment.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, "Checkout session not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error retrieving checkout session: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve checkout session")
+		return
+	}
 
+	// Prepare the response
+	response := struct {
+		Status        string `json:"status"`
+		OrderID       int    `json:"order_id"`
+		OrderStatus   string `json:"order_status"`
+		CustomerEmail string `json:"customer_email"`
+	}{
+		Status:        string(s.Status),
+		OrderID:       order.OrderID,
+		OrderStatus:   order.Status,
+		CustomerEmail: s.CustomerEmail,
+	}
 
-func FetchOrders(w http.ResponseWriter, r *http.Request) {
-    setupResponse(&w)
-
-    if r.Method != http.MethodGet {
-        WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
-        return
-    }
-
-    // Retrieve authenticated user from context
-    user, ok := r.Context().Value(middleware.ContextKeyUser).(models.User)
-    if !ok {
-        WriteError(w, r, http.StatusUnauthorized, "User not found in context")
-        return
-    }
-
-    dbClient, err := db.ConnectDB()
-    if err != nil {
-        WriteError(w, r, http.StatusInternalServerError, "Database connection error")
-        return
-    }
-    defer dbClient.Close()
-
-    // Query to fetch orders with aggregated order items and food item details
-    rows, err := dbClient.Query(`
-        SELECT 
-          o.order_id, 
-          o.total_amount, 
-          o.currency, 
-          o.status, 
-          o.payment_id, 
-          o.created_at, 
-          o.updated_at, 
-          json_agg(json_build_object(
-            'item_id', oi.item_id, 
-            'quantity', oi.quantity, 
-            'price', oi.price, 
-            'name', fi.name, 
-            'cloudimageid', fi.cloudimageid
-          )) AS items
-        FROM orders o
-        LEFT JOIN order_items oi ON o.order_id = oi.order_id
-        LEFT JOIN FoodItems fi ON oi.item_id = fi.id
-        WHERE o.user_id = $1
-        GROUP BY o.order_id
-        ORDER BY o.created_at DESC`, user.Id)
-
-    if err != nil {
-        log.Printf("Error fetching orders: %v", err)
-        WriteError(w, r, http.StatusInternalServerError, "Failed to fetch orders")
-        return
-    }
-    defer rows.Close()
-
-    orders := []map[string]interface{}{}
-
-    // Parse rows to build response
-    for rows.Next() {
-        var orderID int
-        var totalAmount float64
-        var currency, status, paymentID sql.NullString
-        var createdAt, updatedAt time.Time
-        var itemsJSON sql.NullString
-
-        if err := rows.Scan(&orderID, &totalAmount, &currency, &status, &paymentID, &createdAt, &updatedAt, &itemsJSON); err != nil {
-            log.Printf("Error scanning order row: %v", err)
-            WriteError(w, r, http.StatusInternalServerError, "Failed to parse order data")
-            return
-        }
-
-        // Parse items JSON if not null
-        var items []map[string]interface{}
-        if itemsJSON.Valid {
-            if err := json.Unmarshal([]byte(itemsJSON.String), &items); err != nil {
-                log.Printf("Error unmarshaling items JSON: %v", err)
-                WriteError(w, r, http.StatusInternalServerError, "Failed to parse order items")
-                return
-            }
-        }
-
-        // Build order object
-        order := map[string]interface{}{
-            "order_id":    orderID,
-            "total_amount": totalAmount,
-            "currency":    currency.String,
-            "status":      status.String,
-            "payment_id":  paymentID.String,
-            "created_at":  createdAt,
-            "updated_at":  updatedAt,
-            "items":       items,
-        }
-
-        orders = append(orders, order)
-    }
-
-    if err := rows.Err(); err != nil {
-        log.Printf("Error iterating order rows: %v", err)
-        WriteError(w, r, http.StatusInternalServerError, "Error fetching orders")
-        return
-    }
-
-    // Respond with the list of orders
-    WriteSuccessMessage(w, r, orders)
+	WriteSuccessMessage(w, r, response)
 }
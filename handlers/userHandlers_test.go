@@ -0,0 +1,207 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/ratelimit"
+)
+
+func TestSignUpSignsTheUserIn(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+
+	var user models.User
+	c.do("GET", "/private/user/getuser", "").wantCode(http.StatusOK).decode(&user)
+	if user.Id != c.user.Id || user.Email != "asha@example.com" {
+		t.Errorf("getuser = %+v, want the signed up user %d", user, c.user.Id)
+	}
+}
+
+func TestSignUpIgnoresVerificationAndRoleFields(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.client()
+	c.do("POST", "/public/user/signup", `{"name":"Ravi","email":"ravi@example.com","phone":"9000000002","password":"secret123",`+
+		`"email_verified":true,"phone_verified":true,"role":"admin"}`).wantCode(http.StatusOK)
+
+	var user models.User
+	c.do("GET", "/private/user/getuser", "").wantCode(http.StatusOK).decode(&user)
+	if user.EmailVerified || user.PhoneVerified || user.Role != models.RoleCustomer {
+		t.Errorf("new user = %+v, want an unverified customer", user)
+	}
+}
+
+func TestResponsesLeaveThePasswordOut(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.register("asha@example.com")
+	responses := []*testResponse{
+		srv.client().do("POST", "/public/user/signup", `{"name":"Ravi","email":"ravi@example.com","phone":"9000000002","password":"secret123"}`),
+		srv.logIn("asha@example.com", testPassword),
+		c.do("GET", "/private/user/getuser", ""),
+		c.do("POST", "/private/user/edit", `{"name":"Asha","email":"asha@example.com","phone":"9000000001"}`),
+	}
+	for _, res := range responses {
+		res.wantCode(http.StatusOK)
+		if strings.Contains(string(res.body), "password") {
+			t.Errorf("%s: response %s carries the password", res.req, res.body)
+		}
+	}
+}
+
+func TestSignUpValidation(t *testing.T) {
+	srv := newTestServer(t)
+	srv.signUp("asha@example.com")
+
+	tests := []struct {
+		name, body string
+		code       int
+		message    string
+	}{
+		{"missing fields", `{"email":"ravi@example.com","password":"secret123"}`, http.StatusBadRequest, "All fields are required"},
+		{"email taken", `{"name":"Ravi","email":"asha@example.com","phone":"9000000001","password":"secret123"}`, http.StatusConflict, "Email is already registered"},
+		{"phone taken", `{"name":"Ravi","email":"ravi@example.com","phone":"9876500001","password":"secret123"}`, http.StatusConflict, "Phone number is already registered"},
+		{"weak password", `{"name":"Ravi","email":"ravi@example.com","phone":"9000000001","password":"password"}`, http.StatusBadRequest, "Password must contain a letter and a digit"},
+		{"not JSON", `name=Ravi`, http.StatusBadRequest, "Invalid request payload"},
+	}
+	for _, tt := range tests {
+		res := srv.client().do("POST", "/public/user/signup", tt.body)
+		if got := res.message(); res.code != tt.code || got != tt.message {
+			t.Errorf("%s: got %d %q, want %d %q", tt.name, res.code, got, tt.code, tt.message)
+		}
+	}
+}
+
+func TestLogIn(t *testing.T) {
+	srv := newTestServer(t)
+	srv.signUp("asha@example.com")
+
+	c := srv.client()
+	c.do("POST", "/public/user/login", `{"email":"asha@example.com","password":"wrong-password1"}`).
+		wantError(http.StatusUnauthorized, "Invalid email or password")
+	c.do("POST", "/public/user/login", `{"email":"nobody@example.com","password":"secret123"}`).
+		wantError(http.StatusUnauthorized, "Invalid email or password")
+	c.do("GET", "/private/user/getuser", "").wantError(http.StatusUnauthorized, "Unauthorized: User not authenticated")
+
+	c.do("POST", "/public/user/login", fmt.Sprintf(`{"email":"asha@example.com","password":%q}`, testPassword)).wantCode(http.StatusOK)
+	c.do("GET", "/private/user/getuser", "").wantCode(http.StatusOK)
+
+	c.do("POST", "/private/user/logout", "").wantCode(http.StatusOK)
+	c.do("GET", "/private/user/getuser", "").wantCode(http.StatusUnauthorized)
+}
+
+func TestLogInBacksOffAfterFailures(t *testing.T) {
+	srv := newTestServer(t, func(cfg *config.Config) {
+		cfg.Auth.LoginBackoffAfter, cfg.Auth.LoginLockoutAfter = 2, 3
+		cfg.Auth.LoginBackoffBase, cfg.Auth.LoginBackoffMax = time.Hour, time.Hour
+	})
+	srv.signUp("asha@example.com")
+	srv.signUp("ravi@example.com")
+
+	// Failures count against the account however the email is written.
+	for _, email := range []string{"Asha@Example.com", " asha@example.com "} {
+		srv.logIn(email, "wrong-password1").wantError(http.StatusUnauthorized, "Invalid email or password")
+	}
+	// Even the right password is refused until the delay is over.
+	res := srv.logIn("asha@example.com", testPassword)
+	res.wantError(http.StatusTooManyRequests, "Too many failed login attempts; try again later")
+	if got := res.header.Get("Retry-After"); got != "3600" {
+		t.Errorf("Retry-After = %q, want 3600", got)
+	}
+	// Other accounts from the same address are not affected.
+	srv.logIn("ravi@example.com", testPassword).wantCode(http.StatusOK)
+}
+
+func TestAuthRoutesAreRateLimited(t *testing.T) {
+	srv := newTestServer(t, func(cfg *config.Config) {
+		cfg.RateLimit.Auth = ratelimit.Limit{Requests: 2, Period: time.Hour}
+	})
+	srv.logIn("asha@example.com", testPassword).wantCode(http.StatusUnauthorized)
+	res := srv.logIn("asha@example.com", testPassword).wantCode(http.StatusUnauthorized)
+	if got := res.header.Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("RateLimit-Remaining = %q, want 0", got)
+	}
+	srv.logIn("asha@example.com", testPassword).wantError(http.StatusTooManyRequests, "Too many requests")
+	// Clients are counted by address, so a new cookie jar does not help,
+	// but the rest of /public has its own limit.
+	srv.client().do("GET", "/public/cities", "").wantCode(http.StatusOK)
+}
+
+func TestEditUser(t *testing.T) {
+	srv := newTestServer(t)
+	srv.signUp("ravi@example.com")
+	c := srv.signUp("asha@example.com")
+
+	c.do("POST", "/private/user/edit", `{"name":"Asha","email":"ravi@example.com","phone":"9000000001"}`).
+		wantError(http.StatusConflict, "Email is already registered")
+	c.do("POST", "/private/user/edit", `{"name":"Asha","email":"asha.k@example.com","phone":"9000000001"}`).wantCode(http.StatusOK)
+
+	var user models.User
+	c.do("GET", "/private/user/getuser", "").wantCode(http.StatusOK).decode(&user)
+	if user.Name != "Asha" || user.Email != "asha.k@example.com" || user.Phone != "9000000001" {
+		t.Errorf("getuser = %+v after editing", user)
+	}
+	srv.client().do("POST", "/public/user/login", fmt.Sprintf(`{"email":"asha.k@example.com","password":%q}`, testPassword)).
+		wantCode(http.StatusOK)
+}
+
+func TestAddresses(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	other := srv.signUp("ravi@example.com")
+
+	// signUp added an address for each of them.
+	address := models.Address{ID: c.addressID}
+	path := fmt.Sprintf("/private/user/editaddress/%d", address.ID)
+
+	other.do("PUT", path, `{"name":"Mine now","street":"1 MG Road","city":"Pune","postalCode":"411001","phone":"9876543210"}`).
+		wantError(http.StatusNotFound, "Address not found or not authorized")
+	other.do("DELETE", fmt.Sprintf("/private/user/deleteaddress/%d", address.ID), "").
+		wantError(http.StatusNotFound, "Address not found or not authorized")
+	c.do("PUT", path, `{"name":"Work","street":"2 FC Road","city":"Pune","postalCode":"411004","phone":"9876543210"}`).wantCode(http.StatusOK)
+
+	var addresses []models.Address
+	c.do("GET", "/private/user/getaddresses", "").wantCode(http.StatusOK).decode(&addresses)
+	if len(addresses) != 1 || addresses[0].Name != "Work" || addresses[0].UserID != c.user.Id {
+		t.Errorf("addresses = %+v, want the edited address", addresses)
+	}
+	other.do("GET", "/private/user/getaddresses", "").wantCode(http.StatusOK).decode(&addresses)
+	if len(addresses) != 1 || addresses[0].ID != other.addressID {
+		t.Errorf("other user sees addresses %+v", addresses)
+	}
+
+	c.do("DELETE", fmt.Sprintf("/private/user/deleteaddress/%d", address.ID), "").wantCode(http.StatusOK)
+	c.do("GET", "/private/user/getaddresses", "").wantCode(http.StatusOK).decode(&addresses)
+	if len(addresses) != 0 {
+		t.Errorf("addresses = %+v after deleting", addresses)
+	}
+}
+
+func TestCart(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	other := srv.signUp("ravi@example.com")
+
+	var cart models.Cart
+	c.do("GET", "/private/user/getcart", "").wantCode(http.StatusOK).decode(&cart)
+	path := fmt.Sprintf("/private/user/synccart/%d", cart.ID)
+
+	other.do("POST", path, `{"items":[{"id":20,"quantity":1,"restrauntId":2}]}`).
+		wantError(http.StatusForbidden, "Unauthorized to modify this cart")
+	c.do("POST", path, `{"items":[{"id":10,"quantity":1,"restrauntId":1},{"id":20,"quantity":1,"restrauntId":2}]}`).
+		wantError(http.StatusBadRequest, "All items must be from the same restaurant")
+	c.do("POST", path, `{"items":[{"id":10,"quantity":2,"restrauntId":1},{"id":11,"quantity":1,"restrauntId":1}]}`).wantCode(http.StatusOK)
+
+	var synced models.Cart
+	c.do("GET", "/private/user/getcart", "").wantCode(http.StatusOK).decode(&synced)
+	if synced.ID != cart.ID || synced.RestaurantID != 1 || len(synced.Items) != 2 {
+		t.Fatalf("cart = %+v, want cart %d with Dosa and Idli", synced, cart.ID)
+	}
+	if item := synced.Items[0]; item.Name != "Dosa" || item.Price != 100 || item.Quantity != 2 {
+		t.Errorf("first item = %+v, want 2 Dosa at the menu price", item)
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/middleware"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+func registerUserRoutes(r *mux.Router, h *Handler) {
+	r.NotFoundHandler = http.NotFoundHandler()
+
+	r.HandleFunc("/user/signup", h.HandleSignUp).Methods("POST", "OPTIONS")
+	r.HandleFunc("/user/login", h.HandleLogIn).Methods("POST", "OPTIONS")
+	r.HandleFunc("/user/requestpasswordreset", h.RequestPasswordReset).Methods("POST", "OPTIONS")
+	r.HandleFunc("/user/resetpassword", h.ResetPassword).Methods("POST", "OPTIONS")
+	r.HandleFunc("/user/verifyemail", h.VerifyEmail).Methods("POST", "OPTIONS")
+	r.HandleFunc("/user/requestloginotp", h.RequestLoginOTP).Methods("POST", "OPTIONS")
+	r.HandleFunc("/user/loginotp", h.LogInWithOTP).Methods("POST", "OPTIONS")
+}
+
+func registerProtectedUserRoutes(r *mux.Router, h *Handler) {
+	r.NotFoundHandler = http.NotFoundHandler()
+	staff := middleware.Authorize(models.RoleRestaurantOwner, models.RoleCourier, models.RoleAdmin)
+
+	r.HandleFunc("/user/getuser", h.HandleGetUser).Methods("GET", "OPTIONS")
+	r.HandleFunc("/user/edit", h.HandleEditUser).Methods("POST", "OPTIONS")
+	r.HandleFunc("/user/logout", h.HandleLogOut).Methods("POST", "OPTIONS")
+	r.HandleFunc("/user/changepassword", h.ChangePassword).Methods("POST", "OPTIONS")
+	r.HandleFunc("/user/resendverification", h.ResendVerificationEmail).Methods("POST", "OPTIONS")
+	r.HandleFunc("/user/requestphoneverification", h.RequestPhoneVerification).Methods("POST", "OPTIONS")
+	r.HandleFunc("/user/verifyphone", h.VerifyPhone).Methods("POST", "OPTIONS")
+
+	r.HandleFunc("/user/getcart", h.FetchCart).Methods("GET", "OPTIONS")
+
+	r.HandleFunc("/user/getaddresses", h.GetUserAddresses).Methods("GET", "OPTIONS")
+	r.HandleFunc("/user/addaddress", h.HandleAddAddress).Methods("POST", "OPTIONS")
+	r.HandleFunc("/user/editaddress/{id}", h.HandleEditAddress).Methods("PUT", "OPTIONS")
+	r.HandleFunc("/user/deleteaddress/{id}", h.HandleDeleteAddress).Methods("DELETE", "OPTIONS")
+	r.HandleFunc("/user/setprimaryaddress/{id}", h.SetPrimaryAddress).Methods("PUT", "OPTIONS")
+
+	r.HandleFunc("/user/synccart/{cart_id}", h.SyncCart).Methods("POST", "OPTIONS")
+
+	r.HandleFunc("/user/fetchorders", h.FetchOrders).Methods("GET", "OPTIONS")
+	r.HandleFunc("/orders", h.FetchOrders).Methods("GET", "OPTIONS")
+	r.HandleFunc("/orders/{id}", h.GetOrder).Methods("GET", "OPTIONS")
+	r.HandleFunc("/orders/{id}/status", h.TransitionOrder).Methods("POST", "OPTIONS")
+	r.HandleFunc("/orders/{id}/cancel", h.CancelOrder).Methods("POST", "OPTIONS")
+	r.Handle("/orders/{id}/refund", middleware.Authorize(models.RoleRestaurantOwner, models.RoleAdmin)(h.Idempotent(h.RefundOrder))).Methods("POST", "OPTIONS")
+	r.HandleFunc("/orders/{id}/reorder", h.ReorderOrder).Methods("POST", "OPTIONS")
+	r.HandleFunc("/orders/{id}/events", h.OrderEvents).Methods("GET", "OPTIONS")
+	r.Handle("/orders/{id}/eta", staff(http.HandlerFunc(h.UpdateOrderETA))).Methods("POST", "OPTIONS")
+	r.Handle("/orders/{id}/location", middleware.Authorize(models.RoleCourier, models.RoleAdmin)(http.HandlerFunc(h.UpdateCourierLocation))).Methods("POST", "OPTIONS")
+}
+
+// registerCheckoutRoutes registers the payment routes, which NewRouter limits
+// more tightly than the other protected routes. r must already require a
+// signed in user.
+func registerCheckoutRoutes(r *mux.Router, h *Handler) {
+	r.HandleFunc("/payment/create-checkout-session", h.Idempotent(h.CreateCheckoutSession)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/payment/checkout-cart", h.Idempotent(h.CheckoutCart)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/payment/session-status", h.RetrieveCheckoutSession).Methods("GET", "OPTIONS")
+}
+
+// registerAdminRoutes registers the admin API. r must already be limited to
+// admins.
+func registerAdminRoutes(r *mux.Router, h *Handler) {
+	r.HandleFunc("/users/{id}/role", h.SetUserRole).Methods("PUT", "OPTIONS")
+	r.HandleFunc("/restaurants/{id}/owners", h.AddRestaurantOwner).Methods("POST", "OPTIONS")
+	r.HandleFunc("/restaurants/{id}/owners/{user_id}", h.RemoveRestaurantOwner).Methods("DELETE", "OPTIONS")
+}
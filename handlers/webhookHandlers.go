@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/payment"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
+)
+
+// maxWebhookPayload bounds the body read from the gateway; real events are a
+// few kilobytes.
+const maxWebhookPayload = 64 << 10
+
+// HandlePaymentWebhook applies payment gateway events to orders. It is the
+// authoritative source of payment status: it works even when the customer
+// never returns to the site after paying.
+func (h *Handler) HandlePaymentWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		WriteError(w, r, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookPayload))
+	if err != nil {
+		WriteError(w, r, http.StatusRequestEntityTooLarge, "Request body too large")
+		return
+	}
+
+	event, err := h.Payments.VerifyWebhook(payload, r.Header)
+	if errors.Is(err, payment.ErrInvalidSignature) {
+		log.Printf("Rejected payment webhook: %v", err)
+		WriteError(w, r, http.StatusBadRequest, "Invalid signature")
+		return
+	}
+	if err != nil {
+		log.Printf("Error reading payment webhook: %v", err)
+		WriteError(w, r, http.StatusBadRequest, "Invalid event payload")
+		return
+	}
+
+	update, ok := paymentUpdateFromEvent(event)
+	if !ok {
+		WriteSuccessMessage(w, r, map[string]bool{"received": true})
+		return
+	}
+
+	change, err := h.Store.Orders.ApplyPaymentUpdate(r.Context(), update)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		// Not one of our orders, or one created before orders carried
+		// metadata. Acknowledge it so the gateway stops retrying.
+		log.Printf("Payment event %s (%s) matches no order", event.ID, event.GatewayType)
+	case err != nil:
+		// A non-2xx response makes the gateway redeliver the event later.
+		log.Printf("Error applying payment event %s (%s): %v", event.ID, event.GatewayType, err)
+		WriteError(w, r, http.StatusInternalServerError, "Failed to update order")
+		return
+	case change != nil:
+		log.Printf("Payment event %s (%s) moved order %d to %s", event.ID, event.GatewayType, change.OrderID, change.ToStatus)
+	default:
+		log.Printf("Payment event %s (%s) already processed or superseded", event.ID, event.GatewayType)
+	}
+
+	WriteSuccessMessage(w, r, map[string]bool{"received": true})
+}
+
+// paymentUpdateFromEvent translates a payment event into an order update. ok
+// is false for events that do not change an order. The store applies the
+// update only when the order state machine allows it.
+func paymentUpdateFromEvent(event payment.Event) (update store.PaymentUpdate, ok bool) {
+	update = store.PaymentUpdate{
+		EventID:   event.ID,
+		EventType: event.GatewayType,
+		OrderID:   event.OrderID,
+		SessionID: event.SessionID,
+		PaymentID: event.PaymentID,
+	}
+
+	switch event.Type {
+	case payment.EventCheckoutCompleted:
+		// Delayed payment methods complete the session before the money
+		// arrives; the order stays pending until it does.
+		if !event.Paid {
+			return update, false
+		}
+		update.Status = models.OrderCompleted
+		update.DeactivateCart = true
+	case payment.EventCheckoutExpired:
+		update.Status = models.OrderExpired
+	case payment.EventPaymentFailed:
+		update.Status = models.OrderFailed
+	case payment.EventRefunded:
+		// The store works out the order's status from its refunds.
+		if len(event.Refunds) == 0 {
+			return update, false
+		}
+		for _, r := range event.Refunds {
+			update.Refunds = append(update.Refunds, models.Refund{
+				ProviderRefundID: r.ID,
+				Amount:           float64(r.Amount) / 100,
+				Status:           r.Status,
+			})
+		}
+	default:
+		return update, false
+	}
+	return update, true
+}
@@ -0,0 +1,204 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/payment"
+)
+
+// deliver posts a signed webhook event.
+func (s *testServer) deliver(event payment.Event) *testResponse {
+	s.t.Helper()
+	payload, err := json.Marshal(event)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return s.client().do("POST", "/webhooks/fake", string(payload), payment.FakeSignatureHeader, s.fake.Sign(payload))
+}
+
+func paid(eventID string, orderID int) payment.Event {
+	return payment.Event{ID: eventID, Type: payment.EventCheckoutCompleted, OrderID: orderID,
+		PaymentID: fmt.Sprintf("pi_fake_%d", orderID), Paid: true}
+}
+
+func TestWebhookRejectsUnsignedEvents(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	orderID := c.checkout(10, 1)
+
+	payload, _ := json.Marshal(paid("evt_1", orderID))
+	srv.client().do("POST", "/webhooks/fake", string(payload)).wantError(http.StatusBadRequest, "Invalid signature")
+	srv.client().do("POST", "/webhooks/fake", string(payload), payment.FakeSignatureHeader, "deadbeef").
+		wantError(http.StatusBadRequest, "Invalid signature")
+	if order := c.order(orderID); order.Status != "pending" {
+		t.Errorf("status = %s, want pending", order.Status)
+	}
+}
+
+func TestWebhookCompletesPaidOrders(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	var cart struct {
+		ID int `json:"cart_id"`
+	}
+	c.do("GET", "/private/user/getcart", "").wantCode(http.StatusOK).decode(&cart)
+	orderID := c.checkout(10, 1)
+
+	// Delayed payment methods complete the session before the money arrives.
+	unpaid := paid("evt_1", orderID)
+	unpaid.Paid = false
+	srv.deliver(unpaid).wantCode(http.StatusOK)
+	if order := c.order(orderID); order.Status != "pending" {
+		t.Errorf("status after an unpaid completion = %s, want pending", order.Status)
+	}
+
+	srv.deliver(paid("evt_2", orderID)).wantCode(http.StatusOK)
+	order := c.order(orderID)
+	if order.Status != "completed" || order.PaymentID != fmt.Sprintf("pi_fake_%d", orderID) {
+		t.Errorf("order = %s paid by %q, want completed by pi_fake_%d", order.Status, order.PaymentID, orderID)
+	}
+	var next struct {
+		ID int `json:"cart_id"`
+	}
+	c.do("GET", "/private/user/getcart", "").wantCode(http.StatusOK).decode(&next)
+	if next.ID == cart.ID {
+		t.Error("the paid cart is still active")
+	}
+
+	// A late expiry does not undo the payment.
+	srv.deliver(payment.Event{ID: "evt_3", Type: payment.EventCheckoutExpired, OrderID: orderID}).wantCode(http.StatusOK)
+	if order := c.order(orderID); order.Status != "completed" {
+		t.Errorf("status after a late expiry = %s, want completed", order.Status)
+	}
+}
+
+func TestWebhookFailuresAndExpiries(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	failed, expired := c.checkout(10, 1), c.checkout(11, 1)
+
+	srv.deliver(payment.Event{ID: "evt_1", Type: payment.EventPaymentFailed, OrderID: failed}).wantCode(http.StatusOK)
+	if order := c.order(failed); order.Status != "failed" {
+		t.Errorf("status = %s, want failed", order.Status)
+	}
+	// The customer may retry in the same session and succeed.
+	srv.deliver(paid("evt_2", failed)).wantCode(http.StatusOK)
+	if order := c.order(failed); order.Status != "completed" {
+		t.Errorf("status after a retried payment = %s, want completed", order.Status)
+	}
+
+	srv.deliver(payment.Event{ID: "evt_3", Type: payment.EventCheckoutExpired, OrderID: expired}).wantCode(http.StatusOK)
+	if order := c.order(expired); order.Status != "expired" {
+		t.Errorf("status = %s, want expired", order.Status)
+	}
+}
+
+// asyncCheckout completes orderID's checkout unpaid, as delayed payment
+// methods such as bank debits do, and returns the event that later reports
+// the outcome, translated as the Stripe provider translates
+// checkout.session.async_payment_succeeded and _failed.
+func (s *testServer) asyncCheckout(orderID int, succeeded bool) payment.Event {
+	s.t.Helper()
+	completed := paid("evt_completed", orderID)
+	completed.Paid = false
+	s.deliver(completed).wantCode(http.StatusOK)
+
+	if succeeded {
+		event := paid("evt_async", orderID)
+		event.GatewayType = "checkout.session.async_payment_succeeded"
+		return event
+	}
+	return payment.Event{ID: "evt_async", GatewayType: "checkout.session.async_payment_failed", Type: payment.EventPaymentFailed,
+		OrderID: orderID, PaymentID: fmt.Sprintf("pi_fake_%d", orderID)}
+}
+
+func TestWebhookCompletesAsyncPayments(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	orderID := c.checkout(10, 1)
+
+	event := srv.asyncCheckout(orderID, true)
+	if order := c.order(orderID); order.Status != "pending" {
+		t.Fatalf("status before the payment arrived = %s, want pending", order.Status)
+	}
+	srv.deliver(event).wantCode(http.StatusOK)
+	if order := c.order(orderID); order.Status != "completed" || order.PaymentID != fmt.Sprintf("pi_fake_%d", orderID) {
+		t.Errorf("order = %s paid by %q, want completed", order.Status, order.PaymentID)
+	}
+}
+
+func TestWebhookFailsAsyncPayments(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	orderID := c.checkout(10, 1)
+
+	srv.deliver(srv.asyncCheckout(orderID, false)).wantCode(http.StatusOK)
+	if order := c.order(orderID); order.Status != "failed" {
+		t.Errorf("status = %s, want failed", order.Status)
+	}
+}
+
+func TestWebhookIgnoresRedeliveriesAndUnknownOrders(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	orderID := c.checkout(10, 1)
+
+	expiry := payment.Event{ID: "evt_1", Type: payment.EventCheckoutExpired, OrderID: orderID}
+	srv.deliver(expiry).wantCode(http.StatusOK)
+	srv.deliver(paid("evt_2", orderID)).wantCode(http.StatusOK)
+	srv.deliver(expiry).wantCode(http.StatusOK)
+	if order := c.order(orderID); order.Status != "expired" {
+		t.Errorf("status = %s, want expired: an expired session cannot be paid", order.Status)
+	}
+
+	srv.deliver(paid("evt_3", 999)).wantCode(http.StatusOK)
+	srv.deliver(payment.Event{ID: "evt_4", GatewayType: "customer.created"}).wantCode(http.StatusOK)
+}
+
+func TestWebhookRecordsRefunds(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.signUp("asha@example.com")
+	admin := srv.signUpAs("admin@example.com", models.RoleAdmin)
+	orderID := c.checkout(10, 1)
+	srv.deliver(paid("evt_1", orderID)).wantCode(http.StatusOK)
+	paymentID := fmt.Sprintf("pi_fake_%d", orderID)
+
+	var issued struct {
+		Refund models.Refund `json:"refund"`
+	}
+	admin.do("POST", fmt.Sprintf("/private/orders/%d/refund", orderID), `{"amount":10}`).wantCode(http.StatusOK).decode(&issued)
+
+	// Refunds are matched by their gateway ID, so the one issued above is
+	// not recorded twice and redeliveries change nothing.
+	refunded := payment.Event{ID: "evt_2", GatewayType: "charge.refunded", Type: payment.EventRefunded, PaymentID: paymentID,
+		Refunds: []payment.Refund{
+			{ID: issued.Refund.ProviderRefundID, Amount: 1000, Status: "succeeded"},
+			{ID: "re_dashboard_1", Amount: 4000, Status: "succeeded"},
+		}}
+	srv.deliver(refunded).wantCode(http.StatusOK)
+	refunded.ID = "evt_3"
+	srv.deliver(refunded).wantCode(http.StatusOK)
+	order := srv.order(orderID)
+	if order.Status != models.OrderPartiallyRefunded || len(order.Refunds) != 2 || order.RefundedAmount() != 50 {
+		t.Errorf("order = %s with refunds %+v, want 50 refunded in two refunds", order.Status, order.Refunds)
+	}
+
+	// A refund that failed returns nothing.
+	failed := payment.Event{ID: "evt_4", GatewayType: "refund.failed", Type: payment.EventRefunded, PaymentID: paymentID,
+		Refunds: []payment.Refund{{ID: "re_dashboard_1", Amount: 4000, Status: "failed"}}}
+	srv.deliver(failed).wantCode(http.StatusOK)
+	if order := srv.order(orderID); order.RefundedAmount() != 10 {
+		t.Errorf("refunded %v after a failed refund, want 10", order.RefundedAmount())
+	}
+
+	refunded.ID = "evt_5"
+	refunded.Refunds = []payment.Refund{{ID: "re_dashboard_2", Amount: 9000, Status: "succeeded"}}
+	srv.deliver(refunded).wantCode(http.StatusOK)
+	if order := srv.order(orderID); order.Status != models.OrderRefunded || len(order.Refunds) != 3 {
+		t.Errorf("order = %s with refunds %+v, want refunded in three refunds", order.Status, order.Refunds)
+	}
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"github.com/gorilla/mux"
+)
+
+// registerWebhookRoutes adds the endpoints called by third parties. They sit
+// outside /private because callers authenticate by signature, not session.
+func registerWebhookRoutes(r *mux.Router, h *Handler) {
+	// One endpoint per gateway, so its URL in the gateway dashboard names
+	// the gateway; e.g. /webhooks/stripe.
+	r.HandleFunc("/webhooks/"+h.Config.Payment.Provider, h.HandlePaymentWebhook).Methods("POST")
+}
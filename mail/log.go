@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Log keeps messages instead of sending them: it appends them to a file, or
+// writes them to the log when no file is set. Links in password reset and
+// verification emails can be copied from there during development.
+type Log struct {
+	path string
+	from string
+
+	mu sync.Mutex
+}
+
+func NewLog(path, from string) *Log {
+	return &Log{path: path, from: from}
+}
+
+func (l *Log) Send(ctx context.Context, msg Message) error {
+	if err := validHeader(msg.To, msg.Subject); err != nil {
+		return err
+	}
+	data := format(l.from, msg, time.Now())
+	if l.path == "" {
+		log.Printf("Mail not sent (log mailer):\n%s", data)
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, "\r\n\r\n"...)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
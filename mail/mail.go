@@ -0,0 +1,60 @@
+// Package mail sends email to users. SMTP is the production implementation;
+// Log keeps messages local for development.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+)
+
+// Mailer delivers email.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// New returns the mailer selected by cfg.Mail.Mailer.
+func New(cfg *config.Config) (Mailer, error) {
+	switch cfg.Mail.Mailer {
+	case config.MailerSMTP:
+		return NewSMTP(cfg.Mail.SMTPHost, cfg.Mail.SMTPPort, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword, cfg.Mail.From), nil
+	case config.MailerLog:
+		return NewLog(cfg.Mail.File, cfg.Mail.From), nil
+	default:
+		return nil, fmt.Errorf("mail: unknown mailer %q", cfg.Mail.Mailer)
+	}
+}
+
+// format renders msg as an RFC 5322 message from from.
+func format(from string, msg Message, now time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// validHeader rejects header values that would let a caller inject headers.
+func validHeader(values ...string) error {
+	for _, v := range values {
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("mail: header value %q contains a line break", v)
+		}
+	}
+	return nil
+}
@@ -1,29 +1,51 @@
 package main
 
 import (
-	"crypto/tls"
+	"context"
+	"flag"
 	"log"
 	"net/http"
 	"os"
 
-	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
 
-	"github.com/vishal-sharma-001/FoodHaven-Backend/middleware"
-	"github.com/vishal-sharma-001/FoodHaven-Backend/routes"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/database"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/events"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/handlers"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/mail"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/migrations"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/payment"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/sms"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/store"
 )
 
 func main() {
-    port := ":8080"
-    router := mux.NewRouter().StrictSlash(true)
+    if len(os.Args) > 1 {
+        switch os.Args[1] {
+        case "migrate":
+            runMigrate(os.Args[2:])
+            return
+        case "admin":
+            runAdmin(os.Args[2:])
+            return
+        }
+    }
+
+    configPath := flag.String("config", "", "path to a YAML or JSON config file (defaults to $CONFIG_FILE)")
+    migrateOnStart := flag.Bool("migrate", false, "apply pending database migrations before serving")
+    flag.Parse()
 
-    sessionKey := os.Getenv("SESSION_KEY")
-    if sessionKey == "" {
-        log.Fatal("SESSION_KEY is missing. Please set it in your environment variables or .env file.")
+    cfg, err := config.Load(*configPath)
+    if err != nil {
+        log.Fatalf("Failed to load configuration: %v", err)
+    }
+    if err := cfg.Validate(); err != nil {
+        log.Fatalf("Invalid configuration:\n%v", err)
     }
 
-    store := sessions.NewCookieStore([]byte(sessionKey))
-    store.Options = &sessions.Options{
+    sessionStore := sessions.NewCookieStore([]byte(cfg.Session.Key))
+    sessionStore.Options = &sessions.Options{
         Path:     "/",
         MaxAge:   86400,
         HttpOnly: true,
@@ -31,16 +53,61 @@ func main() {
         SameSite: http.SameSiteLaxMode,
     }
 
-    publicRoutes := router.PathPrefix("/public").Subrouter()
-    routes.RegisterFoodRoutes(publicRoutes)
-    routes.RegisterRestaurantsRoutes(publicRoutes)
-    routes.RegisterUserRoutes(publicRoutes, store)
+    dbClient, err := database.Open(context.Background(), cfg.Database)
+    if err != nil {
+        log.Fatalf("Database connection error: %v", err)
+    }
+
+    if *migrateOnStart {
+        applied, err := migrations.Up(context.Background(), dbClient)
+        if err != nil {
+            log.Fatalf("Migration failed: %v", err)
+        }
+        log.Printf("Applied %d migration(s)", len(applied))
+    }
+
+    payments, err := payment.New(cfg)
+    if err != nil {
+        log.Fatalf("Payment provider error: %v", err)
+    }
+    if cfg.Payment.Provider == config.PaymentFake {
+        log.Printf("Warning: using the fake payment provider; no real payments will be taken.")
+    }
 
-    protectedRoutes := router.PathPrefix("/private").Subrouter()
-    protectedRoutes.Use(middleware.Authenticate(store))
-    routes.RegisterProtectedUserRoutes(protectedRoutes, store)
+    mailer, err := mail.New(cfg)
+    if err != nil {
+        log.Fatalf("Mailer error: %v", err)
+    }
+    if cfg.Mail.Mailer == config.MailerLog {
+        log.Printf("Warning: using the log mailer; emails are not delivered.")
+    }
+
+    sender, err := sms.New(cfg)
+    if err != nil {
+        log.Fatalf("SMS sender error: %v", err)
+    }
+    if cfg.SMS.Sender == config.SMSLog {
+        log.Printf("Warning: using the log SMS sender; text messages are not delivered.")
+    }
 
-    uiDir := "./FoodHavenUI"
+    st := store.NewPostgres(dbClient)
+    broker := events.NewBroker()
+    st.Orders = events.PublishOrderChanges(st.Orders, broker)
+    h := handlers.New(cfg, st, sessionStore, payments, broker, mailer, sender)
+    h.ReadinessChecks = []handlers.ReadinessCheck{
+        handlers.DatabaseCheck(dbClient),
+        handlers.MigrationsCheck(dbClient),
+    }
+    if cfg.Payment.Provider == config.PaymentStripe {
+        h.ReadinessChecks = append(h.ReadinessChecks, handlers.StripeCheck(cfg.Stripe.SecretKey))
+    }
+
+    if cfg.Payment.Provider == config.PaymentStripe && cfg.Stripe.WebhookSecret == "" {
+        log.Printf("Warning: STRIPE_WEBHOOK_SECRET is not set; /webhooks/stripe will reject deliveries and paid orders will stay pending.")
+    }
+    router := handlers.NewRouter(h, cfg)
+
+    uiDir := cfg.Server.UIDir
     if _, err := os.Stat(uiDir); os.IsNotExist(err) {
         log.Printf("Warning: UI directory %q not found. Ensure UI files are present for static serving.\n", uiDir)
     } else {
@@ -52,27 +119,13 @@ func main() {
         http.ServeFile(w, r, uiDir+"/index.html")
     })
 
-    tlsCertPath := "/etc/tls/tls.crt"
-    tlsKeyPath := "/etc/tls/tls.key"
-
-    if _, err := os.Stat(tlsCertPath); os.IsNotExist(err) {
-        log.Fatalf("TLS certificate file not found at %v", tlsCertPath)
-    }
-    if _, err := os.Stat(tlsKeyPath); os.IsNotExist(err) {
-        log.Fatalf("TLS key file not found at %v", tlsKeyPath)
-    }
-
-    log.Printf("Starting the server on https://localhost%v\n", port)
-
-    server := &http.Server{
-        Addr:      port,
-        Handler: router,
-        TLSConfig: &tls.Config{
-            MinVersion: tls.VersionTLS13,
-        },
-    }
-
-    if err := server.ListenAndServeTLS(tlsCertPath, tlsKeyPath); err != nil {
-        log.Fatalf("Error starting server: %v", err)
+    // Emails and texts still being sent need the database.
+    err = serve(cfg.Server, router, broker.Close, h.Wait, func() {
+        if err := dbClient.Close(); err != nil {
+            log.Printf("Error closing database pool: %v", err)
+        }
+    })
+    if err != nil {
+        log.Fatal(err)
     }
-}
\ No newline at end of file
+}
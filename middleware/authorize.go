@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+// Authorize lets through only users holding one of roles. It reads the user
+// Authenticate stored in the request context, so it must run after it.
+func Authorize(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := r.Context().Value(ContextKeyUser).(models.User)
+			if !ok {
+				log.Println("Unauthorized: Authorize used without Authenticate")
+				http.Error(w, "Unauthorized: User not authenticated", http.StatusUnauthorized)
+				return
+			}
+			if !slices.Contains(roles, user.Role) {
+				log.Printf("Forbidden: user %d with role %q requested %s", user.Id, user.Role, r.URL.Path)
+				http.Error(w, "Forbidden: Insufficient role", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+func TestAuthorize(t *testing.T) {
+	h := Authorize(models.RoleRestaurantOwner, models.RoleAdmin)(noContent)
+	as := func(user *models.User) *http.Request {
+		r := httptest.NewRequest("GET", "/", nil)
+		if user != nil {
+			r = r.WithContext(context.WithValue(r.Context(), ContextKeyUser, *user))
+		}
+		return r
+	}
+
+	tests := []struct {
+		name    string
+		user    *models.User
+		code    int
+		message string
+	}{
+		{"no user", nil, http.StatusUnauthorized, "Unauthorized: User not authenticated"},
+		{"customer", &models.User{Id: 1, Role: models.RoleCustomer}, http.StatusForbidden, "Forbidden: Insufficient role"},
+		{"courier", &models.User{Id: 2, Role: models.RoleCourier}, http.StatusForbidden, "Forbidden: Insufficient role"},
+		{"no role", &models.User{Id: 3}, http.StatusForbidden, "Forbidden: Insufficient role"},
+		{"restaurant owner", &models.User{Id: 4, Role: models.RoleRestaurantOwner}, http.StatusNoContent, ""},
+		{"admin", &models.User{Id: 5, Role: models.RoleAdmin}, http.StatusNoContent, ""},
+	}
+	for _, tt := range tests {
+		w := serve(h, as(tt.user))
+		if got := strings.TrimSpace(w.Body.String()); w.Code != tt.code || got != tt.message {
+			t.Errorf("%s: got %d %q, want %d %q", tt.name, w.Code, got, tt.code, tt.message)
+		}
+	}
+}
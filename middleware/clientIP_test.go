@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		forwarded  []string
+		trustProxy bool
+		want       string
+	}{
+		{"direct", nil, false, "192.0.2.1"},
+		{"header ignored without a proxy", []string{"203.0.113.9"}, false, "192.0.2.1"},
+		{"last hop added by the proxy", []string{"198.51.100.7, 203.0.113.9"}, true, "203.0.113.9"},
+		{"last of several headers", []string{"198.51.100.7", "203.0.113.9"}, true, "203.0.113.9"},
+		{"no header behind a proxy", nil, true, "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/public/user/login", nil)
+		r.RemoteAddr = "192.0.2.1:51234"
+		for _, value := range tt.forwarded {
+			r.Header.Add("X-Forwarded-For", value)
+		}
+		if got := ClientIP(r, tt.trustProxy); got != tt.want {
+			t.Errorf("%s: ClientIP = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/ratelimit"
+)
+
+var noContent = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+})
+
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestRateLimit(t *testing.T) {
+	limit := ratelimit.Limit{Requests: 2, Period: time.Minute}
+	h := RateLimit(ratelimit.NewMemory(), "test", limit, ByIP(false))(noContent)
+	request := func(method, addr string) *http.Request {
+		r := httptest.NewRequest(method, "/", nil)
+		r.RemoteAddr = addr
+		return r
+	}
+
+	w := serve(h, request("GET", "10.0.0.1:1234"))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("first request: got %d", w.Code)
+	}
+	for header, want := range map[string]string{
+		"RateLimit-Policy":    "2;w=60",
+		"RateLimit-Limit":     "2",
+		"RateLimit-Remaining": "1",
+		"RateLimit-Reset":     "30",
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	// Preflights are not counted.
+	for i := 0; i < 3; i++ {
+		if w := serve(h, request("OPTIONS", "10.0.0.1:1234")); w.Code != http.StatusNoContent {
+			t.Fatalf("preflight: got %d", w.Code)
+		}
+	}
+	serve(h, request("GET", "10.0.0.1:1234"))
+	w = serve(h, request("GET", "10.0.0.1:5678"))
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" {
+		t.Errorf("third request: got %d with Retry-After %q, want 429 after 30", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := serve(h, request("GET", "10.0.0.2:1234")); w.Code != http.StatusNoContent {
+		t.Errorf("other client: got %d", w.Code)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	h := RateLimit(ratelimit.NewMemory(), "test", ratelimit.Limit{}, ByIP(false))(noContent)
+	w := serve(h, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("got %d with headers %v, want the request passed through untouched", w.Code, w.Header())
+	}
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("backend down")
+}
+
+func TestRateLimitLetsRequestsThroughWhenTheLimiterFails(t *testing.T) {
+	h := RateLimit(failingLimiter{}, "test", ratelimit.Limit{Requests: 1, Period: time.Second}, ByIP(false))(noContent)
+	if w := serve(h, httptest.NewRequest("GET", "/", nil)); w.Code != http.StatusNoContent {
+		t.Errorf("got %d, want the request let through", w.Code)
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Add("X-Forwarded-For", "1.2.3.4, 203.0.113.7")
+
+	tests := []struct {
+		name string
+		key  KeyFunc
+		r    *http.Request
+		want string
+	}{
+		{"ByIP", ByIP(false), r, "ip:10.0.0.1"},
+		{"ByIP behind a proxy", ByIP(true), r, "ip:203.0.113.7"},
+		{"ByUser without a user", ByUser(false), r, "ip:10.0.0.1"},
+		{"ByUser", ByUser(false), r.WithContext(context.WithValue(r.Context(), ContextKeyUser, models.User{Id: 42})), "user:42"},
+	}
+	for _, tt := range tests {
+		if got := tt.key(tt.r); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
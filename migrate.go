@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/database"
+	"github.com/vishal-sharma-001/FoodHaven-Backend/migrations"
+)
+
+const migrateUsage = `usage: FoodHaven-Backend migrate [-config file] <command>
+
+commands:
+  up          apply all pending migrations (default)
+  down [N]    revert the last N applied migrations (default 1)
+  status      list migrations and whether they are applied`
+
+// runMigrate implements the `migrate` subcommand. It only needs the database
+// settings, so the rest of the configuration is not validated.
+func runMigrate(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	configPath := flags.String("config", "", "path to a YAML or JSON config file (defaults to $CONFIG_FILE)")
+	flags.Usage = func() { fmt.Fprintln(os.Stderr, migrateUsage) }
+	flags.Parse(args)
+	args = flags.Args()
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	if command != "up" && command != "down" && command != "status" {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := cfg.Database.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	ctx := context.Background()
+	dbClient, err := database.Open(ctx, cfg.Database)
+	if err != nil {
+		log.Fatalf("Database connection error: %v", err)
+	}
+	defer dbClient.Close()
+
+	switch command {
+	case "up":
+		applied, err := migrations.Up(ctx, dbClient)
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		log.Printf("Applied %d migration(s)", len(applied))
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				log.Fatalf("Invalid step count %q", args[1])
+			}
+		}
+		reverted, err := migrations.Down(ctx, dbClient, steps)
+		if err != nil {
+			log.Fatalf("Migration rollback failed: %v", err)
+		}
+		log.Printf("Reverted %d migration(s)", len(reverted))
+
+	case "status":
+		statuses, err := migrations.List(ctx, dbClient)
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-40s %s\n", s.Version, s.Name, state)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS cart_items;
+DROP TABLE IF EXISTS cart;
+DROP TABLE IF EXISTS addresses;
+DROP TABLE IF EXISTS FoodItems;
+DROP TABLE IF EXISTS restaurantsdata;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,105 @@
+-- Baseline schema the handlers were written against. Every statement is
+-- guarded with IF NOT EXISTS so databases created before migrations existed
+-- can adopt this version without changes.
+
+CREATE TABLE IF NOT EXISTS users (
+    id         SERIAL PRIMARY KEY,
+    name       TEXT NOT NULL,
+    email      TEXT NOT NULL,
+    phone      TEXT NOT NULL,
+    password   TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT users_email_key UNIQUE (email),
+    CONSTRAINT users_phone_key UNIQUE (phone)
+);
+
+CREATE TABLE IF NOT EXISTS restaurantsdata (
+    id           SERIAL PRIMARY KEY,
+    name         TEXT NOT NULL,
+    rating       NUMERIC(2, 1) NOT NULL DEFAULT 0,
+    cuisine      TEXT NOT NULL DEFAULT '',
+    deliverytime INTEGER NOT NULL DEFAULT 0,
+    offers       TEXT NOT NULL DEFAULT '',
+    locality     TEXT NOT NULL DEFAULT '',
+    cloudimageid TEXT NOT NULL,
+    costfortwo   NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    veg          BOOLEAN NOT NULL DEFAULT FALSE,
+    city         TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS restaurantsdata_city_idx ON restaurantsdata (city);
+
+-- Menu items are linked to their restaurant through the restaurant's
+-- cloudimageid, which is what /public/fooditems filters on.
+CREATE TABLE IF NOT EXISTS FoodItems (
+    id           SERIAL PRIMARY KEY,
+    name         TEXT NOT NULL,
+    price        NUMERIC(10, 2) NOT NULL,
+    description  TEXT NOT NULL DEFAULT '',
+    cloudimageid TEXT NOT NULL,
+    category     TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS fooditems_cloudimageid_idx ON FoodItems (cloudimageid);
+
+CREATE TABLE IF NOT EXISTS addresses (
+    id          SERIAL PRIMARY KEY,
+    user_id     INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name        TEXT NOT NULL DEFAULT '',
+    street      TEXT NOT NULL DEFAULT '',
+    city        TEXT NOT NULL DEFAULT '',
+    postal_code TEXT NOT NULL DEFAULT '',
+    phone       TEXT NOT NULL DEFAULT '',
+    is_primary  BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS addresses_user_id_idx ON addresses (user_id);
+
+CREATE TABLE IF NOT EXISTS cart (
+    id            SERIAL PRIMARY KEY,
+    user_id       INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    restaurant_id INTEGER,
+    total_amount  NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    is_active     BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS cart_user_id_active_idx ON cart (user_id) WHERE is_active;
+
+CREATE TABLE IF NOT EXISTS cart_items (
+    id         SERIAL PRIMARY KEY,
+    cart_id    INTEGER NOT NULL REFERENCES cart (id) ON DELETE CASCADE,
+    item_id    INTEGER NOT NULL REFERENCES FoodItems (id),
+    quantity   INTEGER NOT NULL CHECK (quantity > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT cart_items_cart_id_item_id_key UNIQUE (cart_id, item_id)
+);
+
+CREATE TABLE IF NOT EXISTS orders (
+    order_id     SERIAL PRIMARY KEY,
+    user_id      INTEGER NOT NULL REFERENCES users (id),
+    session_id   TEXT,
+    payment_id   TEXT,
+    total_amount NUMERIC(10, 2) NOT NULL,
+    currency     TEXT NOT NULL DEFAULT 'INR',
+    status       TEXT NOT NULL DEFAULT 'pending',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS orders_user_id_created_at_idx ON orders (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS orders_session_id_idx ON orders (session_id);
+
+CREATE TABLE IF NOT EXISTS order_items (
+    id         SERIAL PRIMARY KEY,
+    order_id   INTEGER NOT NULL REFERENCES orders (order_id) ON DELETE CASCADE,
+    item_id    INTEGER NOT NULL REFERENCES FoodItems (id),
+    quantity   INTEGER NOT NULL CHECK (quantity > 0),
+    price      NUMERIC(10, 2) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS order_items_order_id_idx ON order_items (order_id);
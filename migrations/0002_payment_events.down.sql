@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS orders_payment_id_idx;
+DROP TABLE IF EXISTS payment_events;
@@ -0,0 +1,11 @@
+-- Stripe webhook deliveries that have been applied to an order. Stripe may
+-- deliver the same event more than once; the primary key makes processing
+-- idempotent.
+CREATE TABLE IF NOT EXISTS payment_events (
+    event_id     TEXT PRIMARY KEY,
+    event_type   TEXT NOT NULL,
+    order_id     INTEGER REFERENCES orders (order_id) ON DELETE SET NULL,
+    processed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS orders_payment_id_idx ON orders (payment_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS orders_cart_id_idx;
+ALTER TABLE orders DROP COLUMN IF EXISTS cart_id;
@@ -0,0 +1,5 @@
+-- Orders checked out from a persisted cart remember which cart they were
+-- snapshotted from.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS cart_id INTEGER REFERENCES cart (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS orders_cart_id_idx ON orders (cart_id);
@@ -0,0 +1,15 @@
+-- Responses of requests sent with an Idempotency-Key header, replayed when
+-- the client retries with the same key. status_code is NULL while the first
+-- request is still running.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id       INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    key           TEXT NOT NULL,
+    request_hash  TEXT NOT NULL,
+    status_code   INTEGER,
+    response_body BYTEA,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at  TIMESTAMPTZ,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_created_at_idx ON idempotency_keys (created_at);
@@ -0,0 +1,19 @@
+-- Every status an order has been in, who moved it there and when.
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id            SERIAL PRIMARY KEY,
+    order_id      INTEGER NOT NULL REFERENCES orders (order_id) ON DELETE CASCADE,
+    from_status   TEXT,
+    to_status     TEXT NOT NULL,
+    actor         TEXT NOT NULL,
+    actor_user_id INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    note          TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS order_status_history_order_id_idx ON order_status_history (order_id, created_at);
+
+-- Existing orders start their history at their current status.
+INSERT INTO order_status_history (order_id, to_status, actor, created_at)
+SELECT o.order_id, o.status, 'system', o.updated_at
+FROM orders o
+WHERE NOT EXISTS (SELECT 1 FROM order_status_history h WHERE h.order_id = o.order_id);
@@ -0,0 +1,14 @@
+-- Money returned to customers through the payment provider. An order is
+-- refunded once its refunds add up to its total.
+CREATE TABLE IF NOT EXISTS refunds (
+    id                 SERIAL PRIMARY KEY,
+    order_id           INTEGER NOT NULL REFERENCES orders (order_id) ON DELETE CASCADE,
+    provider_refund_id TEXT NOT NULL UNIQUE,
+    amount             NUMERIC(10, 2) NOT NULL CHECK (amount > 0),
+    status             TEXT NOT NULL,
+    reason             TEXT NOT NULL DEFAULT '',
+    actor_user_id      INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS refunds_order_id_idx ON refunds (order_id);
@@ -0,0 +1,7 @@
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS delivery_phone,
+    DROP COLUMN IF EXISTS delivery_postal_code,
+    DROP COLUMN IF EXISTS delivery_city,
+    DROP COLUMN IF EXISTS delivery_street,
+    DROP COLUMN IF EXISTS delivery_name,
+    DROP COLUMN IF EXISTS address_id;
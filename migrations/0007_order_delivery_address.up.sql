@@ -0,0 +1,10 @@
+-- Orders keep a copy of the address they are delivered to, so editing or
+-- deleting the address later does not change past orders. address_id only
+-- links back to the address while it still exists.
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS address_id INTEGER REFERENCES addresses (id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS delivery_name TEXT,
+    ADD COLUMN IF NOT EXISTS delivery_street TEXT,
+    ADD COLUMN IF NOT EXISTS delivery_city TEXT,
+    ADD COLUMN IF NOT EXISTS delivery_postal_code TEXT,
+    ADD COLUMN IF NOT EXISTS delivery_phone TEXT;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS addresses_one_primary_idx;
@@ -0,0 +1,11 @@
+-- Each user has exactly one primary address once they have any. Extra
+-- primaries keep only the oldest, and users left without one get their
+-- oldest address promoted before the index enforces it.
+UPDATE addresses a SET is_primary = FALSE
+WHERE a.is_primary
+  AND EXISTS (SELECT 1 FROM addresses b WHERE b.user_id = a.user_id AND b.is_primary AND b.id < a.id);
+
+UPDATE addresses SET is_primary = TRUE
+WHERE id IN (SELECT MIN(id) FROM addresses GROUP BY user_id HAVING NOT bool_or(is_primary));
+
+CREATE UNIQUE INDEX IF NOT EXISTS addresses_one_primary_idx ON addresses (user_id) WHERE is_primary;
@@ -0,0 +1,18 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- One-time codes sent by SMS to log in or to verify a phone number. code_hash
+-- is keyed with the server secret, so the short codes cannot be recovered
+-- from the table by brute force.
+CREATE TABLE IF NOT EXISTS phone_otps (
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    phone      TEXT NOT NULL,
+    purpose    TEXT NOT NULL CONSTRAINT phone_otps_purpose_check CHECK (purpose IN ('login', 'verify')),
+    code_hash  TEXT NOT NULL,
+    attempts   INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS phone_otps_user_id_idx ON phone_otps (user_id, purpose, created_at);
@@ -0,0 +1,14 @@
+-- Audit log of password logins, also used to throttle repeated failures.
+-- email is whatever was typed, so attempts on unknown accounts are counted
+-- too; user_id is set when it matched an account.
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id         BIGSERIAL PRIMARY KEY,
+    email      TEXT NOT NULL,
+    ip         TEXT NOT NULL,
+    user_id    INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    result     TEXT NOT NULL CHECK (result IN ('succeeded', 'failed', 'throttled')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS login_attempts_email_idx ON login_attempts (email, created_at);
+CREATE INDEX IF NOT EXISTS login_attempts_ip_idx ON login_attempts (ip, created_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS restaurant_owners;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
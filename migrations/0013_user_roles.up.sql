@@ -0,0 +1,13 @@
+-- Roles decide what a user may do beyond ordering food for themselves.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'customer'
+    CONSTRAINT users_role_check CHECK (role IN ('customer', 'restaurant_owner', 'courier', 'admin'));
+
+-- Restaurant owners act only on orders from the restaurants they own.
+CREATE TABLE IF NOT EXISTS restaurant_owners (
+    restaurant_id INTEGER NOT NULL REFERENCES restaurantsdata (id) ON DELETE CASCADE,
+    user_id       INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (restaurant_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS restaurant_owners_user_id_idx ON restaurant_owners (user_id);
@@ -0,0 +1,18 @@
+-- Orders record the restaurant they were placed with, so restaurant owners'
+-- access is checked against the order itself rather than today's menu.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS restaurant_id INTEGER REFERENCES restaurantsdata (id) ON DELETE SET NULL;
+
+-- Existing orders take the restaurant of their first item, found through the
+-- menu as checkout found it.
+UPDATE orders o SET restaurant_id = (
+    SELECT r.id
+    FROM order_items oi
+    JOIN FoodItems f ON f.id = oi.item_id
+    JOIN restaurantsdata r ON r.cloudimageid = f.cloudimageid
+    WHERE oi.order_id = o.order_id
+    ORDER BY oi.id, r.id
+    LIMIT 1
+)
+WHERE o.restaurant_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS orders_restaurant_id_idx ON orders (restaurant_id);
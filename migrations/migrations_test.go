@@ -0,0 +1,301 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d_%s: versions must run 1, 2, 3… without gaps", m.Version, m.Name)
+		}
+		if m.Name == "" || m.Up == "" || m.Down == "" {
+			t.Errorf("migration %d_%s: needs a name, an up and a down script", m.Version, m.Name)
+		}
+	}
+}
+
+func TestUpAppliesPendingMigrationsInOrder(t *testing.T) {
+	all := mustLoad(t)
+	db, fake := openFake(t)
+
+	applied, err := Up(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := versions(all); !reflect.DeepEqual(applied, want) {
+		t.Errorf("Up applied %v, want %v", applied, want)
+	}
+	var want []string
+	for _, m := range all {
+		want = append(want, m.Up)
+	}
+	if !reflect.DeepEqual(fake.executed, want) {
+		t.Errorf("Up ran %d scripts out of order or more than once", len(fake.executed))
+	}
+	if fake.locked {
+		t.Error("Up did not release the migration lock")
+	}
+
+	// Running again changes nothing.
+	fake.executed = nil
+	if applied, err := Up(context.Background(), db); err != nil || len(applied) != 0 || len(fake.executed) != 0 {
+		t.Errorf("second Up applied %v, ran %d scripts, err %v; want nothing", applied, len(fake.executed), err)
+	}
+	if pending, err := Pending(context.Background(), db); err != nil || pending != 0 {
+		t.Errorf("Pending = %d, %v; want 0", pending, err)
+	}
+}
+
+func TestPendingIsReadOnly(t *testing.T) {
+	all := mustLoad(t)
+	db, fake := openFake(t)
+
+	if pending, err := Pending(context.Background(), db); err != nil || pending != len(all) {
+		t.Errorf("Pending = %d, %v; want %d", pending, err, len(all))
+	}
+	if fake.created || len(fake.executed) != 0 {
+		t.Error("Pending changed a database the migrations never ran against")
+	}
+}
+
+func TestUpSkipsAppliedMigrations(t *testing.T) {
+	all := mustLoad(t)
+	db, fake := openFake(t)
+	fake.applied[all[0].Version] = time.Now()
+
+	applied, err := Up(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := versions(all[1:]); !reflect.DeepEqual(applied, want) {
+		t.Errorf("Up applied %v, want %v", applied, want)
+	}
+	for _, script := range fake.executed {
+		if script == all[0].Up {
+			t.Error("Up ran an applied migration again")
+		}
+	}
+}
+
+func TestUpStopsAtAFailingMigration(t *testing.T) {
+	all := mustLoad(t)
+	db, fake := openFake(t)
+	last := all[len(all)-1]
+	fake.failOn = last.Up
+
+	applied, err := Up(context.Background(), db)
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("migration %d_%s", last.Version, last.Name)) {
+		t.Fatalf("Up = %v, want the failure of migration %d", err, last.Version)
+	}
+	if want := versions(all[:len(all)-1]); !reflect.DeepEqual(applied, want) {
+		t.Errorf("Up applied %v, want %v", applied, want)
+	}
+	// The failed migration's transaction is rolled back, so it is still
+	// pending.
+	if _, ok := fake.applied[last.Version]; ok {
+		t.Errorf("failed migration %d is recorded as applied", last.Version)
+	}
+	if fake.locked {
+		t.Error("Up did not release the migration lock")
+	}
+}
+
+func TestDownRevertsTheLatestMigrations(t *testing.T) {
+	all := mustLoad(t)
+	db, fake := openFake(t)
+	if _, err := Up(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+	last := all[len(all)-1]
+
+	fake.executed = nil
+	reverted, err := Down(context.Background(), db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reverted, []int{last.Version}) || !reflect.DeepEqual(fake.executed, []string{last.Down}) {
+		t.Errorf("Down reverted %v running %d scripts, want only %d", reverted, len(fake.executed), last.Version)
+	}
+
+	statuses, err := List(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if applied := s.AppliedAt != nil; applied != (s.Version != last.Version) {
+			t.Errorf("migration %d applied = %v after reverting %d", s.Version, applied, last.Version)
+		}
+	}
+}
+
+func mustLoad(t *testing.T) []Migration {
+	t.Helper()
+	migrations, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return migrations
+}
+
+func versions(migrations []Migration) []int {
+	var v []int
+	for _, m := range migrations {
+		v = append(v, m.Version)
+	}
+	return v
+}
+
+// fakeDB understands the statements the runner issues about
+// schema_migrations and the advisory lock, and records every other
+// statement as a migration script.
+type fakeDB struct {
+	mu       sync.Mutex
+	applied  map[int]time.Time
+	executed []string
+	// created is set once schema_migrations has been created.
+	created bool
+	locked   bool
+	// failOn makes executing this script fail.
+	failOn string
+}
+
+func openFake(t *testing.T) (*sql.DB, *fakeDB) {
+	fake := &fakeDB{applied: make(map[int]time.Time)}
+	db := sql.OpenDB(fake)
+	t.Cleanup(func() { db.Close() })
+	return db, fake
+}
+
+func (f *fakeDB) Connect(ctx context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                            { return nil }
+
+type fakeConn struct {
+	db *fakeDB
+	// tx holds the changes of the open transaction until it commits.
+	tx *fakeTx
+}
+
+type fakeTx struct {
+	conn     *fakeConn
+	executed []string
+	insert   []int
+	delete   []int
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("fake: prepared statements are not supported")
+}
+func (c *fakeConn) Close() error { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.tx = &fakeTx{conn: c}
+	return c.tx, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	f := c.db
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	q := strings.TrimSpace(query)
+	switch {
+	case strings.HasPrefix(q, "SELECT pg_advisory_lock"):
+		f.locked = true
+	case strings.HasPrefix(q, "SELECT pg_advisory_unlock"):
+		f.locked = false
+	case strings.HasPrefix(q, "CREATE TABLE IF NOT EXISTS schema_migrations"):
+		f.created = true
+	case strings.HasPrefix(q, "INSERT INTO schema_migrations"):
+		c.tx.insert = append(c.tx.insert, int(args[0].Value.(int64)))
+	case strings.HasPrefix(q, "DELETE FROM schema_migrations"):
+		c.tx.delete = append(c.tx.delete, int(args[0].Value.(int64)))
+	case query == f.failOn:
+		return nil, errors.New("fake: syntax error")
+	default:
+		if c.tx == nil {
+			return nil, fmt.Errorf("fake: migration script run outside a transaction")
+		}
+		c.tx.executed = append(c.tx.executed, query)
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	f := c.db
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch strings.TrimSpace(query) {
+	case "SELECT to_regclass('schema_migrations') IS NOT NULL":
+		return &fakeRows{columns: []string{"exists"}, values: [][]driver.Value{{f.created}}}, nil
+	case "SELECT version, applied_at FROM schema_migrations":
+	default:
+		return nil, fmt.Errorf("fake: unexpected query %q", query)
+	}
+	if !f.created {
+		return nil, errors.New(`fake: relation "schema_migrations" does not exist`)
+	}
+	rows := &fakeRows{columns: []string{"version", "applied_at"}}
+	for version, at := range f.applied {
+		rows.values = append(rows.values, []driver.Value{int64(version), at})
+	}
+	return rows, nil
+}
+
+func (tx *fakeTx) Commit() error {
+	f := tx.conn.db
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.executed = append(f.executed, tx.executed...)
+	for _, v := range tx.insert {
+		f.applied[v] = time.Now()
+	}
+	for _, v := range tx.delete {
+		delete(f.applied, v)
+	}
+	tx.conn.tx = nil
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.conn.tx = nil
+	return nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
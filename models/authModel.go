@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// PasswordReset is a request to reset a user's password. The token itself
+// is only ever sent to the user; TokenHash is what is stored.
+type PasswordReset struct {
+	ID        int
+	UserID    int
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// EmailVerification is a link sent to confirm that a user owns Email.
+type EmailVerification struct {
+	ID        int
+	UserID    int
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// What a PhoneOTP may be used for.
+const (
+	OTPLogin  = "login"
+	OTPVerify = "verify"
+)
+
+// PhoneOTP is a one-time code sent by SMS to Phone. Either use proves the
+// user owns the phone.
+type PhoneOTP struct {
+	ID        int
+	UserID    int
+	Phone     string
+	Purpose   string
+	CodeHash  string
+	Attempts  int
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// Results of a LoginAttempt.
+const (
+	LoginSucceeded = "succeeded"
+	LoginFailed    = "failed"
+	// LoginThrottled attempts were refused before the password was checked.
+	LoginThrottled = "throttled"
+)
+
+// LoginAttempt records one password login. UserID is 0 when Email did not
+// match an account.
+type LoginAttempt struct {
+	ID        int64
+	Email     string
+	IP        string
+	UserID    int
+	Result    string
+	CreatedAt time.Time
+}
@@ -0,0 +1,38 @@
+package models
+
+type Cart struct {
+	ID           int         `json:"cart_id"`
+	UserID       int         `json:"user_id"`
+	RestaurantID int         `json:"restaurantid"`
+	TotalAmount  float64     `json:"total_amount"`
+	Items        []OrderItem `json:"items"`
+}
+
+// Reasons an item of a past order changed or was left out when reordering.
+const (
+	ReorderPriceChanged    = "price_changed"
+	ReorderQuantityReduced = "quantity_reduced"
+	ReorderUnavailable     = "unavailable"
+	ReorderOtherRestaurant = "other_restaurant"
+)
+
+// ReorderItemChange reports how an item of a past order differs in the cart
+// it was reordered into.
+type ReorderItemChange struct {
+	ItemID   int     `json:"item_id"`
+	Name     string  `json:"name,omitempty"`
+	Reason   string  `json:"reason"`
+	OldPrice float64 `json:"old_price"`
+	// NewPrice and Quantity are what went into the cart; both are 0 for
+	// removed items.
+	NewPrice float64 `json:"new_price"`
+	Quantity int     `json:"quantity"`
+}
+
+// ReorderResult is the cart a past order was copied into, with what changed
+// since the order was placed.
+type ReorderResult struct {
+	Cart    Cart                `json:"cart"`
+	Changed []ReorderItemChange `json:"changed"`
+	Removed []ReorderItemChange `json:"removed"`
+}
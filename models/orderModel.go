@@ -7,13 +7,85 @@ import (
 type Order struct {
 	OrderID     int         `json:"order_id"`
 	UserID      int         `json:"user_id"`
+	CartID      int         `json:"cart_id,omitempty"`
 	Items       []OrderItem `json:"items"`
 	TotalAmount float64     `json:"total_amount"`
 	Currency    string      `json:"currency"`
 	Status      string      `json:"status"`
 	Txnid       string      `json:"txnid"`
-	CreatedAt   time.Time   `json:"created_at"`
-	UpdatedAt   time.Time   `json:"updated_at"`
+	SessionID   string      `json:"session_id,omitempty"`
+	PaymentID   string      `json:"payment_id"`
+	// RestaurantID is zero for older orders whose restaurant could not be
+	// worked out from their items.
+	RestaurantID int `json:"restaurant_id,omitempty"`
+	// Address is nil for orders placed before checkout asked for one.
+	Address *DeliveryAddress `json:"address,omitempty"`
+	Refunds []Refund         `json:"refunds,omitempty"`
+	// History is only loaded for a single order.
+	History   []OrderStatusChange `json:"history,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// DeliveryAddress is the copy of a user's address taken when an order is
+// placed. AddressID is zero once the address itself has been deleted.
+type DeliveryAddress struct {
+	AddressID  int    `json:"address_id,omitempty"`
+	Name       string `json:"name"`
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	PostalCode string `json:"postalCode"`
+	Phone      string `json:"phone"`
+}
+
+// NewDeliveryAddress snapshots address for an order.
+func NewDeliveryAddress(address Address) *DeliveryAddress {
+	return &DeliveryAddress{
+		AddressID:  address.ID,
+		Name:       address.Name,
+		Street:     address.Street,
+		City:       address.City,
+		PostalCode: address.PostalCode,
+		Phone:      address.Phone,
+	}
+}
+
+// OrderPage is one page of an order listing.
+type OrderPage struct {
+	Orders []Order `json:"orders"`
+	// NextCursor fetches the following page; it is empty on the last one.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// RefundedAmount is how much of the order has been returned to the customer,
+// including refunds the provider is still processing.
+func (o Order) RefundedAmount() float64 {
+	var total float64
+	for _, refund := range o.Refunds {
+		if refund.Status != RefundFailed && refund.Status != RefundCanceled {
+			total += refund.Amount
+		}
+	}
+	return total
+}
+
+// Refund statuses that mean no money was returned. Other statuses are the
+// provider's own, such as "pending" and "succeeded".
+const (
+	RefundFailed   = "failed"
+	RefundCanceled = "canceled"
+)
+
+// Refund is money returned for an order through the payment provider.
+type Refund struct {
+	ID               int       `json:"id"`
+	OrderID          int       `json:"order_id"`
+	ProviderRefundID string    `json:"provider_refund_id"`
+	Amount           float64   `json:"amount"`
+	Status           string    `json:"status"`
+	Reason           string    `json:"reason,omitempty"`
+	ActorUserID      int       `json:"actor_user_id,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
 }
 
 type OrderItem struct {
@@ -25,7 +97,11 @@ type OrderItem struct {
 	RestaurantID int    `json:"restrauntId"`
 }
 
+// PaymentRequest is the checkout body. Only item IDs, quantities and
+// restaurant IDs are used; prices and Amount are recomputed on the server.
+// AddressID must name one of the user's addresses.
 type PaymentRequest struct {
-	Items   []OrderItem `json:"items"`
-	Amount  int `json:"amount"`
+	Items     []OrderItem `json:"items"`
+	Amount    int         `json:"amount"`
+	AddressID int         `json:"address_id"`
 }
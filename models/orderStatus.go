@@ -0,0 +1,144 @@
+package models
+
+import "time"
+
+// Order statuses. An order starts pending, is paid (completed) through the
+// payment provider and then moves through fulfilment to delivered.
+const (
+	OrderPending = "pending"
+	// OrderCompleted means the payment completed; the restaurant has not
+	// accepted the order yet. The name predates fulfilment tracking.
+	OrderCompleted         = "completed"
+	OrderExpired           = "expired"
+	OrderFailed            = "failed"
+	OrderAccepted          = "accepted"
+	OrderPreparing         = "preparing"
+	OrderOutForDelivery    = "out_for_delivery"
+	OrderDelivered         = "delivered"
+	OrderCancelled         = "cancelled"
+	OrderRefunded          = "refunded"
+	OrderPartiallyRefunded = "partially_refunded"
+)
+
+// OrderActor is who moves an order from one status to another.
+type OrderActor string
+
+const (
+	ActorCustomer   OrderActor = "customer"
+	ActorRestaurant OrderActor = "restaurant"
+	ActorCourier    OrderActor = "courier"
+	ActorAdmin      OrderActor = "admin"
+	// ActorSystem is the server itself, acting on payment provider events.
+	ActorSystem OrderActor = "system"
+)
+
+// ActorForRole returns the actor a user with role acts as on orders they do
+// not own.
+func ActorForRole(role string) OrderActor {
+	switch role {
+	case RoleRestaurantOwner:
+		return ActorRestaurant
+	case RoleCourier:
+		return ActorCourier
+	case RoleAdmin:
+		return ActorAdmin
+	default:
+		return ActorCustomer
+	}
+}
+
+// orderTransitions lists, for each status, the statuses it may move to and
+// who may make the move. Admins may make any move a person could.
+var orderTransitions = map[string]map[string][]OrderActor{
+	OrderPending: {
+		OrderCompleted: {ActorSystem},
+		OrderExpired:   {ActorSystem},
+		OrderFailed:    {ActorSystem},
+		OrderCancelled: {ActorCustomer},
+	},
+	OrderFailed: {
+		OrderCompleted: {ActorSystem},
+		OrderExpired:   {ActorSystem},
+		OrderCancelled: {ActorCustomer},
+	},
+	OrderCompleted: {
+		OrderAccepted:          {ActorRestaurant},
+		OrderCancelled:         {ActorCustomer, ActorRestaurant},
+		OrderRefunded:          {ActorSystem},
+		OrderPartiallyRefunded: {ActorSystem},
+	},
+	OrderAccepted: {
+		OrderPreparing: {ActorRestaurant},
+		OrderCancelled: {ActorRestaurant},
+	},
+	OrderPreparing: {
+		OrderOutForDelivery: {ActorCourier},
+	},
+	OrderOutForDelivery: {
+		OrderDelivered: {ActorCourier},
+	},
+	OrderDelivered: {
+		OrderRefunded:          {ActorSystem},
+		OrderPartiallyRefunded: {ActorSystem},
+	},
+	OrderCancelled: {
+		OrderRefunded:          {ActorSystem},
+		OrderPartiallyRefunded: {ActorSystem},
+	},
+	OrderPartiallyRefunded: {
+		OrderRefunded:          {ActorSystem},
+		OrderPartiallyRefunded: {ActorSystem},
+	},
+}
+
+// CanTransitionOrder reports whether actor may move an order from one status
+// to another.
+func CanTransitionOrder(from, to string, actor OrderActor) bool {
+	for _, allowed := range orderTransitions[from][to] {
+		if allowed == actor || (actor == ActorAdmin && allowed != ActorSystem) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOrderStatus reports whether status is one of the statuses above.
+func IsOrderStatus(status string) bool {
+	if _, ok := orderTransitions[status]; ok {
+		return true
+	}
+	for _, next := range orderTransitions {
+		if _, ok := next[status]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOrderRefundable reports whether an order in status has a payment that can
+// still be refunded.
+func IsOrderRefundable(status string) bool {
+	return CanTransitionOrder(status, OrderPartiallyRefunded, ActorSystem)
+}
+
+// IsOrderInFulfilment reports whether the restaurant or courier is working
+// on an order in status.
+func IsOrderInFulfilment(status string) bool {
+	switch status {
+	case OrderAccepted, OrderPreparing, OrderOutForDelivery:
+		return true
+	}
+	return false
+}
+
+// OrderStatusChange is one entry of an order's status history.
+type OrderStatusChange struct {
+	OrderID    int        `json:"order_id"`
+	FromStatus string     `json:"from_status,omitempty"`
+	ToStatus   string     `json:"to_status"`
+	Actor      OrderActor `json:"actor,omitempty"`
+	// ActorUserID is the user who made the change; 0 for ActorSystem.
+	ActorUserID int       `json:"actor_user_id,omitempty"`
+	Note        string    `json:"note,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
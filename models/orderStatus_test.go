@@ -0,0 +1,69 @@
+package models
+
+import "testing"
+
+func TestCanTransitionOrder(t *testing.T) {
+	tests := []struct {
+		from, to string
+		actor    OrderActor
+		want     bool
+	}{
+		{OrderPending, OrderCompleted, ActorSystem, true},
+		{OrderPending, OrderCompleted, ActorAdmin, false},
+		{OrderPending, OrderCancelled, ActorCustomer, true},
+		{OrderFailed, OrderCompleted, ActorSystem, true},
+		{OrderCompleted, OrderAccepted, ActorRestaurant, true},
+		{OrderCompleted, OrderAccepted, ActorCustomer, false},
+		{OrderCompleted, OrderAccepted, ActorAdmin, true},
+		{OrderCompleted, OrderCancelled, ActorCustomer, true},
+		{OrderAccepted, OrderCancelled, ActorCustomer, false},
+		{OrderAccepted, OrderCancelled, ActorRestaurant, true},
+		{OrderAccepted, OrderPreparing, ActorCourier, false},
+		{OrderPreparing, OrderOutForDelivery, ActorCourier, true},
+		{OrderPreparing, OrderOutForDelivery, ActorRestaurant, false},
+		{OrderOutForDelivery, OrderDelivered, ActorCourier, true},
+		{OrderDelivered, OrderRefunded, ActorSystem, true},
+		{OrderDelivered, OrderRefunded, ActorAdmin, false},
+		{OrderDelivered, OrderCancelled, ActorAdmin, false},
+		{OrderCancelled, OrderPartiallyRefunded, ActorSystem, true},
+		{OrderPartiallyRefunded, OrderRefunded, ActorSystem, true},
+		{OrderRefunded, OrderPartiallyRefunded, ActorSystem, false},
+		{OrderExpired, OrderCompleted, ActorSystem, false},
+		{OrderPending, OrderDelivered, ActorAdmin, false},
+	}
+	for _, tt := range tests {
+		if got := CanTransitionOrder(tt.from, tt.to, tt.actor); got != tt.want {
+			t.Errorf("CanTransitionOrder(%s, %s, %s) = %v, want %v", tt.from, tt.to, tt.actor, got, tt.want)
+		}
+	}
+}
+
+func TestIsOrderStatus(t *testing.T) {
+	for _, status := range []string{OrderPending, OrderExpired, OrderDelivered, OrderRefunded} {
+		if !IsOrderStatus(status) {
+			t.Errorf("IsOrderStatus(%q) = false", status)
+		}
+	}
+	for _, status := range []string{"", "paid", "Delivered"} {
+		if IsOrderStatus(status) {
+			t.Errorf("IsOrderStatus(%q) = true", status)
+		}
+	}
+}
+
+func TestIsOrderRefundable(t *testing.T) {
+	tests := map[string]bool{
+		OrderPending:           false,
+		OrderCompleted:         true,
+		OrderAccepted:          false,
+		OrderDelivered:         true,
+		OrderCancelled:         true,
+		OrderPartiallyRefunded: true,
+		OrderRefunded:          false,
+	}
+	for status, want := range tests {
+		if got := IsOrderRefundable(status); got != want {
+			t.Errorf("IsOrderRefundable(%s) = %v, want %v", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// OrderETA is when an order is expected to reach the customer.
+type OrderETA struct {
+	OrderID   int       `json:"order_id"`
+	ETA       time.Time `json:"eta"`
+	UpdatedBy int       `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CourierLocation is where the courier delivering an order last reported
+// being.
+type CourierLocation struct {
+	OrderID    int       `json:"order_id"`
+	CourierID  int       `json:"courier_id"`
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	ReportedAt time.Time `json:"reported_at"`
+}
@@ -1,11 +1,39 @@
 package models
 
+// Roles a user can hold. Everyone who signs up is a customer; other roles
+// are granted by an administrator.
+const (
+	RoleCustomer        = "customer"
+	RoleRestaurantOwner = "restaurant_owner"
+	RoleCourier         = "courier"
+	RoleAdmin           = "admin"
+)
+
+// IsRole reports whether role is one of the roles above.
+func IsRole(role string) bool {
+	switch role {
+	case RoleCustomer, RoleRestaurantOwner, RoleCourier, RoleAdmin:
+		return true
+	}
+	return false
+}
+
 type User struct {
 	Id       int    `json:"id" validate:"required"`
 	Name     string `json:"name" validate:"required, min=5 max=100"`
 	Email    string `json:"email" validate:"required, min=5 max=100"`
 	Phone    string `json:"phone" validate:"required, min=5 max=100"`
-	Password string `json:"password" validate:"required, min=5 max=100"`
+	// Password is the bcrypt hash once stored; handlers clear it before
+	// writing a user out.
+	Password string `json:"password,omitempty" validate:"required, min=5 max=100"`
+	Role     string `json:"role"`
+	// EmailVerified and PhoneVerified are cleared whenever the email or
+	// phone changes.
+	EmailVerified bool `json:"email_verified"`
+	PhoneVerified bool `json:"phone_verified"`
+	// SessionVersion is stored in each session; sessions carrying an older
+	// version are no longer accepted.
+	SessionVersion int `json:"-"`
 }
 
 type Address struct {
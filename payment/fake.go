@@ -0,0 +1,154 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// FakeSignatureHeader carries the signature of a Fake webhook delivery.
+const FakeSignatureHeader = "Fake-Signature"
+
+// Fake is an in-process Provider for local development and integration
+// tests. It never talks to a network and its IDs are derived from the order
+// ID, so runs are reproducible. Sessions complete and are paid as soon as
+// they are created; tests can move them with Expire.
+//
+// Webhook deliveries are JSON-encoded Events whose FakeSignatureHeader is
+// set to Sign(payload).
+type Fake struct {
+	webhookSecret string
+
+	mu       sync.Mutex
+	sessions map[string]Session
+	payments map[string]*fakePayment
+	refunds  int
+	// sessionKeys and refundKeys map idempotency keys to what they created.
+	sessionKeys map[string]string
+	refundKeys  map[string]Refund
+}
+
+type fakePayment struct {
+	amount   int64
+	refunded int64
+}
+
+func NewFake(webhookSecret string) *Fake {
+	return &Fake{
+		webhookSecret: webhookSecret,
+		sessions:      make(map[string]Session),
+		payments:      make(map[string]*fakePayment),
+		sessionKeys:   make(map[string]string),
+		refundKeys:    make(map[string]Refund),
+	}
+}
+
+func (p *Fake) CreateSession(ctx context.Context, req SessionRequest) (Session, error) {
+	var amount int64
+	for _, item := range req.LineItems {
+		amount += item.UnitAmount * item.Quantity
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if id, ok := p.sessionKeys[req.IdempotencyKey]; ok && req.IdempotencyKey != "" {
+		return p.sessions[id], nil
+	}
+	s := Session{
+		ID:           fmt.Sprintf("cs_fake_%d", req.OrderID),
+		ClientSecret: fmt.Sprintf("cs_fake_%d_secret", req.OrderID),
+		Status:       SessionComplete,
+		Paid:         true,
+		PaymentID:    fmt.Sprintf("pi_fake_%d", req.OrderID),
+	}
+	p.sessions[s.ID] = s
+	p.payments[s.PaymentID] = &fakePayment{amount: amount}
+	if req.IdempotencyKey != "" {
+		p.sessionKeys[req.IdempotencyKey] = s.ID
+	}
+	return s, nil
+}
+
+func (p *Fake) GetSession(ctx context.Context, sessionID string) (Session, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s, ok := p.sessions[sessionID]
+	if !ok {
+		return Session{}, ErrNotFound
+	}
+	return s, nil
+}
+
+func (p *Fake) ExpireSession(ctx context.Context, sessionID string) (Session, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s, ok := p.sessions[sessionID]
+	if !ok {
+		return Session{}, ErrNotFound
+	}
+	if s.Status == SessionOpen {
+		s.Status = SessionExpired
+		p.sessions[sessionID] = s
+	}
+	return s, nil
+}
+
+// Expire marks a session as abandoned, as if the customer never paid.
+func (p *Fake) Expire(sessionID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if s, ok := p.sessions[sessionID]; ok {
+		s.Status, s.Paid = SessionExpired, false
+		p.sessions[sessionID] = s
+	}
+}
+
+func (p *Fake) Refund(ctx context.Context, req RefundRequest) (Refund, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if r, ok := p.refundKeys[req.IdempotencyKey]; ok && req.IdempotencyKey != "" {
+		return r, nil
+	}
+	payment, ok := p.payments[req.PaymentID]
+	if !ok {
+		return Refund{}, ErrNotFound
+	}
+	remaining := payment.amount - payment.refunded
+	amount := req.Amount
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount <= 0 || amount > remaining {
+		return Refund{}, fmt.Errorf("payment: refund of %d exceeds refundable amount %d", amount, remaining)
+	}
+	payment.refunded += amount
+	p.refunds++
+	r := Refund{ID: fmt.Sprintf("re_fake_%d", p.refunds), Amount: amount, Status: "succeeded"}
+	if req.IdempotencyKey != "" {
+		p.refundKeys[req.IdempotencyKey] = r
+	}
+	return r, nil
+}
+
+// Sign returns the signature VerifyWebhook expects for payload.
+func (p *Fake) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(p.webhookSecret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (p *Fake) VerifyWebhook(payload []byte, header http.Header) (Event, error) {
+	if !hmac.Equal([]byte(p.Sign(payload)), []byte(header.Get(FakeSignatureHeader))) {
+		return Event{}, ErrInvalidSignature
+	}
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return Event{}, err
+	}
+	return event, nil
+}
@@ -0,0 +1,146 @@
+// Package payment hides the payment gateway behind Provider so handlers do
+// not depend on a particular gateway's SDK. Stripe is the production
+// implementation; Fake is a deterministic stand-in for local development and
+// integration tests.
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+)
+
+var (
+	// ErrInvalidSignature is returned by VerifyWebhook for payloads that were
+	// not signed by the gateway.
+	ErrInvalidSignature = errors.New("payment: invalid webhook signature")
+	// ErrNotFound is returned for unknown sessions and payments.
+	ErrNotFound = errors.New("payment: not found")
+)
+
+// Provider is a payment gateway.
+type Provider interface {
+	// CreateSession opens a hosted checkout for an order.
+	CreateSession(ctx context.Context, req SessionRequest) (Session, error)
+	// GetSession fetches the current state of a checkout session.
+	GetSession(ctx context.Context, sessionID string) (Session, error)
+	// ExpireSession closes an open checkout session so it can no longer be
+	// paid and returns its final state. Sessions that are already complete
+	// or expired are returned as they are.
+	ExpireSession(ctx context.Context, sessionID string) (Session, error)
+	// Refund returns money for a completed payment.
+	Refund(ctx context.Context, req RefundRequest) (Refund, error)
+	// VerifyWebhook authenticates a webhook delivery from its body and
+	// headers and decodes it.
+	VerifyWebhook(payload []byte, header http.Header) (Event, error)
+}
+
+// New returns the provider selected by cfg.Payment.Provider.
+func New(cfg *config.Config) (Provider, error) {
+	switch cfg.Payment.Provider {
+	case config.PaymentStripe:
+		return NewStripe(cfg.Stripe.SecretKey, cfg.Stripe.WebhookSecret), nil
+	case config.PaymentFake:
+		return NewFake(cfg.Payment.FakeWebhookSecret), nil
+	default:
+		return nil, fmt.Errorf("payment: unknown provider %q", cfg.Payment.Provider)
+	}
+}
+
+// LineItem is one row of the checkout page. Amounts are in the currency's
+// minor unit (paise for INR).
+type LineItem struct {
+	Name        string
+	Description string
+	ImageURL    string
+	UnitAmount  int64
+	Quantity    int64
+}
+
+type SessionRequest struct {
+	OrderID   int
+	Currency  string
+	LineItems []LineItem
+	// ReturnURL is where the customer lands after checkout. The gateway's
+	// session ID placeholder is substituted by the provider.
+	ReturnURL string
+	// IdempotencyKey, when set, makes the gateway return the original
+	// session if the same request is sent again.
+	IdempotencyKey string
+}
+
+type SessionStatus string
+
+const (
+	SessionOpen     SessionStatus = "open"
+	SessionComplete SessionStatus = "complete"
+	SessionExpired  SessionStatus = "expired"
+)
+
+type Session struct {
+	ID           string
+	ClientSecret string
+	Status       SessionStatus
+	// Paid reports whether the money has been collected; a session can be
+	// complete while a delayed payment method is still settling.
+	Paid bool
+	// PaymentID identifies the payment once one has been attempted. Refunds
+	// are issued against it.
+	PaymentID     string
+	CustomerEmail string
+}
+
+type RefundRequest struct {
+	PaymentID string
+	// Amount in minor units; 0 refunds whatever has not been refunded yet.
+	Amount int64
+	// Reason is empty or ReasonRequestedByCustomer.
+	Reason string
+	// IdempotencyKey, when set, makes retries return the refund the first
+	// request issued.
+	IdempotencyKey string
+}
+
+// ReasonRequestedByCustomer marks a refund the customer asked for.
+const ReasonRequestedByCustomer = "requested_by_customer"
+
+type Refund struct {
+	ID     string `json:"id"`
+	Amount int64  `json:"amount"`
+	Status string `json:"status"`
+}
+
+// EventType is the gateway-neutral kind of a webhook event.
+type EventType string
+
+const (
+	EventCheckoutCompleted EventType = "checkout.completed"
+	EventCheckoutExpired   EventType = "checkout.expired"
+	EventPaymentFailed     EventType = "payment.failed"
+	EventRefunded          EventType = "payment.refunded"
+)
+
+// Event is a verified webhook delivery. Type is empty for events that do not
+// concern orders; they only need to be acknowledged.
+type Event struct {
+	ID string `json:"id"`
+	// GatewayType is the event name used by the gateway, for logging.
+	GatewayType string    `json:"gateway_type"`
+	Type        EventType `json:"type"`
+
+	// OrderID is the order reference attached at checkout, or 0 when the
+	// gateway did not echo it back.
+	OrderID   int    `json:"order_id"`
+	SessionID string `json:"session_id"`
+	PaymentID string `json:"payment_id"`
+	// Paid is set on EventCheckoutCompleted once the money is collected.
+	Paid bool `json:"paid"`
+
+	// Refunds lists the refunds of the payment reported by EventRefunded,
+	// including ones made outside this service, such as in the gateway's
+	// dashboard.
+	Refunds []Refund `json:"refunds,omitempty"`
+}
@@ -0,0 +1,120 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stripe/stripe-go/v81/webhook"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// signed returns a Stripe event whose data object is object, with the
+// headers of a delivery signed with secret.
+func signed(secret, eventType, object string) ([]byte, http.Header) {
+	payload := fmt.Sprintf(`{"id":"evt_1","object":"event","type":%q,"data":{"object":%s}}`, eventType, object)
+	s := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: []byte(payload), Secret: secret})
+	header := http.Header{}
+	header.Set("Stripe-Signature", s.Header)
+	return s.Payload, header
+}
+
+func TestStripeVerifyWebhookTranslatesEvents(t *testing.T) {
+	p := NewStripe("sk_test_123", testWebhookSecret)
+
+	tests := []struct {
+		eventType, object string
+		want              Event
+	}{
+		{
+			"checkout.session.completed",
+			`{"id":"cs_1","object":"checkout.session","payment_status":"paid","payment_intent":"pi_1","metadata":{"order_id":"7"}}`,
+			Event{Type: EventCheckoutCompleted, OrderID: 7, SessionID: "cs_1", PaymentID: "pi_1", Paid: true},
+		},
+		{
+			// Delayed payment methods complete the session unpaid.
+			"checkout.session.completed",
+			`{"id":"cs_1","object":"checkout.session","payment_status":"unpaid","metadata":{"order_id":"7"}}`,
+			Event{Type: EventCheckoutCompleted, OrderID: 7, SessionID: "cs_1"},
+		},
+		{
+			// ... and report the outcome later.
+			"checkout.session.async_payment_succeeded",
+			`{"id":"cs_1","object":"checkout.session","payment_status":"paid","payment_intent":"pi_1","metadata":{"order_id":"7"}}`,
+			Event{Type: EventCheckoutCompleted, OrderID: 7, SessionID: "cs_1", PaymentID: "pi_1", Paid: true},
+		},
+		{
+			"checkout.session.async_payment_failed",
+			`{"id":"cs_1","object":"checkout.session","payment_status":"unpaid","payment_intent":"pi_1","metadata":{"order_id":"7"}}`,
+			Event{Type: EventPaymentFailed, OrderID: 7, SessionID: "cs_1", PaymentID: "pi_1"},
+		},
+		{
+			"checkout.session.expired",
+			`{"id":"cs_1","object":"checkout.session","payment_status":"unpaid","metadata":{"order_id":"7"}}`,
+			Event{Type: EventCheckoutExpired, OrderID: 7, SessionID: "cs_1"},
+		},
+		{
+			"payment_intent.payment_failed",
+			`{"id":"pi_1","object":"payment_intent","metadata":{"order_id":"7"}}`,
+			Event{Type: EventPaymentFailed, OrderID: 7, PaymentID: "pi_1"},
+		},
+		{
+			"charge.refunded",
+			`{"id":"ch_1","object":"charge","payment_intent":"pi_1","refunds":{"object":"list","data":[{"id":"re_1","object":"refund","amount":4000,"status":"succeeded"}]}}`,
+			Event{Type: EventRefunded, PaymentID: "pi_1", Refunds: []Refund{{ID: "re_1", Amount: 4000, Status: "succeeded"}}},
+		},
+		{
+			// Newer API versions leave the refunds out of the charge.
+			"charge.refunded",
+			`{"id":"ch_1","object":"charge","payment_intent":"pi_1"}`,
+			Event{},
+		},
+		{
+			"refund.updated",
+			`{"id":"re_2","object":"refund","payment_intent":"pi_1","amount":2500,"status":"failed"}`,
+			Event{Type: EventRefunded, PaymentID: "pi_1", Refunds: []Refund{{ID: "re_2", Amount: 2500, Status: "failed"}}},
+		},
+		{
+			// Sessions created before orders carried metadata.
+			"checkout.session.completed",
+			`{"id":"cs_1","object":"checkout.session","payment_status":"paid"}`,
+			Event{Type: EventCheckoutCompleted, SessionID: "cs_1", Paid: true},
+		},
+		{"customer.created", `{"id":"cus_1","object":"customer"}`, Event{}},
+	}
+	for _, tt := range tests {
+		payload, header := signed(testWebhookSecret, tt.eventType, tt.object)
+		got, err := p.VerifyWebhook(payload, header)
+		if err != nil {
+			t.Errorf("%s: %v", tt.eventType, err)
+			continue
+		}
+		tt.want.ID, tt.want.GatewayType = "evt_1", tt.eventType
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %s:\ngot  %+v\nwant %+v", tt.eventType, tt.object, got, tt.want)
+		}
+	}
+}
+
+func TestStripeVerifyWebhookRejectsBadSignatures(t *testing.T) {
+	p := NewStripe("sk_test_123", testWebhookSecret)
+	object := `{"id":"cs_1","object":"checkout.session","payment_status":"paid"}`
+
+	payload, header := signed("whsec_other", "checkout.session.completed", object)
+	if _, err := p.VerifyWebhook(payload, header); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("wrong secret: %v, want ErrInvalidSignature", err)
+	}
+
+	payload, _ = signed(testWebhookSecret, "checkout.session.completed", object)
+	if _, err := p.VerifyWebhook(payload, http.Header{}); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("no signature: %v, want ErrInvalidSignature", err)
+	}
+
+	payload, header = signed(testWebhookSecret, "checkout.session.completed", object)
+	if _, err := NewStripe("sk_test_123", "").VerifyWebhook(payload, header); err == nil || errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("no secret configured: %v, want a configuration error", err)
+	}
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryTake(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+
+	take := func(key string) Result {
+		t.Helper()
+		result, err := m.Take(context.Background(), key, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	// A full bucket can be spent in a burst.
+	for want := 2; want >= 0; want-- {
+		if r := take("a"); !r.Allowed || r.Remaining != want {
+			t.Fatalf("burst: got %+v, want allowed with %d remaining", r, want)
+		}
+	}
+	r := take("a")
+	if r.Allowed || r.RetryAfter != time.Second || r.Reset != 3*time.Second {
+		t.Errorf("empty bucket: got %+v, want refused, retry after 1s, reset in 3s", r)
+	}
+	if r := take("b"); !r.Allowed {
+		t.Errorf("other key: got %+v, want allowed", r)
+	}
+
+	// The bucket refills a token per second.
+	now = now.Add(500 * time.Millisecond)
+	if r := take("a"); r.Allowed || r.RetryAfter != 500*time.Millisecond {
+		t.Errorf("half a token: got %+v, want refused, retry after 500ms", r)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if r := take("a"); !r.Allowed || r.Remaining != 0 {
+		t.Errorf("one token: got %+v, want allowed with 0 remaining", r)
+	}
+	now = now.Add(time.Hour)
+	if r := take("a"); !r.Allowed || r.Remaining != 2 {
+		t.Errorf("refilled: got %+v, want allowed with 2 remaining", r)
+	}
+}
+
+func TestMemorySweepsFullBuckets(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	limit := Limit{Requests: 5, Period: time.Second}
+
+	for _, key := range []string{"a", "b", "c"} {
+		if _, err := m.Take(context.Background(), key, limit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now = now.Add(sweepInterval)
+	if _, err := m.Take(context.Background(), "d", limit); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.buckets) != 1 {
+		t.Errorf("%d buckets after a sweep, want 1", len(m.buckets))
+	}
+}
@@ -0,0 +1,40 @@
+// Package ratelimit meters requests with token buckets. Memory keeps the
+// buckets in process, which is enough for a single instance; replicas that
+// must share limits plug a shared backend in behind Limiter.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limit allows Requests requests per Period. A bucket holds up to Requests
+// tokens and refills evenly over Period, so a full bucket can be spent in a
+// burst. A Limit with no Requests disables limiting.
+type Limit struct {
+	Requests int           `yaml:"requests"`
+	Period   time.Duration `yaml:"period"`
+}
+
+// Enabled reports whether l limits anything.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// Result describes a bucket after a request has been counted against it.
+type Result struct {
+	Allowed bool
+	// Remaining is how many more requests would be allowed right now.
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next request is allowed, when this
+	// one was not.
+	RetryAfter time.Duration
+}
+
+// Limiter counts requests against per-key buckets.
+type Limiter interface {
+	// Take spends a token from key's bucket under limit if one is left.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
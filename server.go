@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+)
+
+// serve runs the HTTP server (and the optional HTTPS redirect listener) until
+// it fails or the process receives SIGINT/SIGTERM. On a signal it stops
+// accepting connections, calls onShutdown so long-lived requests such as
+// event streams can finish, and waits up to ShutdownTimeout for in-flight
+// requests, then runs the cleanup functions in order.
+func serve(cfg config.ServerConfig, handler http.Handler, onShutdown func(), cleanup ...func()) error {
+	defer func() {
+		for _, fn := range cleanup {
+			fn()
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+	if onShutdown != nil {
+		server.RegisterOnShutdown(onShutdown)
+	}
+	servers := []*http.Server{server}
+	errCh := make(chan error, 2)
+
+	switch cfg.Mode {
+	case config.ModeTLS:
+		if _, err := os.Stat(cfg.TLSCertFile); os.IsNotExist(err) {
+			return fmt.Errorf("TLS certificate file not found at %v", cfg.TLSCertFile)
+		}
+		if _, err := os.Stat(cfg.TLSKeyFile); os.IsNotExist(err) {
+			return fmt.Errorf("TLS key file not found at %v", cfg.TLSKeyFile)
+		}
+		server.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS13,
+		}
+
+		log.Printf("Starting the server on https://localhost%v\n", cfg.Addr)
+		go func() { errCh <- server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile) }()
+
+		if cfg.RedirectAddr != "" {
+			redirect := &http.Server{
+				Addr:              cfg.RedirectAddr,
+				Handler:           redirectToHTTPS(cfg.Addr),
+				ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+				IdleTimeout:       cfg.IdleTimeout,
+			}
+			servers = append(servers, redirect)
+
+			log.Printf("Redirecting http://localhost%v to HTTPS\n", cfg.RedirectAddr)
+			go func() { errCh <- redirect.ListenAndServe() }()
+		}
+
+	case config.ModeHTTP:
+		log.Printf("Starting the server on http://localhost%v\n", cfg.Addr)
+		go func() { errCh <- server.ListenAndServe() }()
+	}
+
+	var serveErr error
+	select {
+	case serveErr = <-errCh:
+		if errors.Is(serveErr, http.ErrServerClosed) {
+			serveErr = nil
+		}
+	case <-ctx.Done():
+		log.Println("Shutdown signal received, draining in-flight requests")
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	for _, s := range servers {
+		if err := s.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Error shutting down server on %v: %v", s.Addr, err)
+		}
+	}
+
+	if serveErr != nil {
+		return fmt.Errorf("error starting server: %w", serveErr)
+	}
+	log.Println("Server stopped")
+	return nil
+}
+
+// redirectToHTTPS sends every request to the same host and path on the TLS
+// listener at tlsAddr.
+func redirectToHTTPS(tlsAddr string) http.Handler {
+	_, tlsPort, _ := net.SplitHostPort(tlsAddr)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if tlsPort != "" && tlsPort != "443" {
+			host = net.JoinHostPort(host, tlsPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
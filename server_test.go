@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		tlsAddr, target, want string
+	}{
+		{":443", "http://example.com/orders?page=2", "https://example.com/orders?page=2"},
+		{":443", "http://example.com:8081/", "https://example.com/"},
+		{":8443", "http://example.com:8081/menu", "https://example.com:8443/menu"},
+		{"127.0.0.1:8443", "http://localhost/", "https://localhost:8443/"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		redirectToHTTPS(tt.tlsAddr).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+		if rec.Code != http.StatusPermanentRedirect {
+			t.Errorf("%s via %s: status = %d, want %d", tt.target, tt.tlsAddr, rec.Code, http.StatusPermanentRedirect)
+		}
+		if got := rec.Header().Get("Location"); got != tt.want {
+			t.Errorf("%s via %s: Location = %q, want %q", tt.target, tt.tlsAddr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Package sms sends text messages to users. Only Log, which writes messages
+// to the log for local development, is implemented; a gateway plugs in
+// behind Sender.
+package sms
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/config"
+)
+
+// Sender delivers a text message to a phone number.
+type Sender interface {
+	Send(ctx context.Context, phone, text string) error
+}
+
+// New returns the sender selected by cfg.SMS.Sender.
+func New(cfg *config.Config) (Sender, error) {
+	switch cfg.SMS.Sender {
+	case config.SMSLog:
+		return Log{}, nil
+	default:
+		return nil, fmt.Errorf("sms: unknown sender %q", cfg.SMS.Sender)
+	}
+}
+
+// Log writes messages to the log instead of sending them.
+type Log struct{}
+
+func (Log) Send(ctx context.Context, phone, text string) error {
+	log.Printf("SMS not sent (log sender) to %s: %s", phone, text)
+	return nil
+}
@@ -0,0 +1,1062 @@
+package store
+
+import (
+	"context"
+	"crypto/subtle"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+// Memory is an in-process implementation of every store interface. It keeps
+// the same ownership and uniqueness rules as the Postgres schema so handlers
+// can be exercised with httptest without a database.
+type Memory struct {
+	mu     sync.Mutex
+	nextID int
+
+	users         map[int]models.User
+	resets        map[int]models.PasswordReset
+	verifications map[int]models.EmailVerification
+	otps          map[int]models.PhoneOTP
+	loginAttempts []models.LoginAttempt
+	restaurants   map[string][]models.Restaurants
+	owners        map[int]map[int]bool
+	food          map[int]models.FoodItems
+	carts         map[int]*memoryCart
+	addresses     map[int]models.Address
+	orders        map[int]models.Order
+
+	statusHistory []models.OrderStatusChange
+	paymentEvents map[string]bool
+	idempotency   map[string]IdempotencyRecord
+}
+
+type memoryCart struct {
+	id           int
+	userID       int
+	restaurantID int
+	totalAmount  float64
+	active       bool
+	items        []models.OrderItem
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		users:         make(map[int]models.User),
+		resets:        make(map[int]models.PasswordReset),
+		verifications: make(map[int]models.EmailVerification),
+		otps:          make(map[int]models.PhoneOTP),
+		restaurants:   make(map[string][]models.Restaurants),
+		owners:        make(map[int]map[int]bool),
+		food:          make(map[int]models.FoodItems),
+		carts:         make(map[int]*memoryCart),
+		addresses:     make(map[int]models.Address),
+		orders:        make(map[int]models.Order),
+
+		paymentEvents: make(map[string]bool),
+		idempotency:   make(map[string]IdempotencyRecord),
+	}
+}
+
+// Store exposes m through the repository interfaces.
+func (m *Memory) Store() *Store {
+	return &Store{
+		Users:          memoryUserStore{m},
+		PasswordResets: memoryPasswordResetStore{m},
+		Verifications:  memoryEmailVerificationStore{m},
+		PhoneOTPs:      memoryPhoneOTPStore{m},
+		LoginAttempts:  memoryLoginAttemptStore{m},
+		Restaurants:    memoryRestaurantStore{m},
+		Food:           memoryFoodStore{m},
+		Carts:          memoryCartStore{m},
+		Addresses:      memoryAddressStore{m},
+		Orders:         memoryOrderStore{m},
+		Idempotency:    memoryIdempotencyStore{m},
+	}
+}
+
+// AddRestaurant seeds a restaurant listed under city.
+func (m *Memory) AddRestaurant(city string, restaurant models.Restaurants) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.restaurants[city] = append(m.restaurants[city], restaurant)
+}
+
+// AddFoodItem seeds a menu item.
+func (m *Memory) AddFoodItem(item models.FoodItems) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.food[item.Id] = item
+}
+
+// SetUserRole seeds a role granted outside the API.
+func (m *Memory) SetUserRole(userID int, role string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if user, ok := m.users[userID]; ok {
+		user.Role = role
+		m.users[userID] = user
+	}
+}
+
+func (m *Memory) id() int {
+	m.nextID++
+	return m.nextID
+}
+
+type memoryUserStore struct{ m *Memory }
+
+func (s memoryUserStore) Create(ctx context.Context, user *models.User) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if err := s.m.checkUnique(0, user.Email, user.Phone); err != nil {
+		return err
+	}
+	// As in Postgres, new users start as unverified customers whatever
+	// the caller filled in.
+	user.Id = s.m.id()
+	user.Role = models.RoleCustomer
+	user.EmailVerified, user.PhoneVerified = false, false
+	s.m.users[user.Id] = *user
+	return nil
+}
+
+func (s memoryUserStore) GetByID(ctx context.Context, id int) (models.User, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	user, ok := s.m.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (s memoryUserStore) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	for _, user := range s.m.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return models.User{}, ErrNotFound
+}
+
+func (s memoryUserStore) GetByPhone(ctx context.Context, phone string) (models.User, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	for _, user := range s.m.users {
+		if user.Phone == phone {
+			return user, nil
+		}
+	}
+	return models.User{}, ErrNotFound
+}
+
+func (s memoryUserStore) SetRole(ctx context.Context, userID int, role string) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	user, ok := s.m.users[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	user.Role = role
+	s.m.users[userID] = user
+	return nil
+}
+
+func (s memoryUserStore) UpdateProfile(ctx context.Context, user models.User) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	existing, ok := s.m.users[user.Id]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := s.m.checkUnique(user.Id, user.Email, user.Phone); err != nil {
+		return err
+	}
+	existing.EmailVerified = existing.EmailVerified && existing.Email == user.Email
+	existing.PhoneVerified = existing.PhoneVerified && existing.Phone == user.Phone
+	existing.Name, existing.Email, existing.Phone = user.Name, user.Email, user.Phone
+	s.m.users[user.Id] = existing
+	return nil
+}
+
+func (m *Memory) checkUnique(selfID int, email, phone string) error {
+	for id, other := range m.users {
+		if id == selfID {
+			continue
+		}
+		if other.Email == email {
+			return ErrEmailTaken
+		}
+		if other.Phone == phone {
+			return ErrPhoneTaken
+		}
+	}
+	return nil
+}
+
+func (s memoryUserStore) UpdatePassword(ctx context.Context, userID int, passwordHash string) (int, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return s.m.updatePassword(userID, passwordHash)
+}
+
+// updatePassword must be called with m.mu held.
+func (m *Memory) updatePassword(userID int, passwordHash string) (int, error) {
+	user, ok := m.users[userID]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	user.Password = passwordHash
+	user.SessionVersion++
+	m.users[userID] = user
+	return user.SessionVersion, nil
+}
+
+type memoryPasswordResetStore struct{ m *Memory }
+
+func (s memoryPasswordResetStore) Create(ctx context.Context, reset *models.PasswordReset) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	reset.ID = s.m.id()
+	reset.CreatedAt = time.Now()
+	s.m.resets[reset.ID] = *reset
+	return nil
+}
+
+func (s memoryPasswordResetStore) LastSentAt(ctx context.Context, userID int) (time.Time, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	var last time.Time
+	for _, reset := range s.m.resets {
+		if reset.UserID == userID && reset.CreatedAt.After(last) {
+			last = reset.CreatedAt
+		}
+	}
+	if last.IsZero() {
+		return time.Time{}, ErrNotFound
+	}
+	return last, nil
+}
+
+func (s memoryPasswordResetStore) Redeem(ctx context.Context, tokenHash, passwordHash string) (int, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	now := time.Now()
+	userID := 0
+	for _, reset := range s.m.resets {
+		if reset.TokenHash == tokenHash && reset.UsedAt == nil && reset.ExpiresAt.After(now) {
+			userID = reset.UserID
+		}
+	}
+	if userID == 0 {
+		return 0, ErrNotFound
+	}
+	for id, reset := range s.m.resets {
+		if reset.UserID == userID && reset.UsedAt == nil {
+			reset.UsedAt = &now
+			s.m.resets[id] = reset
+		}
+	}
+	_, err := s.m.updatePassword(userID, passwordHash)
+	return userID, err
+}
+
+type memoryEmailVerificationStore struct{ m *Memory }
+
+func (s memoryEmailVerificationStore) Create(ctx context.Context, verification *models.EmailVerification) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	verification.ID = s.m.id()
+	verification.CreatedAt = time.Now()
+	s.m.verifications[verification.ID] = *verification
+	return nil
+}
+
+func (s memoryEmailVerificationStore) LastSentAt(ctx context.Context, userID int) (time.Time, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	var last time.Time
+	for _, verification := range s.m.verifications {
+		if verification.UserID == userID && verification.CreatedAt.After(last) {
+			last = verification.CreatedAt
+		}
+	}
+	if last.IsZero() {
+		return time.Time{}, ErrNotFound
+	}
+	return last, nil
+}
+
+func (s memoryEmailVerificationStore) Verify(ctx context.Context, tokenHash string) (int, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	now := time.Now()
+	for id, verification := range s.m.verifications {
+		if verification.TokenHash != tokenHash || verification.UsedAt != nil || !verification.ExpiresAt.After(now) {
+			continue
+		}
+		user, ok := s.m.users[verification.UserID]
+		if !ok || user.Email != verification.Email {
+			return 0, ErrNotFound
+		}
+		verification.UsedAt = &now
+		s.m.verifications[id] = verification
+		user.EmailVerified = true
+		s.m.users[user.Id] = user
+		return user.Id, nil
+	}
+	return 0, ErrNotFound
+}
+
+type memoryPhoneOTPStore struct{ m *Memory }
+
+func (s memoryPhoneOTPStore) Create(ctx context.Context, otp *models.PhoneOTP) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	now := time.Now()
+	for id, earlier := range s.m.otps {
+		if earlier.UserID == otp.UserID && earlier.Purpose == otp.Purpose && earlier.UsedAt == nil {
+			earlier.UsedAt = &now
+			s.m.otps[id] = earlier
+		}
+	}
+	otp.ID = s.m.id()
+	otp.CreatedAt = now
+	s.m.otps[otp.ID] = *otp
+	return nil
+}
+
+func (s memoryPhoneOTPStore) LastSentAt(ctx context.Context, userID int, purpose string) (time.Time, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	var last time.Time
+	for _, otp := range s.m.otps {
+		if otp.UserID == userID && otp.Purpose == purpose && otp.CreatedAt.After(last) {
+			last = otp.CreatedAt
+		}
+	}
+	if last.IsZero() {
+		return time.Time{}, ErrNotFound
+	}
+	return last, nil
+}
+
+func (s memoryPhoneOTPStore) Check(ctx context.Context, userID int, purpose, codeHash string, maxAttempts int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	now := time.Now()
+	var current *models.PhoneOTP
+	for _, otp := range s.m.otps {
+		if otp.UserID != userID || otp.Purpose != purpose || otp.UsedAt != nil || !otp.ExpiresAt.After(now) {
+			continue
+		}
+		if current == nil || otp.ID > current.ID {
+			otp := otp
+			current = &otp
+		}
+	}
+	if current == nil {
+		return ErrNotFound
+	}
+
+	if current.Attempts >= maxAttempts {
+		return ErrTooManyAttempts
+	}
+	if subtle.ConstantTimeCompare([]byte(current.CodeHash), []byte(codeHash)) != 1 {
+		current.Attempts++
+		s.m.otps[current.ID] = *current
+		return ErrCodeMismatch
+	}
+
+	user, ok := s.m.users[userID]
+	if !ok || user.Phone != current.Phone {
+		return ErrNotFound
+	}
+	current.UsedAt = &now
+	s.m.otps[current.ID] = *current
+	user.PhoneVerified = true
+	s.m.users[userID] = user
+	return nil
+}
+
+type memoryLoginAttemptStore struct{ m *Memory }
+
+func (s memoryLoginAttemptStore) Record(ctx context.Context, attempt *models.LoginAttempt) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	attempt.ID = int64(s.m.id())
+	attempt.CreatedAt = time.Now()
+	s.m.loginAttempts = append(s.m.loginAttempts, *attempt)
+	return nil
+}
+
+func (s memoryLoginAttemptStore) Failures(ctx context.Context, email, ip string, since time.Time) (LoginFailures, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	var f LoginFailures
+	// Attempts are appended in order, so walking backwards stops counting
+	// the account at its last success.
+	accountDone := false
+	for i := len(s.m.loginAttempts) - 1; i >= 0; i-- {
+		attempt := s.m.loginAttempts[i]
+		if !attempt.CreatedAt.After(since) {
+			break
+		}
+		if attempt.Email == email && attempt.Result == models.LoginSucceeded {
+			accountDone = true
+		}
+		if attempt.Result != models.LoginFailed {
+			continue
+		}
+		if attempt.Email == email && !accountDone {
+			if f.Account == 0 {
+				f.AccountLast = attempt.CreatedAt
+			}
+			f.Account++
+		}
+		if attempt.IP == ip {
+			if f.IP == 0 {
+				f.IPLast = attempt.CreatedAt
+			}
+			f.IP++
+		}
+	}
+	return f, nil
+}
+
+type memoryRestaurantStore struct{ m *Memory }
+
+func (s memoryRestaurantStore) ListByCity(ctx context.Context, city string) ([]models.Restaurants, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return append([]models.Restaurants(nil), s.m.restaurants[city]...), nil
+}
+
+func (s memoryRestaurantStore) ListCities(ctx context.Context) ([]string, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	var cities []string
+	for city := range s.m.restaurants {
+		cities = append(cities, city)
+	}
+	sort.Strings(cities)
+	return cities, nil
+}
+
+func (s memoryRestaurantStore) AddOwner(ctx context.Context, restaurantID, userID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if _, ok := s.m.users[userID]; !ok || !s.m.hasRestaurant(restaurantID) {
+		return ErrNotFound
+	}
+	if s.m.owners[restaurantID] == nil {
+		s.m.owners[restaurantID] = make(map[int]bool)
+	}
+	s.m.owners[restaurantID][userID] = true
+	return nil
+}
+
+func (s memoryRestaurantStore) RemoveOwner(ctx context.Context, restaurantID, userID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if !s.m.owners[restaurantID][userID] {
+		return ErrNotFound
+	}
+	delete(s.m.owners[restaurantID], userID)
+	return nil
+}
+
+func (s memoryRestaurantStore) OwnsAny(ctx context.Context, userID int, restaurantIDs []int) (bool, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	for _, id := range restaurantIDs {
+		if s.m.owners[id][userID] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Memory) hasRestaurant(id int) bool {
+	for _, restaurants := range m.restaurants {
+		for _, restaurant := range restaurants {
+			if restaurant.Id == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type memoryFoodStore struct{ m *Memory }
+
+func (s memoryFoodStore) ListByCloudImageID(ctx context.Context, cloudImageID string) ([]models.FoodItems, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	var items []models.FoodItems
+	for _, item := range s.m.food {
+		if item.CloudImageID == cloudImageID {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Id < items[j].Id })
+	return items, nil
+}
+
+func (s memoryFoodStore) GetByIDs(ctx context.Context, ids []int) (map[int]MenuItem, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	items := make(map[int]MenuItem, len(ids))
+	for _, id := range ids {
+		food, ok := s.m.food[id]
+		if !ok {
+			continue
+		}
+		if restaurantID := s.m.restaurantByImage(food.CloudImageID); restaurantID != 0 {
+			items[id] = MenuItem{FoodItems: food, RestaurantID: restaurantID}
+		}
+	}
+	return items, nil
+}
+
+// restaurantByImage mirrors the FoodItems to restaurantsdata join on
+// cloudimageid, preferring the lowest restaurant ID.
+func (m *Memory) restaurantByImage(cloudImageID string) int {
+	found := 0
+	for _, restaurants := range m.restaurants {
+		for _, r := range restaurants {
+			if r.CloudImageID == cloudImageID && (found == 0 || r.Id < found) {
+				found = r.Id
+			}
+		}
+	}
+	return found
+}
+
+type memoryCartStore struct{ m *Memory }
+
+func (s memoryCartStore) GetActive(ctx context.Context, userID int) (models.Cart, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	for _, c := range s.m.carts {
+		if c.userID == userID && c.active {
+			return s.m.cartView(c), nil
+		}
+	}
+	return models.Cart{UserID: userID, Items: []models.OrderItem{}}, ErrNotFound
+}
+
+func (m *Memory) cartView(c *memoryCart) models.Cart {
+	cart := models.Cart{ID: c.id, UserID: c.userID, RestaurantID: c.restaurantID, TotalAmount: c.totalAmount, Items: []models.OrderItem{}}
+	for _, item := range c.items {
+		food, ok := m.food[item.ID]
+		if !ok {
+			continue
+		}
+		cart.Items = append(cart.Items, models.OrderItem{
+			ID:           item.ID,
+			Name:         food.Name,
+			Price:        food.Price,
+			CloudImageID: food.CloudImageID,
+			Quantity:     item.Quantity,
+			RestaurantID: c.restaurantID,
+		})
+	}
+	return cart
+}
+
+func (s memoryCartStore) Create(ctx context.Context, userID int) (models.Cart, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	c := &memoryCart{id: s.m.id(), userID: userID, active: true}
+	s.m.carts[c.id] = c
+	return s.m.cartView(c), nil
+}
+
+func (s memoryCartStore) Sync(ctx context.Context, userID, cartID int, items []models.OrderItem) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	c, ok := s.m.carts[cartID]
+	if !ok || !c.active {
+		return ErrNotFound
+	}
+	if c.userID != userID {
+		return ErrForbidden
+	}
+
+	c.items = nil
+	c.totalAmount = 0
+	c.restaurantID = 0
+	for _, item := range items {
+		c.restaurantID = item.RestaurantID
+		c.items = append(c.items, models.OrderItem{ID: item.ID, Quantity: item.Quantity})
+		c.totalAmount += float64(item.Quantity) * item.Price
+	}
+	return nil
+}
+
+func (s memoryCartStore) DeactivateForUser(ctx context.Context, userID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	for _, c := range s.m.carts {
+		if c.userID == userID {
+			c.active = false
+		}
+	}
+	return nil
+}
+
+type memoryAddressStore struct{ m *Memory }
+
+func (s memoryAddressStore) List(ctx context.Context, userID int) ([]models.Address, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	var addresses []models.Address
+	for _, address := range s.m.addresses {
+		if address.UserID == userID {
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i].ID < addresses[j].ID })
+	return addresses, nil
+}
+
+func (s memoryAddressStore) Get(ctx context.Context, userID, addressID int) (models.Address, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	address, ok := s.m.addresses[addressID]
+	if !ok || address.UserID != userID {
+		return models.Address{}, ErrNotFound
+	}
+	return address, nil
+}
+
+func (s memoryAddressStore) Create(ctx context.Context, address *models.Address) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if s.m.primaryAddress(address.UserID) == 0 {
+		address.IsPrimary = true
+	} else if address.IsPrimary {
+		s.m.clearPrimaryAddress(address.UserID)
+	}
+	address.ID = s.m.id()
+	s.m.addresses[address.ID] = *address
+	return nil
+}
+
+func (s memoryAddressStore) Update(ctx context.Context, address *models.Address) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	existing, ok := s.m.addresses[address.ID]
+	if !ok || existing.UserID != address.UserID {
+		return ErrNotFound
+	}
+	if address.IsPrimary && !existing.IsPrimary {
+		s.m.clearPrimaryAddress(address.UserID)
+	}
+	address.IsPrimary = address.IsPrimary || existing.IsPrimary
+	s.m.addresses[address.ID] = *address
+	return nil
+}
+
+func (s memoryAddressStore) SetPrimary(ctx context.Context, userID, addressID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	address, ok := s.m.addresses[addressID]
+	if !ok || address.UserID != userID {
+		return ErrNotFound
+	}
+	s.m.clearPrimaryAddress(userID)
+	address.IsPrimary = true
+	s.m.addresses[addressID] = address
+	return nil
+}
+
+func (s memoryAddressStore) Delete(ctx context.Context, userID, addressID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	existing, ok := s.m.addresses[addressID]
+	if !ok || existing.UserID != userID {
+		return ErrNotFound
+	}
+	delete(s.m.addresses, addressID)
+	// Orders keep their copy of the address but lose the link to it.
+	for id, order := range s.m.orders {
+		if order.Address != nil && order.Address.AddressID == addressID {
+			address := *order.Address
+			address.AddressID = 0
+			order.Address = &address
+			s.m.orders[id] = order
+		}
+	}
+
+	if existing.IsPrimary {
+		oldest := 0
+		for id, address := range s.m.addresses {
+			if address.UserID == userID && (oldest == 0 || id < oldest) {
+				oldest = id
+			}
+		}
+		if oldest != 0 {
+			address := s.m.addresses[oldest]
+			address.IsPrimary = true
+			s.m.addresses[oldest] = address
+		}
+	}
+	return nil
+}
+
+// primaryAddress returns the ID of the user's primary address, or 0. m.mu
+// must be held.
+func (m *Memory) primaryAddress(userID int) int {
+	for id, address := range m.addresses {
+		if address.UserID == userID && address.IsPrimary {
+			return id
+		}
+	}
+	return 0
+}
+
+// clearPrimaryAddress must be called with m.mu held.
+func (m *Memory) clearPrimaryAddress(userID int) {
+	if id := m.primaryAddress(userID); id != 0 {
+		address := m.addresses[id]
+		address.IsPrimary = false
+		m.addresses[id] = address
+	}
+}
+
+type memoryOrderStore struct{ m *Memory }
+
+func (s memoryOrderStore) Create(ctx context.Context, order *models.Order) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	order.OrderID = s.m.id()
+	order.CreatedAt = time.Now()
+	order.UpdatedAt = order.CreatedAt
+	stored := *order
+	stored.Items = append([]models.OrderItem(nil), order.Items...)
+	if order.Address != nil {
+		address := *order.Address
+		stored.Address = &address
+	}
+	s.m.orders[order.OrderID] = stored
+	s.m.statusHistory = append(s.m.statusHistory, models.OrderStatusChange{
+		OrderID:     order.OrderID,
+		ToStatus:    order.Status,
+		Actor:       models.ActorCustomer,
+		ActorUserID: order.UserID,
+		CreatedAt:   order.CreatedAt,
+	})
+	return nil
+}
+
+func (s memoryOrderStore) AttachSession(ctx context.Context, orderID int, sessionID string) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	order, ok := s.m.orders[orderID]
+	if !ok {
+		return ErrNotFound
+	}
+	order.SessionID = sessionID
+	order.UpdatedAt = time.Now()
+	s.m.orders[orderID] = order
+	return nil
+}
+
+func (s memoryOrderStore) ApplyPaymentUpdate(ctx context.Context, update PaymentUpdate) (*models.OrderStatusChange, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	orderID := update.OrderID
+	if orderID == 0 {
+		for id, order := range s.m.orders {
+			var match bool
+			switch {
+			case update.SessionID != "":
+				match = order.SessionID == update.SessionID
+			case update.PaymentID != "":
+				match = order.PaymentID == update.PaymentID
+			}
+			if match && id > orderID {
+				orderID = id
+			}
+		}
+	}
+	order, ok := s.m.orders[orderID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	if update.EventID != "" {
+		if s.m.paymentEvents[update.EventID] {
+			return nil, nil
+		}
+		s.m.paymentEvents[update.EventID] = true
+	}
+	next := update.Status
+	if len(update.Refunds) > 0 {
+		for _, refund := range update.Refunds {
+			refund.OrderID = orderID
+			order = s.m.upsertRefund(order, &refund)
+		}
+		s.m.orders[orderID] = order
+		next = s.m.refundedStatus(order)
+	}
+	if !models.CanTransitionOrder(order.Status, next, models.ActorSystem) {
+		return nil, nil
+	}
+
+	change := models.OrderStatusChange{
+		OrderID:    orderID,
+		FromStatus: order.Status,
+		ToStatus:   next,
+		Actor:      models.ActorSystem,
+		Note:       update.EventType,
+		CreatedAt:  time.Now(),
+	}
+	s.m.statusHistory = append(s.m.statusHistory, change)
+	order.Status = next
+	if update.PaymentID != "" {
+		order.PaymentID = update.PaymentID
+	}
+	order.UpdatedAt = change.CreatedAt
+	s.m.orders[orderID] = order
+
+	if update.DeactivateCart {
+		for _, c := range s.m.carts {
+			if c.userID == order.UserID && (order.CartID == 0 || c.id == order.CartID) {
+				c.active = false
+			}
+		}
+	}
+	return &change, nil
+}
+
+func (s memoryOrderStore) Transition(ctx context.Context, change *models.OrderStatusChange) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	order, ok := s.m.orders[change.OrderID]
+	if !ok {
+		return ErrNotFound
+	}
+	change.FromStatus = order.Status
+	if !models.CanTransitionOrder(change.FromStatus, change.ToStatus, change.Actor) {
+		return &TransitionError{From: change.FromStatus, To: change.ToStatus, Actor: change.Actor}
+	}
+	order.Status = change.ToStatus
+	order.UpdatedAt = time.Now()
+	s.m.orders[order.OrderID] = order
+	change.CreatedAt = order.UpdatedAt
+	s.m.statusHistory = append(s.m.statusHistory, *change)
+	return nil
+}
+
+func (s memoryOrderStore) AddRefund(ctx context.Context, refund *models.Refund) (*models.OrderStatusChange, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	order, ok := s.m.orders[refund.OrderID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	order = s.m.upsertRefund(order, refund)
+	s.m.orders[order.OrderID] = order
+	if refund.Status == models.RefundFailed || refund.Status == models.RefundCanceled {
+		return nil, nil
+	}
+
+	next := s.m.refundedStatus(order)
+	if !models.CanTransitionOrder(order.Status, next, models.ActorSystem) {
+		return nil, nil
+	}
+	change := models.OrderStatusChange{
+		OrderID:    order.OrderID,
+		FromStatus: order.Status,
+		ToStatus:   next,
+		Actor:      models.ActorSystem,
+		Note:       "refund " + refund.ProviderRefundID,
+		CreatedAt:  refund.CreatedAt,
+	}
+	s.m.statusHistory = append(s.m.statusHistory, change)
+	order.Status = next
+	order.UpdatedAt = refund.CreatedAt
+	s.m.orders[order.OrderID] = order
+	return &change, nil
+}
+
+// upsertRefund adds refund to order, or updates the refund with the same
+// provider ID, as the Postgres upsert does. It returns the updated order.
+func (m *Memory) upsertRefund(order models.Order, refund *models.Refund) models.Order {
+	refunds := append([]models.Refund(nil), order.Refunds...)
+	for i, existing := range refunds {
+		if existing.ProviderRefundID != refund.ProviderRefundID {
+			continue
+		}
+		existing.Status = refund.Status
+		if refund.Reason != "" {
+			existing.Reason = refund.Reason
+		}
+		if refund.ActorUserID != 0 {
+			existing.ActorUserID = refund.ActorUserID
+		}
+		refunds[i], *refund = existing, existing
+		order.Refunds = refunds
+		return order
+	}
+	refund.ID = m.id()
+	refund.CreatedAt = time.Now()
+	order.Refunds = append(refunds, *refund)
+	return order
+}
+
+// refundedStatus returns the status order's refunds put it in, or "" when
+// none of them returned any money.
+func (m *Memory) refundedStatus(order models.Order) string {
+	refunded := math.Round(order.RefundedAmount() * 100)
+	switch {
+	case refunded <= 0:
+		return ""
+	case math.Round(order.TotalAmount*100) <= refunded:
+		return models.OrderRefunded
+	default:
+		return models.OrderPartiallyRefunded
+	}
+}
+
+func (s memoryOrderStore) Get(ctx context.Context, orderID int) (models.Order, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	order, ok := s.m.orders[orderID]
+	if !ok {
+		return models.Order{}, ErrNotFound
+	}
+	return s.m.orderView(order), nil
+}
+
+// orderView joins an order's items with the menu, as the Postgres query does.
+func (m *Memory) orderView(order models.Order) models.Order {
+	items := make([]models.OrderItem, 0, len(order.Items))
+	for _, item := range order.Items {
+		food := m.food[item.ID]
+		items = append(items, models.OrderItem{ID: item.ID, Quantity: item.Quantity, Price: item.Price, Name: food.Name, CloudImageID: food.CloudImageID})
+	}
+	order.Items = items
+	order.Refunds = append([]models.Refund(nil), order.Refunds...)
+	if order.Address != nil {
+		address := *order.Address
+		order.Address = &address
+	}
+	return order
+}
+
+func (s memoryOrderStore) List(ctx context.Context, filter OrderFilter) ([]models.Order, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	orders := []models.Order{}
+	for _, order := range s.m.orders {
+		if filter.matches(order) {
+			orders = append(orders, s.m.orderView(order))
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return newerOrder(orders[i].CreatedAt, orders[i].OrderID, orders[j].CreatedAt, orders[j].OrderID)
+	})
+	if filter.Limit > 0 && len(orders) > filter.Limit {
+		orders = orders[:filter.Limit]
+	}
+	return orders, nil
+}
+
+func (f OrderFilter) matches(order models.Order) bool {
+	if f.UserID != 0 && order.UserID != f.UserID {
+		return false
+	}
+	if f.SessionID != "" && order.SessionID != f.SessionID {
+		return false
+	}
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, order.Status) {
+		return false
+	}
+	if !f.From.IsZero() && order.CreatedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !order.CreatedAt.Before(f.To) {
+		return false
+	}
+	return f.After == nil || newerOrder(f.After.CreatedAt, f.After.OrderID, order.CreatedAt, order.OrderID)
+}
+
+// newerOrder reports whether order a comes before order b in the newest-first
+// listing.
+func newerOrder(aCreated time.Time, aID int, bCreated time.Time, bID int) bool {
+	if aCreated.Equal(bCreated) {
+		return aID > bID
+	}
+	return aCreated.After(bCreated)
+}
+
+func (s memoryOrderStore) History(ctx context.Context, orderID int) ([]models.OrderStatusChange, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	history := []models.OrderStatusChange{}
+	for _, change := range s.m.statusHistory {
+		if change.OrderID == orderID {
+			history = append(history, change)
+		}
+	}
+	return history, nil
+}
+
+type memoryIdempotencyStore struct{ m *Memory }
+
+func idempotencyKey(userID int, key string) string {
+	return strconv.Itoa(userID) + ":" + key
+}
+
+func (s memoryIdempotencyStore) Begin(ctx context.Context, record IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	k := idempotencyKey(record.UserID, record.Key)
+	existing, ok := s.m.idempotency[k]
+	if ok {
+		existing.ResponseBody = append([]byte(nil), existing.ResponseBody...)
+		return existing, false, nil
+	}
+	record.StatusCode, record.ResponseBody = 0, nil
+	record.CreatedAt = time.Now()
+	s.m.idempotency[k] = record
+	return record, true, nil
+}
+
+func (s memoryIdempotencyStore) Complete(ctx context.Context, userID int, key string, statusCode int, body []byte) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	k := idempotencyKey(userID, key)
+	record, ok := s.m.idempotency[k]
+	if !ok {
+		return ErrNotFound
+	}
+	record.StatusCode = statusCode
+	record.ResponseBody = append([]byte(nil), body...)
+	s.m.idempotency[k] = record
+	return nil
+}
+
+func (s memoryIdempotencyStore) Release(ctx context.Context, userID int, key string) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	k := idempotencyKey(userID, key)
+	if record, ok := s.m.idempotency[k]; ok && record.StatusCode == 0 {
+		delete(s.m.idempotency, k)
+	}
+	return nil
+}
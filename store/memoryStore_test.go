@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+// placeOrders creates one order per creation time, for users 1 and 2 in
+// turn, and returns their IDs.
+func placeOrders(t *testing.T, m *Memory, statuses []string, created []time.Time) []int {
+	t.Helper()
+	orders := m.Store().Orders
+	var ids []int
+	for i, at := range created {
+		order := models.Order{UserID: 1 + i%2, Status: statuses[i%len(statuses)]}
+		if err := orders.Create(context.Background(), &order); err != nil {
+			t.Fatal(err)
+		}
+		stored := m.orders[order.OrderID]
+		stored.CreatedAt = at
+		m.orders[order.OrderID] = stored
+		ids = append(ids, order.OrderID)
+	}
+	return ids
+}
+
+func orderIDs(orders []models.Order) []int {
+	ids := []int{}
+	for _, order := range orders {
+		ids = append(ids, order.OrderID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMemoryOrderListPagesThroughTies(t *testing.T) {
+	m := NewMemory()
+	noon := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	// Orders placed in the same instant are ordered by ID.
+	ids := placeOrders(t, m, []string{models.OrderPending}, []time.Time{
+		noon, noon, noon.Add(time.Minute), noon, noon.Add(-time.Minute),
+	})
+	want := []int{ids[2], ids[3], ids[1], ids[0], ids[4]}
+
+	var got []int
+	filter := OrderFilter{Limit: 2}
+	for page := 0; page < 5; page++ {
+		orders, err := m.Store().Orders.List(context.Background(), filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(orders) == 0 {
+			break
+		}
+		got = append(got, orderIDs(orders)...)
+		last := orders[len(orders)-1]
+		filter.After = &OrderCursor{CreatedAt: last.CreatedAt, OrderID: last.OrderID}
+	}
+	if !equalIDs(got, want) {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+}
+
+func TestMemoryOrderListFilters(t *testing.T) {
+	m := NewMemory()
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	// Users 1 and 2 alternate; statuses cycle pending, completed, cancelled.
+	ids := placeOrders(t, m, []string{models.OrderPending, models.OrderCompleted, models.OrderCancelled}, []time.Time{
+		day, day.Add(6 * time.Hour), day.Add(12 * time.Hour), day.Add(18 * time.Hour), day.Add(24 * time.Hour), day.Add(30 * time.Hour),
+	})
+
+	tests := []struct {
+		name   string
+		filter OrderFilter
+		want   []int
+	}{
+		{"user", OrderFilter{UserID: 1}, []int{ids[4], ids[2], ids[0]}},
+		{"statuses", OrderFilter{Statuses: []string{models.OrderPending, models.OrderCancelled}}, []int{ids[5], ids[3], ids[2], ids[0]}},
+		{"from is inclusive", OrderFilter{From: day.Add(24 * time.Hour)}, []int{ids[5], ids[4]}},
+		{"to is exclusive", OrderFilter{To: day.Add(12 * time.Hour)}, []int{ids[1], ids[0]}},
+		{"user and status", OrderFilter{UserID: 2, Statuses: []string{models.OrderCompleted}}, []int{ids[1]}},
+		{"status and range", OrderFilter{Statuses: []string{models.OrderPending}, From: day.Add(time.Hour), To: day.Add(48 * time.Hour)}, []int{ids[3]}},
+		{"after and user", OrderFilter{UserID: 1, After: &OrderCursor{CreatedAt: day.Add(24 * time.Hour), OrderID: ids[4]}}, []int{ids[2], ids[0]}},
+		{"limit", OrderFilter{UserID: 2, Limit: 2}, []int{ids[5], ids[3]}},
+		{"no match", OrderFilter{UserID: 3}, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders, err := m.Store().Orders.List(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := orderIDs(orders); !equalIDs(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
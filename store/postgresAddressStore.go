@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+type postgresAddressStore struct {
+	db *sql.DB
+}
+
+func (s *postgresAddressStore) List(ctx context.Context, userID int) ([]models.Address, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, user_id, name, street, city, postal_code, phone, is_primary FROM addresses WHERE user_id = $1 ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var addresses []models.Address
+	for rows.Next() {
+		var address models.Address
+		if err := rows.Scan(&address.ID, &address.UserID, &address.Name, &address.Street, &address.City, &address.PostalCode, &address.Phone, &address.IsPrimary); err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, rows.Err()
+}
+
+func (s *postgresAddressStore) Get(ctx context.Context, userID, addressID int) (models.Address, error) {
+	var address models.Address
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, user_id, name, street, city, postal_code, phone, is_primary
+		FROM addresses WHERE id = $1 AND user_id = $2`, addressID, userID,
+	).Scan(&address.ID, &address.UserID, &address.Name, &address.Street, &address.City, &address.PostalCode, &address.Phone, &address.IsPrimary)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Address{}, ErrNotFound
+	}
+	return address, err
+}
+
+func (s *postgresAddressStore) Create(ctx context.Context, address *models.Address) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := lockAddresses(ctx, tx, address.UserID); err != nil {
+			return err
+		}
+
+		var first bool
+		err := tx.QueryRowContext(ctx, `SELECT NOT EXISTS (SELECT 1 FROM addresses WHERE user_id = $1)`, address.UserID).Scan(&first)
+		if err != nil {
+			return err
+		}
+		if first {
+			address.IsPrimary = true
+		} else if address.IsPrimary {
+			if err := clearPrimaryAddress(ctx, tx, address.UserID, 0); err != nil {
+				return err
+			}
+		}
+
+		return tx.QueryRowContext(ctx, `
+			INSERT INTO addresses (user_id, name, street, city, postal_code, phone, is_primary)
+			VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+			address.UserID, address.Name, address.Street, address.City, address.PostalCode, address.Phone, address.IsPrimary).Scan(&address.ID)
+	})
+}
+
+func (s *postgresAddressStore) Update(ctx context.Context, address *models.Address) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := lockAddresses(ctx, tx, address.UserID); err != nil {
+			return err
+		}
+		if address.IsPrimary {
+			if err := clearPrimaryAddress(ctx, tx, address.UserID, address.ID); err != nil {
+				return err
+			}
+		}
+
+		// Clearing is_primary is ignored: the address stays primary until
+		// another one is made primary.
+		err := tx.QueryRowContext(ctx, `
+			UPDATE addresses
+			SET name = $1, street = $2, city = $3, postal_code = $4, phone = $5, is_primary = is_primary OR $6
+			WHERE id = $7 AND user_id = $8
+			RETURNING is_primary`,
+			address.Name, address.Street, address.City, address.PostalCode, address.Phone, address.IsPrimary, address.ID, address.UserID,
+		).Scan(&address.IsPrimary)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	})
+}
+
+func (s *postgresAddressStore) SetPrimary(ctx context.Context, userID, addressID int) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := lockAddresses(ctx, tx, userID); err != nil {
+			return err
+		}
+		if err := clearPrimaryAddress(ctx, tx, userID, addressID); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, `UPDATE addresses SET is_primary = TRUE WHERE id = $1 AND user_id = $2`, addressID, userID)
+		if err != nil {
+			return err
+		}
+		return rowsAffectedOrNotFound(result)
+	})
+}
+
+func (s *postgresAddressStore) Delete(ctx context.Context, userID, addressID int) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := lockAddresses(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		var wasPrimary bool
+		err := tx.QueryRowContext(ctx, `DELETE FROM addresses WHERE id = $1 AND user_id = $2 RETURNING is_primary`, addressID, userID).Scan(&wasPrimary)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil || !wasPrimary {
+			return err
+		}
+
+		// The oldest remaining address takes over, if there is one.
+		_, err = tx.ExecContext(ctx, `
+			UPDATE addresses SET is_primary = TRUE
+			WHERE id = (SELECT MIN(id) FROM addresses WHERE user_id = $1)`, userID)
+		return err
+	})
+}
+
+// lockAddresses serialises changes to a user's addresses, so that two of
+// them cannot both decide to be the only primary one. It locks the user's
+// row rather than the addresses, which may not exist yet.
+func lockAddresses(ctx context.Context, tx *sql.Tx, userID int) error {
+	var id int
+	err := tx.QueryRowContext(ctx, `SELECT id FROM users WHERE id = $1 FOR NO KEY UPDATE`, userID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// clearPrimaryAddress unmarks the user's primary address unless it is
+// keepID. It runs before the new primary is marked, as the unique index on
+// primary addresses is checked row by row.
+func clearPrimaryAddress(ctx context.Context, tx *sql.Tx, userID, keepID int) error {
+	_, err := tx.ExecContext(ctx, `UPDATE addresses SET is_primary = FALSE WHERE user_id = $1 AND is_primary AND id <> $2`, userID, keepID)
+	return err
+}
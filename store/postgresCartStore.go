@@ -0,0 +1,142 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+type postgresCartStore struct {
+	db *sql.DB
+}
+
+func (s *postgresCartStore) GetActive(ctx context.Context, userID int) (models.Cart, error) {
+	cart := models.Cart{UserID: userID, Items: []models.OrderItem{}}
+
+	var restaurantID sql.NullInt64 // to handle nullable restaurant_id
+	err := s.db.QueryRowContext(ctx, "SELECT id, restaurant_id, total_amount FROM cart WHERE user_id = $1 AND is_active = TRUE", userID).
+		Scan(&cart.ID, &restaurantID, &cart.TotalAmount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cart, ErrNotFound
+	}
+	if err != nil {
+		return cart, err
+	}
+	cart.RestaurantID = int(restaurantID.Int64)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			ci.item_id,
+			fi.name,
+			ci.quantity,
+			fi.price::numeric, -- Cast price to NUMERIC to avoid type issues
+			fi.cloudimageid
+		FROM cart_items ci
+		JOIN FoodItems fi ON ci.item_id = fi.id
+		WHERE ci.cart_id = $1`, cart.ID)
+	if err != nil {
+		return cart, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.OrderItem
+		if err := rows.Scan(&item.ID, &item.Name, &item.Quantity, &item.Price, &item.CloudImageID); err != nil {
+			return cart, err
+		}
+		item.RestaurantID = cart.RestaurantID
+		cart.Items = append(cart.Items, item)
+	}
+	return cart, rows.Err()
+}
+
+func (s *postgresCartStore) Create(ctx context.Context, userID int) (models.Cart, error) {
+	cart := models.Cart{UserID: userID, Items: []models.OrderItem{}}
+	err := s.db.QueryRowContext(ctx, "INSERT INTO cart (user_id, total_amount, is_active) VALUES ($1, 0, TRUE) RETURNING id", userID).Scan(&cart.ID)
+	return cart, err
+}
+
+func (s *postgresCartStore) Sync(ctx context.Context, userID, cartID int, items []models.OrderItem) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		// Verify that the cart belongs to the user and is active
+		var dbUserID int
+		err := tx.QueryRowContext(ctx, "SELECT user_id FROM cart WHERE id = $1 AND is_active = TRUE FOR UPDATE", cartID).Scan(&dbUserID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if dbUserID != userID {
+			return ErrForbidden
+		}
+
+		existingItems := make(map[int]int)
+		rows, err := tx.QueryContext(ctx, "SELECT item_id, quantity FROM cart_items WHERE cart_id = $1", cartID)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var itemID, quantity int
+			if err := rows.Scan(&itemID, &quantity); err != nil {
+				rows.Close()
+				return err
+			}
+			existingItems[itemID] = quantity
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		totalAmount := 0.0
+		var restaurantID sql.NullInt64
+		updatedItems := make(map[int]bool)
+
+		for _, item := range items {
+			restaurantID = sql.NullInt64{Int64: int64(item.RestaurantID), Valid: item.RestaurantID != 0}
+
+			quantity, exists := existingItems[item.ID]
+			if exists {
+				if quantity != item.Quantity {
+					_, err = tx.ExecContext(ctx,
+						"UPDATE cart_items SET quantity = $1, updated_at = NOW() WHERE cart_id = $2 AND item_id = $3",
+						item.Quantity, cartID, item.ID,
+					)
+				}
+			} else {
+				_, err = tx.ExecContext(ctx,
+					"INSERT INTO cart_items (cart_id, item_id, quantity, created_at, updated_at) VALUES ($1, $2, $3, NOW(), NOW())",
+					cartID, item.ID, item.Quantity,
+				)
+			}
+			if err != nil {
+				return err
+			}
+			totalAmount += float64(item.Quantity) * item.Price
+			updatedItems[item.ID] = true
+		}
+
+		// Remove items that are no longer in the cart
+		for existingID := range existingItems {
+			if !updatedItems[existingID] {
+				if _, err := tx.ExecContext(ctx, "DELETE FROM cart_items WHERE cart_id = $1 AND item_id = $2", cartID, existingID); err != nil {
+					return err
+				}
+			}
+		}
+
+		_, err = tx.ExecContext(ctx,
+			"UPDATE cart SET total_amount = $1, restaurant_id = $2, updated_at = NOW() WHERE id = $3",
+			totalAmount, restaurantID, cartID,
+		)
+		return err
+	})
+}
+
+func (s *postgresCartStore) DeactivateForUser(ctx context.Context, userID int) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE cart SET is_active = false WHERE user_id = $1", userID)
+	return err
+}
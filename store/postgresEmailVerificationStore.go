@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+type postgresEmailVerificationStore struct {
+	db *sql.DB
+}
+
+func (s *postgresEmailVerificationStore) Create(ctx context.Context, verification *models.EmailVerification) error {
+	return s.db.QueryRowContext(ctx, `
+		INSERT INTO email_verifications (user_id, email, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4) RETURNING id, created_at`,
+		verification.UserID, verification.Email, verification.TokenHash, verification.ExpiresAt,
+	).Scan(&verification.ID, &verification.CreatedAt)
+}
+
+func (s *postgresEmailVerificationStore) LastSentAt(ctx context.Context, userID int) (time.Time, error) {
+	var last sql.NullTime
+	err := s.db.QueryRowContext(ctx, `SELECT MAX(created_at) FROM email_verifications WHERE user_id = $1`, userID).Scan(&last)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !last.Valid {
+		return time.Time{}, ErrNotFound
+	}
+	return last.Time, nil
+}
+
+func (s *postgresEmailVerificationStore) Verify(ctx context.Context, tokenHash string) (int, error) {
+	var userID int
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		var email string
+		err := tx.QueryRowContext(ctx, `
+			UPDATE email_verifications SET used_at = NOW()
+			WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+			RETURNING user_id, email`, tokenHash,
+		).Scan(&userID, &email)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		// Fails when the link was sent to an email the user has since
+		// changed.
+		result, err := tx.ExecContext(ctx, `UPDATE users SET email_verified = TRUE WHERE id = $1 AND email = $2`, userID, email)
+		if err != nil {
+			return err
+		}
+		return rowsAffectedOrNotFound(result)
+	})
+	return userID, err
+}
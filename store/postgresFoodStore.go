@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+var fetchfoodItemsQuery = "SELECT id, name, price, description, cloudimageid, category FROM FoodItems WHERE cloudimageid = $1"
+
+// Menu items belong to the restaurant sharing their cloudimageid.
+var fetchMenuItemsByIDQuery = `
+	SELECT DISTINCT ON (f.id) f.id, f.name, f.price, f.description, f.cloudimageid, f.category, r.id
+	FROM FoodItems f
+	JOIN restaurantsdata r ON r.cloudimageid = f.cloudimageid
+	WHERE f.id = ANY($1)
+	ORDER BY f.id, r.id`
+
+type postgresFoodStore struct {
+	db *sql.DB
+}
+
+func (s *postgresFoodStore) ListByCloudImageID(ctx context.Context, cloudImageID string) ([]models.FoodItems, error) {
+	rows, err := s.db.QueryContext(ctx, fetchfoodItemsQuery, cloudImageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.FoodItems
+	for rows.Next() {
+		var item models.FoodItems
+		if err := rows.Scan(&item.Id, &item.Name, &item.Price, &item.Description, &item.CloudImageID, &item.Category); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (s *postgresFoodStore) GetByIDs(ctx context.Context, ids []int) (map[int]MenuItem, error) {
+	rows, err := s.db.QueryContext(ctx, fetchMenuItemsByIDQuery, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make(map[int]MenuItem, len(ids))
+	for rows.Next() {
+		var item MenuItem
+		if err := rows.Scan(&item.Id, &item.Name, &item.Price, &item.Description, &item.CloudImageID, &item.Category, &item.RestaurantID); err != nil {
+			return nil, err
+		}
+		items[item.Id] = item
+	}
+	return items, rows.Err()
+}
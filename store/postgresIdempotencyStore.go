@@ -0,0 +1,132 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+)
+
+// postgresIdempotencyStore marks a claimed key with a session advisory lock
+// held on a dedicated connection until the request completes or releases
+// it. If the process dies first, the connection closes, the lock goes with
+// it and the next request with the key takes the claim over.
+type postgresIdempotencyStore struct {
+	db *sql.DB
+
+	mu     sync.Mutex
+	claims map[string]*sql.Conn
+}
+
+func newPostgresIdempotencyStore(db *sql.DB) *postgresIdempotencyStore {
+	return &postgresIdempotencyStore{db: db, claims: make(map[string]*sql.Conn)}
+}
+
+func (s *postgresIdempotencyStore) Begin(ctx context.Context, record IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return IdempotencyRecord{}, false, err
+	}
+	var locked bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1, hashtext($2))`, record.UserID, record.Key).Scan(&locked)
+	if err != nil {
+		conn.Close()
+		return IdempotencyRecord{}, false, err
+	}
+	if !locked {
+		// Another request holds the key; it may not have saved its row yet.
+		conn.Close()
+		existing, err := s.get(ctx, record.UserID, record.Key)
+		if errors.Is(err, sql.ErrNoRows) {
+			record.StatusCode, record.ResponseBody = 0, nil
+			return record, false, nil
+		}
+		return existing, false, err
+	}
+
+	// With the lock held, a row without a response was left by a request
+	// that died, and is taken over.
+	var claimed bool
+	err = conn.QueryRowContext(ctx, `
+		INSERT INTO idempotency_keys (user_id, key, request_hash, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id, key) DO UPDATE
+			SET request_hash = EXCLUDED.request_hash, created_at = NOW()
+			WHERE idempotency_keys.status_code IS NULL
+		RETURNING TRUE`,
+		record.UserID, record.Key, record.RequestHash,
+	).Scan(&claimed)
+	if err != nil {
+		releaseAdvisoryLock(conn, record.UserID, record.Key)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return IdempotencyRecord{}, false, err
+		}
+		existing, err := s.get(ctx, record.UserID, record.Key)
+		return existing, false, err
+	}
+
+	s.mu.Lock()
+	s.claims[idempotencyKey(record.UserID, record.Key)] = conn
+	s.mu.Unlock()
+	return record, true, nil
+}
+
+func (s *postgresIdempotencyStore) get(ctx context.Context, userID int, key string) (IdempotencyRecord, error) {
+	existing := IdempotencyRecord{UserID: userID, Key: key}
+	var statusCode sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT request_hash, status_code, response_body, created_at
+		FROM idempotency_keys WHERE user_id = $1 AND key = $2`,
+		userID, key,
+	).Scan(&existing.RequestHash, &statusCode, &existing.ResponseBody, &existing.CreatedAt)
+	if err != nil {
+		return IdempotencyRecord{}, err
+	}
+	existing.StatusCode = int(statusCode.Int64)
+	return existing, nil
+}
+
+func (s *postgresIdempotencyStore) Complete(ctx context.Context, userID int, key string, statusCode int, body []byte) error {
+	defer s.endClaim(userID, key)
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE idempotency_keys SET status_code = $1, response_body = $2, completed_at = NOW()
+		WHERE user_id = $3 AND key = $4`,
+		statusCode, body, userID, key,
+	)
+	if err != nil {
+		return err
+	}
+	return rowsAffectedOrNotFound(result)
+}
+
+func (s *postgresIdempotencyStore) Release(ctx context.Context, userID int, key string) error {
+	defer s.endClaim(userID, key)
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND status_code IS NULL`,
+		userID, key,
+	)
+	return err
+}
+
+// endClaim drops the advisory lock taken by Begin.
+func (s *postgresIdempotencyStore) endClaim(userID int, key string) {
+	k := idempotencyKey(userID, key)
+	s.mu.Lock()
+	conn := s.claims[k]
+	delete(s.claims, k)
+	s.mu.Unlock()
+	if conn != nil {
+		releaseAdvisoryLock(conn, userID, key)
+	}
+}
+
+func releaseAdvisoryLock(conn *sql.Conn, userID int, key string) {
+	_, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1, hashtext($2))`, userID, key)
+	if err != nil {
+		// Discard the connection rather than return it to the pool with
+		// the lock still held.
+		conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	}
+	conn.Close()
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+type postgresLoginAttemptStore struct {
+	db *sql.DB
+}
+
+func (s *postgresLoginAttemptStore) Record(ctx context.Context, attempt *models.LoginAttempt) error {
+	return s.db.QueryRowContext(ctx, `
+		INSERT INTO login_attempts (email, ip, user_id, result)
+		VALUES ($1, $2, NULLIF($3, 0), $4) RETURNING id, created_at`,
+		attempt.Email, attempt.IP, attempt.UserID, attempt.Result,
+	).Scan(&attempt.ID, &attempt.CreatedAt)
+}
+
+func (s *postgresLoginAttemptStore) Failures(ctx context.Context, email, ip string, since time.Time) (LoginFailures, error) {
+	var f LoginFailures
+	var accountLast, ipLast sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), MAX(created_at) FROM login_attempts
+		WHERE email = $1 AND result = 'failed' AND created_at > $2
+		  AND created_at > COALESCE(
+		      (SELECT MAX(created_at) FROM login_attempts WHERE email = $1 AND result = 'succeeded'),
+		      '-infinity')`, email, since,
+	).Scan(&f.Account, &accountLast)
+	if err != nil {
+		return f, err
+	}
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), MAX(created_at) FROM login_attempts
+		WHERE ip = $1 AND result = 'failed' AND created_at > $2`, ip, since,
+	).Scan(&f.IP, &ipLast)
+	if err != nil {
+		return f, err
+	}
+	f.AccountLast, f.IPLast = accountLast.Time, ipLast.Time
+	return f, nil
+}
@@ -0,0 +1,458 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+type postgresOrderStore struct {
+	db *sql.DB
+}
+
+func (s *postgresOrderStore) Create(ctx context.Context, order *models.Order) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		// session_id is attached once the checkout session exists and
+		// payment_id stays NULL until the payment completes
+		var addressID int
+		var name, street, city, postalCode, phone sql.NullString
+		if a := order.Address; a != nil {
+			addressID = a.AddressID
+			name = sql.NullString{String: a.Name, Valid: true}
+			street = sql.NullString{String: a.Street, Valid: true}
+			city = sql.NullString{String: a.City, Valid: true}
+			postalCode = sql.NullString{String: a.PostalCode, Valid: true}
+			phone = sql.NullString{String: a.Phone, Valid: true}
+		}
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO orders (user_id, cart_id, restaurant_id, session_id, total_amount, currency, status,
+				address_id, delivery_name, delivery_street, delivery_city, delivery_postal_code, delivery_phone,
+				created_at, updated_at)
+			VALUES ($1, NULLIF($2, 0), NULLIF($3, 0), NULLIF($4, ''), $5, $6, $7, NULLIF($8, 0), $9, $10, $11, $12, $13, NOW(), NOW())
+			RETURNING order_id, created_at, updated_at`,
+			order.UserID, order.CartID, order.RestaurantID, order.SessionID, order.TotalAmount, order.Currency, order.Status,
+			addressID, name, street, city, postalCode, phone,
+		).Scan(&order.OrderID, &order.CreatedAt, &order.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range order.Items {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO order_items (order_id, item_id, quantity, price, created_at)
+				VALUES ($1, $2, $3, $4, NOW())`,
+				order.OrderID, item.ID, item.Quantity, item.Price,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return insertStatusChange(ctx, tx, models.OrderStatusChange{
+			OrderID:     order.OrderID,
+			ToStatus:    order.Status,
+			Actor:       models.ActorCustomer,
+			ActorUserID: order.UserID,
+		})
+	})
+}
+
+func insertStatusChange(ctx context.Context, tx *sql.Tx, change models.OrderStatusChange) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO order_status_history (order_id, from_status, to_status, actor, actor_user_id, note, created_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, 0), $6, NOW())`,
+		change.OrderID, change.FromStatus, change.ToStatus, change.Actor, change.ActorUserID, change.Note,
+	)
+	return err
+}
+
+func (s *postgresOrderStore) AttachSession(ctx context.Context, orderID int, sessionID string) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE orders SET session_id = $1, updated_at = NOW() WHERE order_id = $2`,
+		sessionID, orderID,
+	)
+	if err != nil {
+		return err
+	}
+	return rowsAffectedOrNotFound(result)
+}
+
+func (s *postgresOrderStore) ApplyPaymentUpdate(ctx context.Context, update PaymentUpdate) (*models.OrderStatusChange, error) {
+	var applied *models.OrderStatusChange
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		column, key := "order_id", interface{}(update.OrderID)
+		switch {
+		case update.OrderID != 0:
+		case update.SessionID != "":
+			column, key = "session_id", update.SessionID
+		case update.PaymentID != "":
+			column, key = "payment_id", update.PaymentID
+		default:
+			return ErrNotFound
+		}
+
+		// Locking the order serialises concurrent deliveries for it, so the
+		// duplicate check below cannot race.
+		var orderID, userID int
+		var cartID sql.NullInt64
+		var status string
+		err := tx.QueryRowContext(ctx,
+			`SELECT order_id, user_id, cart_id, status FROM orders WHERE `+column+` = $1 ORDER BY order_id DESC LIMIT 1 FOR UPDATE`,
+			key,
+		).Scan(&orderID, &userID, &cartID, &status)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if update.EventID != "" {
+			result, err := tx.ExecContext(ctx, `
+				INSERT INTO payment_events (event_id, event_type, order_id)
+				VALUES ($1, $2, $3) ON CONFLICT (event_id) DO NOTHING`,
+				update.EventID, update.EventType, orderID,
+			)
+			if err != nil {
+				return err
+			}
+			if err := rowsAffectedOrNotFound(result); errors.Is(err, ErrNotFound) {
+				return nil // already processed
+			} else if err != nil {
+				return err
+			}
+		}
+
+		next := update.Status
+		if len(update.Refunds) > 0 {
+			for _, refund := range update.Refunds {
+				refund.OrderID = orderID
+				if err := upsertRefund(ctx, tx, &refund); err != nil {
+					return err
+				}
+			}
+			if next, err = refundedStatus(ctx, tx, orderID); err != nil {
+				return err
+			}
+		}
+		if !models.CanTransitionOrder(status, next, models.ActorSystem) {
+			return nil
+		}
+
+		change := models.OrderStatusChange{
+			OrderID:    orderID,
+			FromStatus: status,
+			ToStatus:   next,
+			Actor:      models.ActorSystem,
+			Note:       update.EventType,
+		}
+		err = tx.QueryRowContext(ctx, `
+			UPDATE orders SET status = $1, payment_id = COALESCE(NULLIF($2, ''), payment_id), updated_at = NOW()
+			WHERE order_id = $3 RETURNING updated_at`,
+			next, update.PaymentID, orderID,
+		).Scan(&change.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if err := insertStatusChange(ctx, tx, change); err != nil {
+			return err
+		}
+
+		if update.DeactivateCart {
+			// Orders checked out from a cart close that cart; older orders
+			// close whatever cart the user has open.
+			if _, err := tx.ExecContext(ctx,
+				"UPDATE cart SET is_active = false WHERE user_id = $1 AND ($2::int IS NULL OR id = $2)",
+				userID, cartID,
+			); err != nil {
+				return err
+			}
+		}
+		applied = &change
+		return nil
+	})
+	return applied, err
+}
+
+func (s *postgresOrderStore) Transition(ctx context.Context, change *models.OrderStatusChange) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			"SELECT status FROM orders WHERE order_id = $1 FOR UPDATE", change.OrderID,
+		).Scan(&change.FromStatus)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !models.CanTransitionOrder(change.FromStatus, change.ToStatus, change.Actor) {
+			return &TransitionError{From: change.FromStatus, To: change.ToStatus, Actor: change.Actor}
+		}
+
+		err = tx.QueryRowContext(ctx,
+			"UPDATE orders SET status = $1, updated_at = NOW() WHERE order_id = $2 RETURNING updated_at",
+			change.ToStatus, change.OrderID,
+		).Scan(&change.CreatedAt)
+		if err != nil {
+			return err
+		}
+		return insertStatusChange(ctx, tx, *change)
+	})
+}
+
+func (s *postgresOrderStore) AddRefund(ctx context.Context, refund *models.Refund) (*models.OrderStatusChange, error) {
+	var applied *models.OrderStatusChange
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		var status string
+		err := tx.QueryRowContext(ctx,
+			"SELECT status FROM orders WHERE order_id = $1 FOR UPDATE", refund.OrderID,
+		).Scan(&status)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := upsertRefund(ctx, tx, refund); err != nil {
+			return err
+		}
+		if refund.Status == models.RefundFailed || refund.Status == models.RefundCanceled {
+			return nil
+		}
+
+		next, err := refundedStatus(ctx, tx, refund.OrderID)
+		if err != nil {
+			return err
+		}
+		if !models.CanTransitionOrder(status, next, models.ActorSystem) {
+			return nil
+		}
+		change := models.OrderStatusChange{
+			OrderID:    refund.OrderID,
+			FromStatus: status,
+			ToStatus:   next,
+			Actor:      models.ActorSystem,
+			Note:       "refund " + refund.ProviderRefundID,
+		}
+		err = tx.QueryRowContext(ctx,
+			"UPDATE orders SET status = $1, updated_at = NOW() WHERE order_id = $2 RETURNING updated_at",
+			next, refund.OrderID,
+		).Scan(&change.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if err := insertStatusChange(ctx, tx, change); err != nil {
+			return err
+		}
+		applied = &change
+		return nil
+	})
+	return applied, err
+}
+
+// upsertRefund records refund, or, when the provider's webhook or the API
+// response recorded it first, fills in what the other one left out.
+func upsertRefund(ctx context.Context, tx *sql.Tx, refund *models.Refund) error {
+	return tx.QueryRowContext(ctx, `
+		INSERT INTO refunds (order_id, provider_refund_id, amount, status, reason, actor_user_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), NOW())
+		ON CONFLICT (provider_refund_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			reason = CASE WHEN EXCLUDED.reason = '' THEN refunds.reason ELSE EXCLUDED.reason END,
+			actor_user_id = COALESCE(EXCLUDED.actor_user_id, refunds.actor_user_id)
+		RETURNING id, created_at`,
+		refund.OrderID, refund.ProviderRefundID, refund.Amount, refund.Status, refund.Reason, refund.ActorUserID,
+	).Scan(&refund.ID, &refund.CreatedAt)
+}
+
+// refundedStatus returns the status an order's refunds put it in, or "" when
+// none of them returned any money.
+func refundedStatus(ctx context.Context, tx *sql.Tx, orderID int) (string, error) {
+	var anyRefunded, fullyRefunded bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(r.id) > 0, o.total_amount <= COALESCE(SUM(r.amount), 0)
+		FROM orders o
+		LEFT JOIN refunds r ON r.order_id = o.order_id AND r.status NOT IN ($2, $3)
+		WHERE o.order_id = $1
+		GROUP BY o.order_id`,
+		orderID, models.RefundFailed, models.RefundCanceled,
+	).Scan(&anyRefunded, &fullyRefunded)
+	switch {
+	case err != nil:
+		return "", err
+	case fullyRefunded:
+		return models.OrderRefunded, nil
+	case anyRefunded:
+		return models.OrderPartiallyRefunded, nil
+	default:
+		return "", nil
+	}
+}
+
+func (s *postgresOrderStore) Get(ctx context.Context, orderID int) (models.Order, error) {
+	orders, err := s.query(ctx, "o.order_id = $1", 0, orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if len(orders) == 0 {
+		return models.Order{}, ErrNotFound
+	}
+	return orders[0], nil
+}
+
+func (s *postgresOrderStore) List(ctx context.Context, filter OrderFilter) ([]models.Order, error) {
+	conds := []string{"TRUE"}
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+	if filter.UserID != 0 {
+		conds = append(conds, "o.user_id = "+arg(filter.UserID))
+	}
+	if filter.SessionID != "" {
+		conds = append(conds, "o.session_id = "+arg(filter.SessionID))
+	}
+	if len(filter.Statuses) > 0 {
+		conds = append(conds, "o.status = ANY("+arg(pq.Array(filter.Statuses))+")")
+	}
+	if !filter.From.IsZero() {
+		conds = append(conds, "o.created_at >= "+arg(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conds = append(conds, "o.created_at < "+arg(filter.To))
+	}
+	if filter.After != nil {
+		conds = append(conds, "(o.created_at, o.order_id) < ("+arg(filter.After.CreatedAt)+", "+arg(filter.After.OrderID)+")")
+	}
+	return s.query(ctx, strings.Join(conds, " AND "), filter.Limit, args...)
+}
+
+func (s *postgresOrderStore) History(ctx context.Context, orderID int) ([]models.OrderStatusChange, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT order_id, COALESCE(from_status, ''), to_status, actor, COALESCE(actor_user_id, 0), note, created_at
+		FROM order_status_history
+		WHERE order_id = $1
+		ORDER BY created_at, id`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.OrderStatusChange{}
+	for rows.Next() {
+		var change models.OrderStatusChange
+		if err := rows.Scan(&change.OrderID, &change.FromStatus, &change.ToStatus, &change.Actor, &change.ActorUserID, &change.Note, &change.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+	return history, rows.Err()
+}
+
+// query fetches the orders matching where, newest first. limit 0 means no
+// limit.
+func (s *postgresOrderStore) query(ctx context.Context, where string, limit int, args ...interface{}) ([]models.Order, error) {
+	limitSQL := ""
+	if limit > 0 {
+		args = append(args, limit)
+		limitSQL = " LIMIT $" + strconv.Itoa(len(args))
+	}
+	// Query to fetch orders with aggregated order items and food item details
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			o.order_id,
+			o.user_id,
+			o.cart_id,
+			o.restaurant_id,
+			o.total_amount,
+			o.currency,
+			o.status,
+			o.session_id,
+			o.payment_id,
+			o.address_id,
+			o.delivery_name,
+			o.delivery_street,
+			o.delivery_city,
+			o.delivery_postal_code,
+			o.delivery_phone,
+			o.created_at,
+			o.updated_at,
+			COALESCE(json_agg(json_build_object(
+				'id', oi.item_id,
+				'quantity', oi.quantity,
+				'price', oi.price,
+				'name', fi.name,
+				'cloudimageid', fi.cloudimageid
+			)) FILTER (WHERE oi.item_id IS NOT NULL), '[]') AS items,
+			COALESCE((
+				SELECT json_agg(json_build_object(
+					'id', r.id,
+					'order_id', r.order_id,
+					'provider_refund_id', r.provider_refund_id,
+					'amount', r.amount,
+					'status', r.status,
+					'reason', r.reason,
+					'actor_user_id', r.actor_user_id,
+					'created_at', r.created_at
+				) ORDER BY r.id)
+				FROM refunds r WHERE r.order_id = o.order_id
+			), '[]') AS refunds
+		FROM orders o
+		LEFT JOIN order_items oi ON o.order_id = oi.order_id
+		LEFT JOIN FoodItems fi ON oi.item_id = fi.id
+		WHERE `+where+`
+		GROUP BY o.order_id
+		ORDER BY o.created_at DESC, o.order_id DESC`+limitSQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []models.Order{}
+	for rows.Next() {
+		var order models.Order
+		var cartID, restaurantID, addressID sql.NullInt64
+		var currency, status, sessionID, paymentID sql.NullString
+		var name, street, city, postalCode, phone sql.NullString
+		var itemsJSON, refundsJSON []byte
+
+		if err := rows.Scan(&order.OrderID, &order.UserID, &cartID, &restaurantID, &order.TotalAmount, &currency, &status, &sessionID, &paymentID,
+			&addressID, &name, &street, &city, &postalCode, &phone,
+			&order.CreatedAt, &order.UpdatedAt, &itemsJSON, &refundsJSON); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(itemsJSON, &order.Items); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(refundsJSON, &order.Refunds); err != nil {
+			return nil, err
+		}
+		order.CartID = int(cartID.Int64)
+		order.RestaurantID = int(restaurantID.Int64)
+		order.Currency = currency.String
+		order.Status = status.String
+		order.SessionID = sessionID.String
+		order.PaymentID = paymentID.String
+		if street.Valid {
+			order.Address = &models.DeliveryAddress{
+				AddressID:  int(addressID.Int64),
+				Name:       name.String,
+				Street:     street.String,
+				City:       city.String,
+				PostalCode: postalCode.String,
+				Phone:      phone.String,
+			}
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
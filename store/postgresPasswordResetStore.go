@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+type postgresPasswordResetStore struct {
+	db *sql.DB
+}
+
+func (s *postgresPasswordResetStore) Create(ctx context.Context, reset *models.PasswordReset) error {
+	return s.db.QueryRowContext(ctx, `
+		INSERT INTO password_resets (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3) RETURNING id, created_at`,
+		reset.UserID, reset.TokenHash, reset.ExpiresAt,
+	).Scan(&reset.ID, &reset.CreatedAt)
+}
+
+func (s *postgresPasswordResetStore) LastSentAt(ctx context.Context, userID int) (time.Time, error) {
+	var last sql.NullTime
+	err := s.db.QueryRowContext(ctx, `SELECT MAX(created_at) FROM password_resets WHERE user_id = $1`, userID).Scan(&last)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !last.Valid {
+		return time.Time{}, ErrNotFound
+	}
+	return last.Time, nil
+}
+
+func (s *postgresPasswordResetStore) Redeem(ctx context.Context, tokenHash, passwordHash string) (int, error) {
+	var userID int
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			UPDATE password_resets SET used_at = NOW()
+			WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+			RETURNING user_id`, tokenHash,
+		).Scan(&userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		// Links sent before this one stop working too.
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE password_resets SET used_at = NOW()
+			WHERE user_id = $1 AND used_at IS NULL`, userID); err != nil {
+			return err
+		}
+
+		_, err = updatePassword(ctx, tx, userID, passwordHash)
+		return err
+	})
+	return userID, err
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+type postgresPhoneOTPStore struct {
+	db *sql.DB
+}
+
+func (s *postgresPhoneOTPStore) Create(ctx context.Context, otp *models.PhoneOTP) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE phone_otps SET used_at = NOW()
+			WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`, otp.UserID, otp.Purpose)
+		if err != nil {
+			return err
+		}
+		return tx.QueryRowContext(ctx, `
+			INSERT INTO phone_otps (user_id, phone, purpose, code_hash, expires_at)
+			VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`,
+			otp.UserID, otp.Phone, otp.Purpose, otp.CodeHash, otp.ExpiresAt,
+		).Scan(&otp.ID, &otp.CreatedAt)
+	})
+}
+
+func (s *postgresPhoneOTPStore) LastSentAt(ctx context.Context, userID int, purpose string) (time.Time, error) {
+	var last sql.NullTime
+	err := s.db.QueryRowContext(ctx, `SELECT MAX(created_at) FROM phone_otps WHERE user_id = $1 AND purpose = $2`, userID, purpose).Scan(&last)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !last.Valid {
+		return time.Time{}, ErrNotFound
+	}
+	return last.Time, nil
+}
+
+func (s *postgresPhoneOTPStore) Check(ctx context.Context, userID int, purpose, codeHash string, maxAttempts int) error {
+	// A wrong guess is an error for the caller but must still be committed.
+	var result error
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		var otp models.PhoneOTP
+		err := tx.QueryRowContext(ctx, `
+			SELECT id, phone, code_hash, attempts FROM phone_otps
+			WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+			ORDER BY id DESC LIMIT 1 FOR UPDATE`, userID, purpose,
+		).Scan(&otp.ID, &otp.Phone, &otp.CodeHash, &otp.Attempts)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if otp.Attempts >= maxAttempts {
+			return ErrTooManyAttempts
+		}
+		if subtle.ConstantTimeCompare([]byte(otp.CodeHash), []byte(codeHash)) != 1 {
+			_, err := tx.ExecContext(ctx, `UPDATE phone_otps SET attempts = attempts + 1 WHERE id = $1`, otp.ID)
+			result = ErrCodeMismatch
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE phone_otps SET used_at = NOW() WHERE id = $1`, otp.ID); err != nil {
+			return err
+		}
+		verified, err := tx.ExecContext(ctx, `UPDATE users SET phone_verified = TRUE WHERE id = $1 AND phone = $2`, userID, otp.Phone)
+		if err != nil {
+			return err
+		}
+		return rowsAffectedOrNotFound(verified)
+	})
+	if err != nil {
+		return err
+	}
+	return result
+}
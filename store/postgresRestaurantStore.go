@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+var fetchRestaurantsList = "SELECT id, name, rating, cuisine, deliverytime, offers, locality, cloudimageid, costfortwo, veg FROM restaurantsdata WHERE city = $1"
+var fetchCitiesQuery = "select distinct city from restaurantsdata"
+
+type postgresRestaurantStore struct {
+	db *sql.DB
+}
+
+func (s *postgresRestaurantStore) ListByCity(ctx context.Context, city string) ([]models.Restaurants, error) {
+	rows, err := s.db.QueryContext(ctx, fetchRestaurantsList, city)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var restaurants []models.Restaurants
+	for rows.Next() {
+		var r models.Restaurants
+		if err := rows.Scan(&r.Id, &r.Name, &r.Rating, &r.Cuisine, &r.DeliveryTime, &r.Offers, &r.Locality, &r.CloudImageID, &r.CostForTwo, &r.Veg); err != nil {
+			return nil, err
+		}
+		restaurants = append(restaurants, r)
+	}
+	return restaurants, rows.Err()
+}
+
+func (s *postgresRestaurantStore) ListCities(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, fetchCitiesQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cities []string
+	for rows.Next() {
+		var city string
+		if err := rows.Scan(&city); err != nil {
+			return nil, err
+		}
+		cities = append(cities, city)
+	}
+	return cities, rows.Err()
+}
+
+func (s *postgresRestaurantStore) AddOwner(ctx context.Context, restaurantID, userID int) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO restaurant_owners (restaurant_id, user_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, restaurantID, userID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *postgresRestaurantStore) RemoveOwner(ctx context.Context, restaurantID, userID int) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM restaurant_owners WHERE restaurant_id = $1 AND user_id = $2", restaurantID, userID)
+	if err != nil {
+		return err
+	}
+	return rowsAffectedOrNotFound(result)
+}
+
+func (s *postgresRestaurantStore) OwnsAny(ctx context.Context, userID int, restaurantIDs []int) (bool, error) {
+	var owns bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM restaurant_owners WHERE user_id = $1 AND restaurant_id = ANY($2))`,
+		userID, pq.Array(restaurantIDs),
+	).Scan(&owns)
+	return owns, err
+}
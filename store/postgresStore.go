@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+// NewPostgres returns a Store backed by the shared connection pool.
+func NewPostgres(db *sql.DB) *Store {
+	return &Store{
+		Users:          &postgresUserStore{db: db},
+		PasswordResets: &postgresPasswordResetStore{db: db},
+		Verifications:  &postgresEmailVerificationStore{db: db},
+		PhoneOTPs:      &postgresPhoneOTPStore{db: db},
+		LoginAttempts:  &postgresLoginAttemptStore{db: db},
+		Restaurants:    &postgresRestaurantStore{db: db},
+		Food:           &postgresFoodStore{db: db},
+		Carts:          &postgresCartStore{db: db},
+		Addresses:      &postgresAddressStore{db: db},
+		Orders:         &postgresOrderStore{db: db},
+		Idempotency:    newPostgresIdempotencyStore(db),
+	}
+}
+
+// querier is what *sql.DB and *sql.Tx have in common, for queries that run
+// both on their own and inside a transaction.
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// withTx runs fn inside a transaction, committing when it returns nil and
+// rolling back otherwise.
+func withTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+			log.Printf("Transaction rollback due to error: %v", err)
+		} else {
+			err = tx.Commit()
+		}
+	}()
+	return fn(tx)
+}
+
+func rowsAffectedOrNotFound(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+type postgresUserStore struct {
+	db *sql.DB
+}
+
+func (s *postgresUserStore) Create(ctx context.Context, user *models.User) error {
+	query := "INSERT INTO users (name, email, phone, password) VALUES ($1, $2, $3, $4) RETURNING id, role, email_verified, phone_verified"
+	err := s.db.QueryRowContext(ctx, query, user.Name, user.Email, user.Phone, user.Password).Scan(&user.Id, &user.Role, &user.EmailVerified, &user.PhoneVerified)
+	return mapUniqueViolation(err)
+}
+
+func (s *postgresUserStore) GetByID(ctx context.Context, id int) (models.User, error) {
+	return s.getBy(ctx, "id", id)
+}
+
+func (s *postgresUserStore) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	return s.getBy(ctx, "email", email)
+}
+
+func (s *postgresUserStore) GetByPhone(ctx context.Context, phone string) (models.User, error) {
+	return s.getBy(ctx, "phone", phone)
+}
+
+// getBy looks a user up by one of the users table's unique columns.
+func (s *postgresUserStore) getBy(ctx context.Context, column string, value interface{}) (models.User, error) {
+	var user models.User
+	query := "SELECT id, name, email, password, phone, role, email_verified, phone_verified, session_version FROM users WHERE " + column + " = $1"
+	err := s.db.QueryRowContext(ctx, query, value).Scan(&user.Id, &user.Name, &user.Email, &user.Password, &user.Phone, &user.Role,
+		&user.EmailVerified, &user.PhoneVerified, &user.SessionVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, ErrNotFound
+	}
+	return user, err
+}
+
+func (s *postgresUserStore) UpdateProfile(ctx context.Context, user models.User) error {
+	query := `
+		UPDATE users
+		SET name = $1, email = $2, phone = $3,
+			email_verified = email_verified AND email = $2,
+			phone_verified = phone_verified AND phone = $3
+		WHERE id = $4`
+	result, err := s.db.ExecContext(ctx, query, user.Name, user.Email, user.Phone, user.Id)
+	if err != nil {
+		return mapUniqueViolation(err)
+	}
+	return rowsAffectedOrNotFound(result)
+}
+
+func (s *postgresUserStore) SetRole(ctx context.Context, userID int, role string) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE users SET role = $1 WHERE id = $2", role, userID)
+	if err != nil {
+		return err
+	}
+	return rowsAffectedOrNotFound(result)
+}
+
+func (s *postgresUserStore) UpdatePassword(ctx context.Context, userID int, passwordHash string) (int, error) {
+	return updatePassword(ctx, s.db, userID, passwordHash)
+}
+
+// updatePassword is shared with password resets, which run it inside their
+// transaction.
+func updatePassword(ctx context.Context, q querier, userID int, passwordHash string) (int, error) {
+	var version int
+	err := q.QueryRowContext(ctx, `
+		UPDATE users SET password = $1, session_version = session_version + 1
+		WHERE id = $2 RETURNING session_version`, passwordHash, userID,
+	).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	return version, err
+}
+
+// mapUniqueViolation translates the users table's unique constraints into
+// store errors the handlers can report to the client.
+func mapUniqueViolation(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		switch pqErr.Constraint {
+		case "users_email_key":
+			return ErrEmailTaken
+		case "users_phone_key":
+			return ErrPhoneTaken
+		}
+	}
+	return err
+}
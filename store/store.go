@@ -0,0 +1,274 @@
+// Package store is the persistence layer. Handlers talk to the interfaces
+// declared here; the Postgres implementation carries the production queries
+// and the in-memory implementation backs handler tests.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vishal-sharma-001/FoodHaven-Backend/models"
+)
+
+var (
+	ErrNotFound   = errors.New("store: not found")
+	ErrForbidden  = errors.New("store: not owned by user")
+	ErrEmailTaken = errors.New("store: email already registered")
+	ErrPhoneTaken = errors.New("store: phone already registered")
+
+	// ErrCodeMismatch and ErrTooManyAttempts are returned by
+	// PhoneOTPStore.Check.
+	ErrCodeMismatch    = errors.New("store: code does not match")
+	ErrTooManyAttempts = errors.New("store: too many attempts")
+)
+
+type UserStore interface {
+	// Create inserts the user and sets its Id. Password must already be hashed.
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id int) (models.User, error)
+	GetByEmail(ctx context.Context, email string) (models.User, error)
+	GetByPhone(ctx context.Context, phone string) (models.User, error)
+	// SetRole changes the user's role. Restaurants the user owns stay
+	// recorded but only count while the role is restaurant owner.
+	SetRole(ctx context.Context, userID int, role string) error
+	// UpdateProfile also marks the email or phone unverified when it
+	// changes.
+	UpdateProfile(ctx context.Context, user models.User) error
+	// UpdatePassword replaces the user's password hash and signs them out
+	// of every session by bumping SessionVersion, which it returns.
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) (sessionVersion int, err error)
+}
+
+type PasswordResetStore interface {
+	// Create saves a reset and sets its ID.
+	Create(ctx context.Context, reset *models.PasswordReset) error
+	// LastSentAt returns when the user was last sent a reset, or
+	// ErrNotFound.
+	LastSentAt(ctx context.Context, userID int) (time.Time, error)
+	// Redeem uses up the unexpired, unused reset with tokenHash and every
+	// other reset of the same user, then updates the password as
+	// UserStore.UpdatePassword does. It returns the user's ID, or
+	// ErrNotFound when there is no such reset.
+	Redeem(ctx context.Context, tokenHash, passwordHash string) (userID int, err error)
+}
+
+type EmailVerificationStore interface {
+	// Create saves a verification and sets its ID and CreatedAt.
+	Create(ctx context.Context, verification *models.EmailVerification) error
+	// LastSentAt returns when the user was last sent a verification, or
+	// ErrNotFound.
+	LastSentAt(ctx context.Context, userID int) (time.Time, error)
+	// Verify uses up the unexpired, unused verification with tokenHash and
+	// marks its email verified, provided it is still the user's email. It
+	// returns the user's ID, or ErrNotFound.
+	Verify(ctx context.Context, tokenHash string) (userID int, err error)
+}
+
+type PhoneOTPStore interface {
+	// Create saves a code and sets its ID and CreatedAt. Codes sent to the
+	// user earlier for the same purpose stop working.
+	Create(ctx context.Context, otp *models.PhoneOTP) error
+	// LastSentAt returns when the user was last sent a code for purpose,
+	// or ErrNotFound.
+	LastSentAt(ctx context.Context, userID int, purpose string) (time.Time, error)
+	// Check compares codeHash with the user's current code for purpose. A
+	// match uses the code up and marks the phone verified. A mismatch
+	// counts as an attempt and returns ErrCodeMismatch; once maxAttempts
+	// have been made the code returns ErrTooManyAttempts. ErrNotFound means
+	// there is no current code, or it was sent to a phone the user no
+	// longer has.
+	Check(ctx context.Context, userID int, purpose, codeHash string, maxAttempts int) error
+}
+
+// LoginFailures counts recent failed logins for an account and for a client
+// IP, with when the latest of each happened.
+type LoginFailures struct {
+	Account     int
+	AccountLast time.Time
+	IP          int
+	IPLast      time.Time
+}
+
+type LoginAttemptStore interface {
+	// Record adds attempt to the audit log and sets its ID and CreatedAt.
+	Record(ctx context.Context, attempt *models.LoginAttempt) error
+	// Failures counts failed attempts made after since: for email, only
+	// those after its last successful login; for ip, all of them.
+	Failures(ctx context.Context, email, ip string, since time.Time) (LoginFailures, error)
+}
+
+type RestaurantStore interface {
+	ListByCity(ctx context.Context, city string) ([]models.Restaurants, error)
+	ListCities(ctx context.Context) ([]string, error)
+	// AddOwner records userID as an owner of the restaurant; adding an
+	// existing owner is not an error. It returns ErrNotFound when the
+	// restaurant or user does not exist.
+	AddOwner(ctx context.Context, restaurantID, userID int) error
+	RemoveOwner(ctx context.Context, restaurantID, userID int) error
+	// OwnsAny reports whether userID owns at least one of restaurantIDs.
+	OwnsAny(ctx context.Context, userID int, restaurantIDs []int) (bool, error)
+}
+
+type FoodStore interface {
+	ListByCloudImageID(ctx context.Context, cloudImageID string) ([]models.FoodItems, error)
+	// GetByIDs returns the requested items that exist, keyed by ID.
+	GetByIDs(ctx context.Context, ids []int) (map[int]MenuItem, error)
+}
+
+// MenuItem is a food item together with the restaurant that sells it.
+type MenuItem struct {
+	models.FoodItems
+	RestaurantID int
+}
+
+type CartStore interface {
+	// GetActive returns the user's active cart with its items, or ErrNotFound.
+	GetActive(ctx context.Context, userID int) (models.Cart, error)
+	Create(ctx context.Context, userID int) (models.Cart, error)
+	// Sync replaces the items of an active cart owned by userID.
+	Sync(ctx context.Context, userID, cartID int, items []models.OrderItem) error
+	DeactivateForUser(ctx context.Context, userID int) error
+}
+
+// AddressStore keeps each user with exactly one primary address once they
+// have any: the first address becomes primary, making another primary
+// clears the flag on the rest, and deleting the primary promotes the oldest
+// remaining address.
+type AddressStore interface {
+	List(ctx context.Context, userID int) ([]models.Address, error)
+	// Get returns ErrNotFound unless the address belongs to userID.
+	Get(ctx context.Context, userID, addressID int) (models.Address, error)
+	// Create sets the address's ID, and IsPrimary when it is the user's
+	// first.
+	Create(ctx context.Context, address *models.Address) error
+	// Update can make the address primary but not stop it being so;
+	// IsPrimary is set to the stored value.
+	Update(ctx context.Context, address *models.Address) error
+	SetPrimary(ctx context.Context, userID, addressID int) error
+	Delete(ctx context.Context, userID, addressID int) error
+}
+
+type OrderStore interface {
+	// Create inserts the order with its items and sets OrderID.
+	Create(ctx context.Context, order *models.Order) error
+	// AttachSession records the checkout session created for the order.
+	AttachSession(ctx context.Context, orderID int, sessionID string) error
+	// ApplyPaymentUpdate moves an order to update.Status. It returns the
+	// change it made, nil when the update was a duplicate or the order
+	// has moved past it, and ErrNotFound when no order matches.
+	ApplyPaymentUpdate(ctx context.Context, update PaymentUpdate) (*models.OrderStatusChange, error)
+	// List returns the orders matching filter with their items and
+	// refunds, newest first.
+	List(ctx context.Context, filter OrderFilter) ([]models.Order, error)
+	// Get returns an order with its items and refunds.
+	Get(ctx context.Context, orderID int) (models.Order, error)
+	// History returns an order's status changes, oldest first.
+	History(ctx context.Context, orderID int) ([]models.OrderStatusChange, error)
+	// Transition moves an order to change.ToStatus on behalf of
+	// change.Actor and records it in the order's history. It fills in
+	// FromStatus and CreatedAt, and returns a *TransitionError when the
+	// state machine does not allow the move.
+	Transition(ctx context.Context, change *models.OrderStatusChange) error
+	// AddRefund records a refund issued by the payment provider, filling in
+	// ID and CreatedAt, and moves the order to refunded or
+	// partially_refunded. It returns that status change, or nil when the
+	// refund did not change the order.
+	AddRefund(ctx context.Context, refund *models.Refund) (*models.OrderStatusChange, error)
+}
+
+// OrderFilter selects orders for OrderStore.List. Zero fields do not filter.
+type OrderFilter struct {
+	UserID    int
+	SessionID string
+	Statuses  []string
+	// From and To bound created_at: From <= created_at < To.
+	From, To time.Time
+	// After continues a listing after the order it points at.
+	After *OrderCursor
+	Limit int
+}
+
+// OrderCursor is a position in the newest-first order listing.
+type OrderCursor struct {
+	CreatedAt time.Time
+	OrderID   int
+}
+
+// TransitionError rejects a status change the order state machine does not
+// allow.
+type TransitionError struct {
+	From, To string
+	Actor    models.OrderActor
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("store: %s may not move an order from %s to %s", e.Actor, e.From, e.To)
+}
+
+// PaymentUpdate is a payment outcome, reported by the payment provider's
+// webhook or, when a checkout session could not be created, by checkout
+// itself.
+type PaymentUpdate struct {
+	// EventID, when set, makes the update idempotent: an event that was
+	// already applied is skipped.
+	EventID   string
+	EventType string
+
+	// The order is located by OrderID when non-zero, otherwise by SessionID,
+	// otherwise by PaymentID.
+	OrderID   int
+	SessionID string
+	PaymentID string
+
+	// Status is applied only when the order state machine lets the system
+	// make the move, so late or out-of-order deliveries cannot undo a newer
+	// outcome.
+	Status string
+	// DeactivateCart closes the user's active cart along with the update.
+	DeactivateCart bool
+	// Refunds made through the provider are recorded, or their status
+	// updated when already known, and the order moves to refunded or
+	// partially_refunded to match them; Status is ignored.
+	Refunds []models.Refund
+}
+
+type IdempotencyStore interface {
+	// Begin claims record's key for its user until Complete or Release is
+	// called, or the process holding the claim dies. When the key is
+	// already taken it returns the existing record and false.
+	Begin(ctx context.Context, record IdempotencyRecord) (IdempotencyRecord, bool, error)
+	// Complete stores the response for a claimed key and ends the claim.
+	Complete(ctx context.Context, userID int, key string, statusCode int, body []byte) error
+	// Release ends a claim without storing a response, so the next request
+	// with the key runs again.
+	Release(ctx context.Context, userID int, key string) error
+}
+
+// IdempotencyRecord is a request made with an Idempotency-Key and, once it
+// has finished, its response.
+type IdempotencyRecord struct {
+	UserID      int
+	Key         string
+	RequestHash string
+	// StatusCode is 0 while the request is in progress.
+	StatusCode   int
+	ResponseBody []byte
+	CreatedAt    time.Time
+}
+
+// Store bundles every repository the handlers depend on.
+type Store struct {
+	Users          UserStore
+	PasswordResets PasswordResetStore
+	Verifications  EmailVerificationStore
+	PhoneOTPs      PhoneOTPStore
+	LoginAttempts  LoginAttemptStore
+	Restaurants    RestaurantStore
+	Food           FoodStore
+	Carts          CartStore
+	Addresses      AddressStore
+	Orders         OrderStore
+	Idempotency    IdempotencyStore
+}